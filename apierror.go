@@ -0,0 +1,64 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// Machine-readable codes of error responses. Clients should branch on these
+// rather than on messages, which may change.
+const (
+	codeInvalidRequest       = "invalid_request"
+	codeValidationFailed     = "validation_failed"
+	codeTextTooLong          = "text_too_long"
+	codeBodyTooLarge         = "body_too_large"
+	codeEventNotFound        = "event_not_found"
+	codeEventExists          = "event_exists"
+	codeBatchNotFound        = "batch_not_found"
+	codeMissingAPIKey        = "missing_api_key"
+	codeInvalidAPIKey        = "invalid_api_key"
+	codeInvalidAdminToken    = "invalid_admin_token"
+	codeRateLimited          = "rate_limited"
+	codeIdempotencyMismatch  = "idempotency_key_reused"
+	codeIdempotencyPending   = "idempotency_key_in_progress"
+	codeLanguagesUnavailable = "languages_unavailable"
+	codeDeadlineExceeded     = "deadline_exceeded"
+	codeTranslationFailed    = "translation_failed"
+	codeProviderError        = "provider_error"
+	codeProviderQuota        = "provider_quota_exceeded"
+	codeProviderTimeout      = "provider_timeout"
+	codeUnsupportedLanguage  = "unsupported_language"
+	codeCircuitOpen          = "circuit_open"
+	codeQueueTimeout         = "queue_timeout"
+	codeOriginNotAllowed     = "origin_not_allowed"
+	codeRequestCanceled      = "request_canceled"
+	codeRouteNotFound        = "route_not_found"
+	codeInternal             = "internal_error"
+)
+
+// apiError is the "error" member of every error response.
+type apiError struct {
+	Code    string `json:"code"`
+	Message string `json:"message"`
+
+	// Fields maps each invalid field to what is wrong with it.
+	Fields map[string]string `json:"fields,omitempty"`
+	// ProviderCode is the provider's error code when it reported one.
+	ProviderCode int `json:"providerCode,omitempty"`
+}
+
+// errorResponse is the body of error responses:
+// {"error":{"code":"event_not_found","message":"Event not found"}}.
+type errorResponse struct {
+	Error apiError `json:"error"`
+}
+
+// respondError writes an error response with code and message.
+func respondError(c *gin.Context, status int, code, message string) {
+	c.JSON(status, errorResponse{apiError{Code: code, Message: message}})
+}
+
+// abortWithError writes an error response with code and message and stops
+// the handlers after the calling middleware.
+func abortWithError(c *gin.Context, status int, code, message string) {
+	c.AbortWithStatusJSON(status, errorResponse{apiError{Code: code, Message: message}})
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// failingPersister loads no events and fails every save.
+type failingPersister struct{}
+
+func (failingPersister) load() (map[string]EventInfo, error) { return nil, nil }
+
+func (failingPersister) save(map[string]EventInfo) error { return errors.New("disk full") }
+
+func TestErrorEnvelope(t *testing.T) {
+	tests := []struct {
+		name   string
+		setup  func(t *testing.T)
+		method string
+		target string
+		body   string
+		status int
+		code   string
+	}{
+		{name: "malformed JSON", method: "POST", target: "/event", body: `{"name":`, status: http.StatusBadRequest, code: codeInvalidRequest},
+		{name: "invalid event", method: "POST", target: "/event", body: `{"name":"Concert"}`, status: http.StatusBadRequest, code: codeValidationFailed},
+		{name: "unknown event", method: "GET", target: "/event?id=missing", status: http.StatusNotFound, code: codeEventNotFound},
+		{name: "unknown batch", method: "GET", target: "/batch/missing", status: http.StatusNotFound, code: codeBatchNotFound},
+		{name: "unknown route", method: "GET", target: "/nowhere", status: http.StatusNotFound, code: codeRouteNotFound},
+		{
+			name: "existing event",
+			setup: func(t *testing.T) {
+				createEvent(t, newRouter(), mustJSON(EventInfo{ID: "taken", Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr"}}))
+			},
+			method: "POST", target: "/event",
+			body:   mustJSON(EventInfo{ID: "taken", Name: "Gala", Location: "Hall", Details: "Music", Languages: []string{"fr"}}),
+			status: http.StatusConflict, code: codeEventExists,
+		},
+		{
+			name: "translation failure",
+			setup: func(t *testing.T) {
+				useFakeProvider(func(text, from, to string) (string, error) { return "", errors.New("boom") })
+			},
+			method: "POST", target: "/event", body: eventBody("Concert", "fr"),
+			status: http.StatusInternalServerError, code: codeTranslationFailed,
+		},
+		{
+			name: "store failure",
+			setup: func(t *testing.T) {
+				store, err := newPersistentEventStore(failingPersister{})
+				if err != nil {
+					t.Fatal(err)
+				}
+				events = store
+			},
+			method: "POST", target: "/event", body: eventBody("Concert", "fr"),
+			status: http.StatusInternalServerError, code: codeInternal,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, "TRANSLATOR_MAX_RETRIES", "0")
+			if tt.setup != nil {
+				tt.setup(t)
+			}
+			w := serveRequest(newRouter(), tt.method, tt.target, tt.body)
+			if w.Code != tt.status {
+				t.Fatalf("status %d, body %s, want %d", w.Code, w.Body, tt.status)
+			}
+			var body map[string]json.RawMessage
+			decodeJSON(t, w, &body)
+			if _, ok := body["message"]; ok {
+				t.Errorf("body %s has a top-level message", w.Body)
+			}
+			var apiErr apiError
+			if err := json.Unmarshal(body["error"], &apiErr); err != nil {
+				t.Fatalf("error member of %s: %v", w.Body, err)
+			}
+			if apiErr.Code != tt.code || apiErr.Message == "" {
+				t.Errorf("error %+v, want code %q and a message", apiErr, tt.code)
+			}
+		})
+	}
+}
+
+func TestCanceledRequestEnvelope(t *testing.T) {
+	setupTest(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	req := httptest.NewRequest("POST", "/event", strings.NewReader(eventBody("Concert", "fr"))).WithContext(ctx)
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	newRouter().ServeHTTP(w, req)
+
+	var body errorResponse
+	decodeJSON(t, w, &body)
+	if w.Code != statusClientClosedRequest || body.Error.Code != codeRequestCanceled {
+		t.Errorf("canceled request: status %d, body %s, want %d %s", w.Code, w.Body, statusClientClosedRequest, codeRequestCanceled)
+	}
+}
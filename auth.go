@@ -0,0 +1,47 @@
+package main
+
+import (
+	"crypto/subtle"
+	"github.com/gin-gonic/gin"
+	"net/http"
+)
+
+// apiKeyContextKey holds a request's X-API-Key once requireAPIKey has
+// accepted it.
+const apiKeyContextKey = "apiKey"
+
+// requireAPIKey rejects requests without an X-API-Key header with 401 and
+// requests whose key is not one of keys with 403. Several keys may be valid
+// at once so clients can rotate theirs independently.
+func requireAPIKey(keys []string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-API-Key")
+		if provided == "" {
+			abortWithError(c, http.StatusUnauthorized, codeMissingAPIKey, "Missing API key")
+			return
+		}
+		valid := 0
+		for _, key := range keys {
+			valid |= subtle.ConstantTimeCompare([]byte(provided), []byte(key))
+		}
+		if valid != 1 {
+			abortWithError(c, http.StatusForbidden, codeInvalidAPIKey, "Invalid API key")
+			return
+		}
+		c.Set(apiKeyContextKey, provided)
+		c.Next()
+	}
+}
+
+// requireAdminToken rejects requests whose X-Admin-Token header does not
+// match token. An empty token disables the guarded endpoints entirely.
+func requireAdminToken(token string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		provided := c.GetHeader("X-Admin-Token")
+		if token == "" || provided == "" || subtle.ConstantTimeCompare([]byte(provided), []byte(token)) != 1 {
+			abortWithError(c, http.StatusUnauthorized, codeInvalidAdminToken, "Invalid admin token")
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestAPIKeyRequiredForWrites(t *testing.T) {
+	setupTest(t, "API_KEYS", "old-key,new-key")
+	r := newRouter()
+
+	tests := []struct {
+		name   string
+		header []string
+		status int
+		code   string
+	}{
+		{"missing key", nil, http.StatusUnauthorized, codeMissingAPIKey},
+		{"invalid key", []string{"X-API-Key", "wrong"}, http.StatusForbidden, codeInvalidAPIKey},
+		{"first key", []string{"X-API-Key", "old-key"}, http.StatusCreated, ""},
+		{"second key", []string{"X-API-Key", "new-key"}, http.StatusCreated, ""},
+	}
+	for _, tt := range tests {
+		w := serveRequest(r, "POST", "/event", eventBody("Concert "+tt.name, "fr"), tt.header...)
+		if w.Code != tt.status {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.status)
+			continue
+		}
+		if tt.code != "" {
+			var res errorResponse
+			decodeJSON(t, w, &res)
+			if res.Error.Code != tt.code {
+				t.Errorf("%s: error code %q, want %q", tt.name, res.Error.Code, tt.code)
+			}
+		}
+	}
+	if n := len(events.list()); n != 2 {
+		t.Fatalf("stored %d events, want 2", n)
+	}
+}
+
+func TestAPIKeyNotRequiredForReads(t *testing.T) {
+	setupTest(t, "API_KEYS", "key")
+	if w := serveRequest(newRouter(), "GET", "/events", ""); w.Code != http.StatusOK {
+		t.Fatalf("GET /events: status %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestAPIKeyRequiredForReads(t *testing.T) {
+	setupTest(t, "API_KEYS", "key", "API_KEYS_FOR_READS", "true")
+	r := newRouter()
+	if w := serveRequest(r, "GET", "/events", ""); w.Code != http.StatusUnauthorized {
+		t.Fatalf("GET /events without a key: status %d, want %d", w.Code, http.StatusUnauthorized)
+	}
+	if w := serveRequest(r, "GET", "/events", "", "X-API-Key", "key"); w.Code != http.StatusOK {
+		t.Fatalf("GET /events with a key: status %d, want %d", w.Code, http.StatusOK)
+	}
+}
+
+func TestNoAPIKeysLeavesAPIOpen(t *testing.T) {
+	setupTest(t)
+	createEvent(t, newRouter(), eventBody("Concert", "fr"))
+}
@@ -0,0 +1,307 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"time"
+)
+
+type TranslationRequest struct {
+	Text string `json:"Text"`
+}
+
+type TranslationResponse struct {
+	Translations []struct {
+		Text string `json:"text"`
+	} `json:"translations"`
+}
+
+// azureProvider translates through Azure Cognitive Services Translator.
+type azureProvider struct {
+	endpoint        string
+	apiVersion      string
+	subscriptionKey string
+	region          string
+	client          *http.Client
+}
+
+func newAzureProvider(cfg Config, client *http.Client) *azureProvider {
+	return &azureProvider{
+		endpoint:        cfg.Endpoint,
+		apiVersion:      cfg.AzureAPIVersion,
+		subscriptionKey: cfg.SubscriptionKey,
+		region:          cfg.Region,
+		client:          client,
+	}
+}
+
+func (p *azureProvider) Translate(ctx context.Context, text, from, to string, opts TranslateOptions) (string, error) {
+	translated, err := p.TranslateBatch(ctx, []string{text}, from, to, opts)
+	if err != nil {
+		return "", err
+	}
+	return translated[0], nil
+}
+
+// TranslateBatch sends every text as its own element of a single request.
+// Azure accepts up to 1000 elements per call.
+func (p *azureProvider) TranslateBatch(ctx context.Context, texts []string, from, to string, opts TranslateOptions) ([]string, error) {
+	candidates, err := p.TranslateAlternatives(ctx, texts, from, to, opts)
+	if err != nil {
+		return nil, err
+	}
+	translated := make([]string, len(candidates))
+	for i, entries := range candidates {
+		translated[i] = entries[0]
+	}
+	return translated, nil
+}
+
+// TranslateAlternatives returns every translation entry Azure sent for each
+// text, in the order received.
+func (p *azureProvider) TranslateAlternatives(ctx context.Context, texts []string, from, to string, opts TranslateOptions) ([][]string, error) {
+	body := make([]TranslationRequest, len(texts))
+	for i, text := range texts {
+		body[i] = TranslationRequest{Text: text}
+	}
+	var res []TranslationResponse
+	if err := p.post(ctx, p.translateURL(from, to, opts), body, &res); err != nil {
+		return nil, err
+	}
+
+	candidates := make([][]string, len(res))
+	for i, item := range res {
+		if len(item.Translations) == 0 {
+			return nil, fmt.Errorf("%w: no translations found", ErrTranslationDecode)
+		}
+		for _, translation := range item.Translations {
+			candidates[i] = append(candidates[i], translation.Text)
+		}
+	}
+	if len(candidates) == 0 {
+		return nil, fmt.Errorf("%w: no translations found", ErrTranslationDecode)
+	}
+	return candidates, nil
+}
+
+func (p *azureProvider) translateURL(from, to string, opts TranslateOptions) string {
+	uri := p.endpoint + "/translate?api-version=" + p.apiVersion + "&to=" + to
+	if from != "" {
+		uri += "&from=" + from
+	}
+	if opts.isHTML() {
+		uri += "&textType=html"
+	}
+	if opts.ProfanityAction != "" && opts.ProfanityAction != "NoAction" {
+		uri += "&profanityAction=" + opts.ProfanityAction
+	}
+	if opts.Category != "" {
+		uri += "&category=" + opts.Category
+	}
+	return uri
+}
+
+type transliterationResponse struct {
+	Text   string `json:"text"`
+	Script string `json:"script"`
+}
+
+func (p *azureProvider) Transliterate(ctx context.Context, text, language, fromScript, toScript string) (string, error) {
+	uri := p.endpoint + "/transliterate?api-version=" + p.apiVersion + "&language=" + language +
+		"&fromScript=" + fromScript + "&toScript=" + toScript
+
+	var res []transliterationResponse
+	if err := p.post(ctx, uri, []TranslationRequest{{Text: text}}, &res); err != nil {
+		return "", err
+	}
+	if len(res) == 0 {
+		return "", fmt.Errorf("%w: no transliterations found", ErrTranslationDecode)
+	}
+	return res[0].Text, nil
+}
+
+type detectResponse struct {
+	Language string  `json:"language"`
+	Score    float64 `json:"score"`
+}
+
+func (p *azureProvider) Detect(ctx context.Context, text string) (string, float64, error) {
+	var res []detectResponse
+	if err := p.post(ctx, p.endpoint+"/detect?api-version="+p.apiVersion, []TranslationRequest{{Text: text}}, &res); err != nil {
+		return "", 0, err
+	}
+	if len(res) == 0 || res[0].Language == "" {
+		return "", 0, fmt.Errorf("%w: no language detected", ErrTranslationDecode)
+	}
+	return res[0].Language, res[0].Score, nil
+}
+
+type azureLanguagesResponse struct {
+	Translation map[string]struct {
+		Name       string `json:"name"`
+		NativeName string `json:"nativeName"`
+		Dir        string `json:"dir"`
+	} `json:"translation"`
+	Transliteration map[string]struct {
+		Scripts []struct {
+			Code string `json:"code"`
+		} `json:"scripts"`
+	} `json:"transliteration"`
+}
+
+// Languages lists Azure's translation languages along with the scripts each
+// can be transliterated from.
+func (p *azureProvider) Languages(ctx context.Context) ([]languageInfo, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", p.endpoint+"/languages?api-version="+p.apiVersion+"&scope=translation,transliteration", nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	var res azureLanguagesResponse
+	if err := p.do(req, &res); err != nil {
+		return nil, err
+	}
+
+	languages := make([]languageInfo, 0, len(res.Translation))
+	for code, language := range res.Translation {
+		info := languageInfo{Code: code, Name: language.Name, NativeName: language.NativeName, Dir: language.Dir}
+		for _, script := range res.Transliteration[code].Scripts {
+			info.Scripts = append(info.Scripts, script.Code)
+		}
+		languages = append(languages, info)
+	}
+	return languages, nil
+}
+
+// post sends body as JSON to uri with the subscription headers and decodes
+// the JSON response into out.
+func (p *azureProvider) post(ctx context.Context, uri string, body, out interface{}) error {
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	return p.do(req, out)
+}
+
+// do sends req with the subscription headers and decodes the JSON response
+// into out.
+func (p *azureProvider) do(req *http.Request, out interface{}) error {
+	req.Header.Add("Ocp-Apim-Subscription-Key", p.subscriptionKey)
+	req.Header.Add("Ocp-Apim-Subscription-Region", p.region)
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrProviderUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return fmt.Errorf("error reading response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return newAzureError(resp, respBody)
+	}
+
+	if err := json.Unmarshal(respBody, out); err != nil {
+		return fmt.Errorf("%w: %v", ErrTranslationDecode, err)
+	}
+	return nil
+}
+
+// AzureError reports a non-OK response from the translation API. Code and
+// Message come from Azure's error envelope when the body contains one; Body
+// always holds the raw response for logging.
+type AzureError struct {
+	StatusCode int
+	Code       int
+	Message    string
+	Body       []byte
+	RetryAfter time.Duration
+}
+
+type azureErrorEnvelope struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+func newAzureError(resp *http.Response, body []byte) *AzureError {
+	azureErr := &AzureError{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var envelope azureErrorEnvelope
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		azureErr.Code = envelope.Error.Code
+		azureErr.Message = envelope.Error.Message
+	}
+	return azureErr
+}
+
+func (e *AzureError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("non-OK HTTP status: %d, response: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("azure error %d (HTTP %d): %s", e.Code, e.StatusCode, e.Message)
+}
+
+func (e *AzureError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+func (e *AzureError) ErrorCode() int {
+	return e.Code
+}
+
+func (e *AzureError) ResponseBody() []byte {
+	return e.Body
+}
+
+func (e *AzureError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+// Azure error codes are the HTTP status followed by three digits, e.g. 401000
+// for a missing key or 403001 for an exhausted free tier.
+func (e *AzureError) httpClass() int {
+	if e.Code >= 100000 {
+		return e.Code / 1000
+	}
+	return e.StatusCode
+}
+
+// IsAuth reports whether the request was rejected because of the
+// subscription key or region.
+func (e *AzureError) IsAuth() bool {
+	return e.httpClass() == http.StatusUnauthorized ||
+		e.httpClass() == http.StatusForbidden && !e.IsQuota()
+}
+
+// IsQuota reports whether the subscription quota or rate limit was exceeded.
+func (e *AzureError) IsQuota() bool {
+	return e.httpClass() == http.StatusTooManyRequests || e.Code == 403001
+}
+
+// IsUnsupportedLanguage reports Azure's 400019 (a language is not supported)
+// and 400036 (the target language is not valid).
+func (e *AzureError) IsUnsupportedLanguage() bool {
+	return e.Code == 400019 || e.Code == 400036
+}
+
+func (e *AzureError) Is(target error) bool {
+	return providerErrorIs(e, target)
+}
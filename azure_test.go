@@ -0,0 +1,268 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"testing"
+)
+
+func TestAzureErrorStatus(t *testing.T) {
+	tests := []struct {
+		name             string
+		status           int
+		body             string
+		wantStatus       int
+		wantCode         string
+		wantProviderCode int
+	}{
+		{"missing key", http.StatusUnauthorized, `{"error":{"code":401000,"message":"The request is not authorized"}}`, http.StatusBadGateway, codeProviderError, 401000},
+		{"free tier exhausted", http.StatusForbidden, `{"error":{"code":403001,"message":"Free tier quota exceeded"}}`, http.StatusTooManyRequests, codeProviderQuota, 403001},
+		{"rate limited", http.StatusTooManyRequests, `{"error":{"code":429001,"message":"Too many requests"}}`, http.StatusTooManyRequests, codeProviderQuota, 429001},
+		{"invalid target", http.StatusBadRequest, `{"error":{"code":400036,"message":"The target language is not valid"}}`, http.StatusBadRequest, codeUnsupportedLanguage, 400036},
+		{"server error", http.StatusInternalServerError, `{"error":{"code":500000,"message":"Internal error"}}`, http.StatusInternalServerError, codeTranslationFailed, 500000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, "TRANSLATOR_MAX_RETRIES", "0")
+			useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json")
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+
+			w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "fr"))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.wantStatus, w.Body)
+			}
+			var res errorResponse
+			decodeJSON(t, w, &res)
+			if res.Error.Code != tt.wantCode {
+				t.Errorf("code %q, want %q", res.Error.Code, tt.wantCode)
+			}
+			if res.Error.ProviderCode != tt.wantProviderCode {
+				t.Errorf("provider code %d, want %d", res.Error.ProviderCode, tt.wantProviderCode)
+			}
+		})
+	}
+}
+
+func TestAzureTranslateURLSourceLanguage(t *testing.T) {
+	p := &azureProvider{endpoint: "https://example.test", apiVersion: "3.0"}
+
+	if uri := p.translateURL("", "fr", TranslateOptions{}); strings.Contains(uri, "from=") {
+		t.Errorf("URL without a source language has from: %s", uri)
+	}
+	if uri := p.translateURL("en", "fr", TranslateOptions{}); !strings.Contains(uri, "&from=en") {
+		t.Errorf("URL with a source language lacks from=en: %s", uri)
+	}
+}
+
+// echoAzure answers translate requests with "<to>:" before each text, one
+// translation per Text entry, and sends every request body to bodies.
+func echoAzure(bodies chan<- []TranslationRequest) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var body []TranslationRequest
+		json.NewDecoder(r.Body).Decode(&body)
+		if bodies != nil {
+			bodies <- body
+		}
+		res := make([]map[string]interface{}, len(body))
+		for i, item := range body {
+			res[i] = map[string]interface{}{
+				"translations": []map[string]string{{"text": r.URL.Query().Get("to") + ":" + item.Text}},
+			}
+		}
+		json.NewEncoder(w).Encode(res)
+	}
+}
+
+func TestAzureTranslateBatch(t *testing.T) {
+	setupTest(t)
+	bodies := make(chan []TranslationRequest, 1)
+	useAzure(t, echoAzure(bodies))
+
+	texts := []string{"Hello", "Good night", "Welcome"}
+	translated, err := translateBatch(context.Background(), texts, "", "fr", TranslateOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := <-bodies
+	if len(body) != len(texts) {
+		t.Fatalf("request carried %d texts, want %d", len(body), len(texts))
+	}
+	for i, text := range texts {
+		if body[i].Text != text {
+			t.Errorf("Text %d = %q, want %q", i, body[i].Text, text)
+		}
+		if want := "fr:" + text; translated[i] != want {
+			t.Errorf("translation %d = %q, want %q", i, translated[i], want)
+		}
+	}
+}
+
+func TestAzureTranslateBatchCountMismatch(t *testing.T) {
+	setupTest(t, "TRANSLATOR_MAX_RETRIES", "0")
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(azureTranslation))
+	})
+
+	_, err := translateBatch(context.Background(), []string{"Hello", "Goodbye"}, "", "fr", TranslateOptions{})
+	if !errors.Is(err, ErrTranslationDecode) {
+		t.Fatalf("got %v, want a decode error", err)
+	}
+}
+
+func TestAzureHTMLTextType(t *testing.T) {
+	setupTest(t)
+	queries := make(chan string, 4)
+	echo := echoAzure(nil)
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.RawQuery
+		echo(w, r)
+	})
+
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: `<b>Live</b> music at <a href="/hall">the hall</a>`, Languages: []string{"fr"}, TextType: "html"}
+	created := createEvent(t, newRouter(), mustJSON(event))
+	if query := <-queries; !strings.Contains(query, "textType=html") {
+		t.Errorf("query %q lacks textType=html", query)
+	}
+	if !strings.Contains(created.Translations["fr"], event.Details) {
+		t.Errorf("fr translation %q does not keep the markup of %q", created.Translations["fr"], event.Details)
+	}
+
+	if uri := (&azureProvider{}).translateURL("", "fr", TranslateOptions{}); strings.Contains(uri, "textType") {
+		t.Errorf("plain text URL %q has textType", uri)
+	}
+}
+
+func TestAzureProfanityAction(t *testing.T) {
+	p := &azureProvider{endpoint: "https://example.test", apiVersion: "3.0"}
+	for action, want := range map[string]bool{"": false, "NoAction": false, "Marked": true, "Deleted": true} {
+		uri := p.translateURL("", "fr", TranslateOptions{ProfanityAction: action})
+		if got := strings.Contains(uri, "profanityAction="+action); got != want || !want && strings.Contains(uri, "profanityAction") {
+			t.Errorf("profanity action %q: URL %s", action, uri)
+		}
+	}
+}
+
+func TestInvalidProfanityActionRejected(t *testing.T) {
+	setupTest(t)
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr"}, ProfanityAction: "Censored"}
+
+	w := serveRequest(newRouter(), "POST", "/event", mustJSON(event))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400", w.Code)
+	}
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if _, ok := res.Error.Fields["profanityAction"]; !ok {
+		t.Fatalf("fields %v, want profanityAction", res.Error.Fields)
+	}
+}
+
+func TestAzureAlternatives(t *testing.T) {
+	setupTest(t)
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[{"translations":[{"text":"Concert ce soir"},{"text":"Concert à la soirée"},{"text":"Soirée concert"}]}]`))
+	})
+	r := newRouter()
+
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr"}, IncludeAlternatives: true}
+	created := createEvent(t, r, mustJSON(event))
+	want := []string{"Concert ce soir", "Concert à la soirée", "Soirée concert"}
+	if got := created.Alternatives["fr"]; strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("alternatives %q, want %q", got, want)
+	}
+	if created.Translations["fr"] != want[0] {
+		t.Errorf("translation %q, want the primary %q", created.Translations["fr"], want[0])
+	}
+
+	event.Name, event.IncludeAlternatives = "Opera", false
+	if plain := createEvent(t, r, mustJSON(event)); plain.Alternatives != nil {
+		t.Errorf("alternatives %v without includeAlternatives", plain.Alternatives)
+	}
+}
+
+func TestAzureAPIVersionInURL(t *testing.T) {
+	setupTest(t, "AZURE_API_VERSION", "3.0-preview.1")
+	versions := make(chan string, 1)
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		versions <- r.URL.Query().Get("api-version")
+		w.Write([]byte(azureTranslation))
+	})
+
+	if _, _, err := translateTexts(context.Background(), []string{"Hello"}, "", "fr", TranslateOptions{}); err != nil {
+		t.Fatalf("translateTexts: %v", err)
+	}
+	if got := <-versions; got != "3.0-preview.1" {
+		t.Fatalf("api-version = %q, want 3.0-preview.1", got)
+	}
+}
+
+func TestInvalidAzureAPIVersionRejected(t *testing.T) {
+	t.Setenv("TRANSLATION_PROVIDER", "azure")
+	t.Setenv("AZURE_TRANSLATOR_KEY", "test-key")
+	if _, err := loadConfig(); err != nil {
+		t.Fatalf("default version rejected: %v", err)
+	}
+	for _, version := range []string{"latest", "3", "3.0;drop"} {
+		t.Setenv("AZURE_API_VERSION", version)
+		if _, err := loadConfig(); err == nil {
+			t.Errorf("AZURE_API_VERSION=%q accepted, want an error", version)
+		}
+	}
+}
+
+// azureQueries serves azureTranslation and passes the query of each request
+// to the returned channel.
+func azureQueries(t *testing.T) <-chan url.Values {
+	t.Helper()
+	queries := make(chan url.Values, 10)
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		queries <- r.URL.Query()
+		w.Write([]byte(azureTranslation))
+	})
+	return queries
+}
+
+func TestAzureCategory(t *testing.T) {
+	tests := []struct {
+		name, configured, event, want string
+	}{
+		{"omitted", "", "", ""},
+		{"configured", "general", "", "general"},
+		{"from the event", "general", "a2eb72f9-43a8-46bd-82fa-4693c8b64c3c-TECH", "a2eb72f9-43a8-46bd-82fa-4693c8b64c3c-TECH"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, "AZURE_CATEGORY", tt.configured)
+			queries := azureQueries(t)
+
+			event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Category: tt.event, Languages: []string{"fr"}}
+			createEvent(t, newRouter(), mustJSON(event))
+			query := <-queries
+			if got, sent := query.Get("category"), query.Has("category"); got != tt.want || sent != (tt.want != "") {
+				t.Errorf("category = %q (sent %v), want %q", got, sent, tt.want)
+			}
+		})
+	}
+}
+
+func TestInvalidCategoryRejected(t *testing.T) {
+	setupTest(t)
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Category: "tech&to=de", Languages: []string{"fr"}}
+	if w := serveRequest(newRouter(), "POST", "/event", mustJSON(event)); w.Code != http.StatusBadRequest {
+		t.Errorf("POST /event with category %q: status %d, want 400", event.Category, w.Code)
+	}
+
+	t.Setenv("TRANSLATION_PROVIDER", "azure")
+	t.Setenv("AZURE_TRANSLATOR_KEY", "test-key")
+	t.Setenv("AZURE_CATEGORY", "-tech")
+	if _, err := loadConfig(); err == nil {
+		t.Error("AZURE_CATEGORY=-tech accepted, want an error")
+	}
+}
@@ -0,0 +1,329 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"sync"
+	"time"
+)
+
+// maxBatchEvents bounds how many events one POST /batch request may create.
+const maxBatchEvents = 1000
+
+const (
+	batchRunning = "running"
+	batchDone    = "done"
+)
+
+// batchJob is a POST /batch request whose events are created in the
+// background, one after another, as POST /events would. The result of each
+// event is saved as soon as it is known, so a restarted server resumes the
+// job with the events that were not done yet.
+type batchJob struct {
+	ID string `json:"id"`
+	// Status is "running" until every item has a result, then "done".
+	Status    string      `json:"status"`
+	Completed int         `json:"completed"`
+	CreatedAt time.Time   `json:"createdAt"`
+	UpdatedAt time.Time   `json:"updatedAt"`
+	Items     []batchItem `json:"items"`
+}
+
+// batchItem is one event of a batch. Event is the event as submitted, given
+// an ID when it had none so a resumed job recognizes the event it was
+// storing when the server stopped. Result is nil until the item is done.
+type batchItem struct {
+	Event  json.RawMessage `json:"event"`
+	Result *bulkResult     `json:"result,omitempty"`
+}
+
+// batchProgress is the response to GET /batch/:id: the job without the
+// submitted events, with the results known so far.
+type batchProgress struct {
+	ID        string       `json:"id"`
+	Status    string       `json:"status"`
+	Total     int          `json:"total"`
+	Completed int          `json:"completed"`
+	CreatedAt time.Time    `json:"createdAt"`
+	UpdatedAt time.Time    `json:"updatedAt"`
+	Results   []bulkResult `json:"results"`
+}
+
+// batchStore holds the batch jobs, saving every change to path when one is
+// set. Finished jobs are dropped once they have not changed for retention,
+// unless it is zero.
+type batchStore struct {
+	sync.Mutex
+	jobs      map[string]*batchJob
+	path      string
+	retention time.Duration
+	// stopped is set at shutdown, so running jobs stop before their next
+	// event.
+	stopped bool
+}
+
+var batchJobs = &batchStore{jobs: make(map[string]*batchJob)}
+
+// loadBatchStore reads the jobs saved to path by an earlier run. An empty
+// path keeps jobs in memory only, so they are not resumed after a restart.
+func loadBatchStore(path string, retention time.Duration) (*batchStore, error) {
+	s := &batchStore{jobs: make(map[string]*batchJob), path: path, retention: retention}
+	if path == "" {
+		return s, nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading batches file: %v", err)
+	}
+	if err := json.Unmarshal(data, &s.jobs); err != nil {
+		return nil, fmt.Errorf("error decoding batches file: %v", err)
+	}
+	s.pruneLocked(time.Now())
+	return s, nil
+}
+
+func (s *batchStore) add(job *batchJob) error {
+	s.Lock()
+	defer s.Unlock()
+	s.jobs[job.ID] = job
+	return s.saveLocked()
+}
+
+// pending returns the indexes of the items of job without a result.
+func (s *batchStore) pending(job *batchJob) []int {
+	s.Lock()
+	defer s.Unlock()
+	var indexes []int
+	for i, item := range job.Items {
+		if item.Result == nil {
+			indexes = append(indexes, i)
+		}
+	}
+	return indexes
+}
+
+// complete records the result of item i of job.
+func (s *batchStore) complete(job *batchJob, i int, result bulkResult) error {
+	s.Lock()
+	defer s.Unlock()
+	result.Index = i
+	job.Items[i].Result = &result
+	job.Completed++
+	if job.Completed == len(job.Items) {
+		job.Status = batchDone
+	}
+	job.UpdatedAt = time.Now().UTC()
+	return s.saveLocked()
+}
+
+func (s *batchStore) progress(id string) (batchProgress, bool) {
+	s.Lock()
+	defer s.Unlock()
+	job, ok := s.jobs[id]
+	if !ok {
+		return batchProgress{}, false
+	}
+	progress := batchProgress{
+		ID:        job.ID,
+		Status:    job.Status,
+		Total:     len(job.Items),
+		Completed: job.Completed,
+		CreatedAt: job.CreatedAt,
+		UpdatedAt: job.UpdatedAt,
+		Results:   make([]bulkResult, 0, job.Completed),
+	}
+	for _, item := range job.Items {
+		if item.Result != nil {
+			progress.Results = append(progress.Results, *item.Result)
+		}
+	}
+	return progress, true
+}
+
+// stop makes running jobs stop before their next item.
+func (s *batchStore) stop() {
+	s.Lock()
+	defer s.Unlock()
+	s.stopped = true
+}
+
+func (s *batchStore) isStopped() bool {
+	s.Lock()
+	defer s.Unlock()
+	return s.stopped
+}
+
+// pruneLocked drops the finished jobs last updated more than s.retention
+// before now. It must be called with the lock held.
+func (s *batchStore) pruneLocked(now time.Time) {
+	if s.retention == 0 {
+		return
+	}
+	for id, job := range s.jobs {
+		if job.Status == batchDone && now.Sub(job.UpdatedAt) > s.retention {
+			delete(s.jobs, id)
+		}
+	}
+}
+
+// unfinished returns the jobs that are still running.
+func (s *batchStore) unfinished() []*batchJob {
+	s.Lock()
+	defer s.Unlock()
+	var jobs []*batchJob
+	for _, job := range s.jobs {
+		if job.Status != batchDone {
+			jobs = append(jobs, job)
+		}
+	}
+	return jobs
+}
+
+// saveLocked drops expired jobs, then writes the rest to s.path.
+func (s *batchStore) saveLocked() error {
+	s.pruneLocked(time.Now())
+	if s.path == "" {
+		return nil
+	}
+	data, err := json.Marshal(s.jobs)
+	if err != nil {
+		return fmt.Errorf("error marshaling batches: %v", err)
+	}
+	return replaceFile(s.path, "batches", data)
+}
+
+// postBatch accepts a JSON array of events to create in the background and
+// answers 202 with the job's progress, to be polled at its Location.
+func postBatch(c *gin.Context) {
+	var raw []json.RawMessage
+	if err := c.ShouldBindJSON(&raw); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if len(raw) == 0 || len(raw) > maxBatchEvents {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("Expected between 1 and %d events", maxBatchEvents))
+		return
+	}
+
+	now := time.Now().UTC()
+	job := &batchJob{
+		ID:        uuid.NewString(),
+		Status:    batchRunning,
+		CreatedAt: now,
+		UpdatedAt: now,
+		Items:     make([]batchItem, len(raw)),
+	}
+	for i, item := range raw {
+		job.Items[i].Event = withEventID(item)
+	}
+	if err := batchJobs.add(job); err != nil {
+		respondError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+	startBatch(job, false)
+
+	progress, _ := batchJobs.progress(job.ID)
+	c.Header("Location", "/batch/"+job.ID)
+	c.JSON(http.StatusAccepted, progress)
+}
+
+func getBatch(c *gin.Context) {
+	progress, ok := batchJobs.progress(c.Param("id"))
+	if !ok {
+		respondError(c, http.StatusNotFound, codeBatchNotFound, "Batch not found")
+		return
+	}
+	c.JSON(http.StatusOK, progress)
+}
+
+// withEventID gives an event without an ID a new one. Bodies that are not
+// JSON objects are left for createBulkEvent to reject.
+func withEventID(item json.RawMessage) json.RawMessage {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(item, &fields); err != nil || fields == nil {
+		return item
+	}
+	var id string
+	if json.Unmarshal(fields["id"], &id) == nil && id != "" {
+		return item
+	}
+	fields["id"], _ = json.Marshal(uuid.NewString())
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return item
+	}
+	return data
+}
+
+// startBatch runs job in the background, tracked by backgroundJobs so
+// shutdown waits for the event being stored.
+func startBatch(job *batchJob, resumed bool) {
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		runBatch(job, resumed)
+	}()
+}
+
+// runBatch creates the pending events of job one after another, stopping
+// before the next one once the server shuts down. resumed is set when the
+// job was started by an earlier run of the server.
+func runBatch(job *batchJob, resumed bool) {
+	ctx := context.Background()
+	pending := batchJobs.pending(job)
+	for n, i := range pending {
+		if batchJobs.isStopped() {
+			logger.Info("batch stopped for shutdown", "id", job.ID, "remaining", len(pending)-n)
+			return
+		}
+		item := job.Items[i].Event
+		result, ok := bulkResult{}, false
+		if resumed && n == 0 {
+			result, ok = storedByJob(job, item)
+		}
+		if !ok {
+			result = createBulkEvent(ctx, item)
+		}
+		if err := batchJobs.complete(job, i, result); err != nil {
+			logger.Error("error saving batch progress", "id", job.ID, "error", err)
+		}
+	}
+	logger.Info("batch finished", "id", job.ID, "events", len(job.Items))
+}
+
+// storedByJob reports the result of an item the job stored before the
+// server stopped, but whose result was not saved yet: an event stored under
+// the item's ID since the job was created. Only the item being created when
+// the server stopped can be in that state.
+func storedByJob(job *batchJob, item json.RawMessage) (bulkResult, bool) {
+	var event EventInfo
+	if err := json.Unmarshal(item, &event); err != nil || event.ID == "" {
+		return bulkResult{}, false
+	}
+	stored, ok := events.get(event.ID)
+	if !ok || stored.CreatedAt.Before(job.CreatedAt) {
+		return bulkResult{}, false
+	}
+	if len(stored.TranslationErrors) > 0 {
+		return bulkResult{Status: "partial", Event: &stored}, true
+	}
+	return bulkResult{Status: "created", Event: &stored}, true
+}
+
+// resumeBatches continues the jobs an earlier run of the server did not
+// finish.
+func resumeBatches() {
+	for _, job := range batchJobs.unfinished() {
+		logger.Info("resuming batch", "id", job.ID, "remaining", len(job.Items)-job.Completed)
+		startBatch(job, true)
+	}
+}
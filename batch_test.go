@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// waitForBatch waits for the batch goroutines to return and returns the
+// job's progress.
+func waitForBatch(t *testing.T, id string) batchProgress {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := waitForBackgroundJobs(ctx); err != nil {
+		progress, _ := batchJobs.progress(id)
+		t.Fatalf("batch %q not done: %+v", id, progress)
+	}
+	progress, ok := batchJobs.progress(id)
+	if !ok {
+		t.Fatalf("batch %q not found", id)
+	}
+	return progress
+}
+
+func batchEvents(names ...string) []json.RawMessage {
+	items := make([]json.RawMessage, len(names))
+	for i, name := range names {
+		items[i] = json.RawMessage(eventBody(name, "fr"))
+	}
+	return items
+}
+
+func TestPostBatch(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	w := serveRequest(r, "POST", "/batch", mustJSON(batchEvents("Concert", "Gala", "Opera")))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST /batch: status %d, body %s", w.Code, w.Body)
+	}
+	var accepted batchProgress
+	decodeJSON(t, w, &accepted)
+	if got, want := w.Header().Get("Location"), "/batch/"+accepted.ID; got != want {
+		t.Errorf("Location %q, want %q", got, want)
+	}
+
+	progress := waitForBatch(t, accepted.ID)
+	if progress.Total != 3 || progress.Completed != 3 || len(progress.Results) != 3 {
+		t.Fatalf("progress %+v, want 3 of 3 done", progress)
+	}
+	for i, result := range progress.Results {
+		if result.Index != i || result.Status != "created" || result.Event == nil {
+			t.Errorf("result %d = %+v, want the created event", i, result)
+		}
+	}
+
+	w = serveRequest(r, "GET", "/batch/"+accepted.ID, "")
+	var polled batchProgress
+	decodeJSON(t, w, &polled)
+	if w.Code != http.StatusOK || polled.Status != batchDone || polled.Completed != 3 {
+		t.Errorf("GET /batch/%s: status %d, body %s", accepted.ID, w.Code, w.Body)
+	}
+}
+
+func TestPostBatchRejectsEmpty(t *testing.T) {
+	setupTest(t)
+	if w := serveRequest(newRouter(), "POST", "/batch", `[]`); w.Code != http.StatusBadRequest {
+		t.Errorf("POST /batch with no events: status %d, want 400", w.Code)
+	}
+}
+
+// TestBatchResumesAfterCrash saves a job as a server would have when it
+// stopped while storing its third of four events, then resumes it as the
+// next run of the server does.
+func TestBatchResumesAfterCrash(t *testing.T) {
+	setupTest(t)
+	path := filepath.Join(t.TempDir(), "batches.json")
+	stopped, err := loadBatchStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now().UTC()
+	job := &batchJob{ID: "job", Status: batchRunning, CreatedAt: now, UpdatedAt: now}
+	for _, item := range batchEvents("Concert", "Gala", "Opera", "Recital") {
+		job.Items = append(job.Items, batchItem{Event: withEventID(item)})
+	}
+	if err := stopped.add(job); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < 2; i++ {
+		if err := stopped.complete(job, i, createBulkEvent(context.Background(), job.Items[i].Event)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The third event was stored, but the server stopped before saving its
+	// result.
+	stored := createBulkEvent(context.Background(), job.Items[2].Event)
+
+	fake := useFakeProvider(nil)
+	if batchJobs, err = loadBatchStore(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	resumeBatches()
+	progress := waitForBatch(t, "job")
+
+	if n := fake.totalCalls(); n != 1 {
+		t.Errorf("made %d calls on resume, want only the fourth event translated", n)
+	}
+	if len(progress.Results) != 4 {
+		t.Fatalf("results %+v, want 4", progress.Results)
+	}
+	if got := progress.Results[2].Event; got == nil || got.ID != stored.Event.ID || got.Translations["fr"] != stored.Event.Translations["fr"] {
+		t.Errorf("third result %+v, want the event stored before the crash", progress.Results[2])
+	}
+	if got := progress.Results[3].Event; got == nil || got.Translations["fr"] != "fr:"+assembleDetails(*got) {
+		t.Errorf("fourth result %+v, want it translated on resume", progress.Results[3])
+	}
+	if n := len(events.list()); n != 4 {
+		t.Errorf("stored %d events, want 4", n)
+	}
+}
+
+func TestBatchStopsAtShutdown(t *testing.T) {
+	setupTest(t)
+	path := filepath.Join(t.TempDir(), "batches.json")
+	var err error
+	if batchJobs, err = loadBatchStore(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	// Shutdown begins while the first event is being translated.
+	useFakeProvider(func(text, from, to string) (string, error) {
+		batchJobs.stop()
+		return to + ":" + text, nil
+	})
+
+	w := serveRequest(newRouter(), "POST", "/batch", mustJSON(batchEvents("Concert", "Gala", "Opera")))
+	var accepted batchProgress
+	decodeJSON(t, w, &accepted)
+	progress := waitForBatch(t, accepted.ID)
+	if progress.Status != batchRunning || progress.Completed != 1 {
+		t.Fatalf("progress %+v, want only the first event done", progress)
+	}
+
+	fake := useFakeProvider(nil)
+	if batchJobs, err = loadBatchStore(path, 0); err != nil {
+		t.Fatal(err)
+	}
+	resumeBatches()
+	progress = waitForBatch(t, accepted.ID)
+	if progress.Status != batchDone || progress.Completed != 3 {
+		t.Fatalf("progress after restart %+v, want every event done", progress)
+	}
+	if n := fake.totalCalls(); n != 2 {
+		t.Errorf("made %d calls after restart, want the two remaining events translated", n)
+	}
+}
+
+func TestFinishedBatchesExpire(t *testing.T) {
+	setupTest(t)
+	path := filepath.Join(t.TempDir(), "batches.json")
+	saved, err := loadBatchStore(path, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	old := time.Now().UTC().Add(-2 * time.Hour)
+	for _, job := range []*batchJob{
+		{ID: "old", Status: batchDone, CreatedAt: old, UpdatedAt: old},
+		{ID: "stalled", Status: batchRunning, CreatedAt: old, UpdatedAt: old},
+		{ID: "recent", Status: batchDone, CreatedAt: old, UpdatedAt: time.Now().UTC()},
+	} {
+		if err := saved.add(job); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	loaded, err := loadBatchStore(path, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, kept := range map[string]bool{"old": false, "stalled": true, "recent": true} {
+		if _, ok := loaded.progress(id); ok != kept {
+			t.Errorf("job %q kept %v, want %v", id, ok, kept)
+		}
+	}
+
+	if err := loaded.add(&batchJob{ID: "new", Status: batchRunning, CreatedAt: time.Now().UTC(), UpdatedAt: time.Now().UTC()}); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), `"old"`) {
+		t.Errorf("batches file still has the expired job: %s", data)
+	}
+}
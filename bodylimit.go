@@ -0,0 +1,31 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"net/http"
+)
+
+// limitBody rejects requests whose body is larger than maxBytes with 413
+// before any handler decodes it. The body is read in full here, so binding
+// never buffers more than maxBytes.
+func limitBody(maxBytes int64) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		body, err := ioutil.ReadAll(http.MaxBytesReader(c.Writer, c.Request.Body, maxBytes))
+		if err != nil {
+			var tooLarge *http.MaxBytesError
+			if errors.As(err, &tooLarge) {
+				abortWithError(c, http.StatusRequestEntityTooLarge, codeBodyTooLarge,
+					fmt.Sprintf("Request body is larger than %d bytes", maxBytes))
+				return
+			}
+			abortWithError(c, http.StatusBadRequest, codeInvalidRequest, "Error reading request body")
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+		c.Next()
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestBodyLimit(t *testing.T) {
+	setupTest(t, "MAX_BODY_BYTES", "4096")
+	r := newRouter()
+
+	oversized := mustJSON(EventInfo{Name: "Concert", Location: "Town Hall", Details: strings.Repeat("music ", 1000), Languages: []string{"fr"}})
+	for _, route := range []struct{ method, target string }{
+		{"POST", "/event"}, {"PUT", "/event?id=x"}, {"PATCH", "/event?id=x"}, {"POST", "/events"}, {"POST", "/batch"}, {"POST", "/translate"},
+	} {
+		w := serveRequest(r, route.method, route.target, oversized)
+		if w.Code != http.StatusRequestEntityTooLarge {
+			t.Errorf("%s %s: status %d, want %d", route.method, route.target, w.Code, http.StatusRequestEntityTooLarge)
+			continue
+		}
+		var res errorResponse
+		decodeJSON(t, w, &res)
+		if res.Error.Code != codeBodyTooLarge {
+			t.Errorf("%s %s: error code %q, want %q", route.method, route.target, res.Error.Code, codeBodyTooLarge)
+		}
+	}
+
+	createEvent(t, r, eventBody("Concert", "fr"))
+}
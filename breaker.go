@@ -0,0 +1,113 @@
+package main
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errCircuitOpen is returned instead of calling the provider while the
+// circuit breaker is open.
+var errCircuitOpen = errors.New("translation provider unavailable, circuit breaker is open")
+
+// Circuit breaker states, as reported by the health endpoint.
+const (
+	circuitClosed   = "closed"
+	circuitOpen     = "open"
+	circuitHalfOpen = "half-open"
+)
+
+// circuitBreaker stops calls to the provider after threshold consecutive
+// transient failures. Once cooldown has passed a single trial call is let
+// through: its success closes the breaker again and its failure reopens it
+// for another cooldown. A nil breaker lets every call through.
+type circuitBreaker struct {
+	sync.Mutex
+	threshold int
+	cooldown  time.Duration
+
+	failures int
+	openedAt time.Time
+	state    string
+}
+
+func newCircuitBreaker(threshold int, cooldown time.Duration) *circuitBreaker {
+	return &circuitBreaker{threshold: threshold, cooldown: cooldown, state: circuitClosed}
+}
+
+// breaker guards every provider call; nil when CIRCUIT_BREAKER_THRESHOLD is 0.
+var breaker *circuitBreaker
+
+// allow reports whether a call may be made now.
+func (b *circuitBreaker) allow(now time.Time) bool {
+	if b == nil {
+		return true
+	}
+	b.Lock()
+	defer b.Unlock()
+
+	switch b.state {
+	case circuitOpen:
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.state = circuitHalfOpen
+		b.openedAt = now
+		return true
+	case circuitHalfOpen:
+		// The trial call is still in flight, unless it was abandoned without
+		// an outcome a whole cooldown ago.
+		if now.Sub(b.openedAt) < b.cooldown {
+			return false
+		}
+		b.openedAt = now
+		return true
+	default:
+		return true
+	}
+}
+
+// record notes the outcome of a call that allow let through. Only transient
+// failures count against the provider: one that answers, even to reject the
+// request, is up.
+func (b *circuitBreaker) record(err error, now time.Time) {
+	if b == nil {
+		return
+	}
+	b.Lock()
+	defer b.Unlock()
+
+	if err == nil || !isRetryable(err) {
+		b.failures = 0
+		b.state = circuitClosed
+		return
+	}
+
+	b.failures++
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		if b.state != circuitOpen {
+			logger.Warn("circuit breaker opened", "failures", b.failures, "cooldown", b.cooldown)
+		}
+		b.state = circuitOpen
+		b.openedAt = now
+	}
+}
+
+// status reports the breaker's state and, while it is open, how long until a
+// trial call is let through.
+func (b *circuitBreaker) status(now time.Time) (string, time.Duration) {
+	if b == nil {
+		return circuitClosed, 0
+	}
+	b.Lock()
+	defer b.Unlock()
+
+	if b.state != circuitOpen {
+		return b.state, 0
+	}
+	remaining := b.cooldown - now.Sub(b.openedAt)
+	if remaining <= 0 {
+		return circuitHalfOpen, 0
+	}
+	return circuitOpen, remaining
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var errUnreachable = &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}
+
+func TestCircuitBreakerOpensAfterThreshold(t *testing.T) {
+	setupTest(t)
+	b := newCircuitBreaker(3, time.Minute)
+	now := time.Now()
+
+	for i := 0; i < 3; i++ {
+		if !b.allow(now) {
+			t.Fatalf("call %d refused before the threshold", i)
+		}
+		b.record(errUnreachable, now)
+	}
+	if b.allow(now) {
+		t.Fatal("call allowed with the breaker open")
+	}
+	if state, remaining := b.status(now); state != circuitOpen || remaining != time.Minute {
+		t.Fatalf("status %s %v, want open for a minute", state, remaining)
+	}
+
+	// After the cooldown one trial call is let through; its success closes
+	// the breaker.
+	later := now.Add(time.Minute)
+	if !b.allow(later) {
+		t.Fatal("trial call refused after the cooldown")
+	}
+	if b.allow(later) {
+		t.Fatal("second call allowed while the trial call is in flight")
+	}
+	b.record(nil, later)
+	if state, _ := b.status(later); state != circuitClosed || !b.allow(later) {
+		t.Fatalf("state %s after a successful trial, want closed", state)
+	}
+}
+
+func TestCircuitBreakerTrialFailureReopens(t *testing.T) {
+	setupTest(t)
+	b := newCircuitBreaker(1, time.Minute)
+	now := time.Now()
+	b.record(errUnreachable, now)
+
+	later := now.Add(time.Minute)
+	if !b.allow(later) {
+		t.Fatal("trial call refused after the cooldown")
+	}
+	b.record(errUnreachable, later)
+	if b.allow(later.Add(time.Second)) {
+		t.Fatal("call allowed after the trial failed")
+	}
+}
+
+func TestCircuitBreakerIgnoresRejections(t *testing.T) {
+	setupTest(t)
+	b := newCircuitBreaker(1, time.Minute)
+	now := time.Now()
+	b.record(errors.New("bad request"), now)
+	if !b.allow(now) {
+		t.Fatal("breaker opened on a failure that is not transient")
+	}
+}
+
+func TestCircuitBreakerFailsFast(t *testing.T) {
+	setupTest(t, "CIRCUIT_BREAKER_THRESHOLD", "2", "TRANSLATOR_MAX_RETRIES", "0")
+	var hits int32
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		http.Error(w, `{"error":{"code":503000,"message":"Service unavailable"}}`, http.StatusServiceUnavailable)
+	})
+	r := newRouter()
+
+	for i := 0; i < 2; i++ {
+		if w := serveRequest(r, "POST", "/event", eventBody("Concert", "fr")); w.Code == http.StatusCreated {
+			t.Fatalf("POST %d succeeded against a failing provider", i)
+		}
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Fatalf("provider called %d times, want 2", n)
+	}
+
+	w := serveRequest(r, "POST", "/event", eventBody("Concert", "fr"))
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d with the breaker open, want %d", w.Code, http.StatusServiceUnavailable)
+	}
+	if w.Header().Get("Retry-After") == "" {
+		t.Error("no Retry-After with the breaker open")
+	}
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if res.Error.Code != codeCircuitOpen {
+		t.Fatalf("error code %q, want %q", res.Error.Code, codeCircuitOpen)
+	}
+	if n := atomic.LoadInt32(&hits); n != 2 {
+		t.Fatalf("provider called %d times with the breaker open, want no more calls", n)
+	}
+
+	var health healthBody
+	decodeJSON(t, serveRequest(r, "GET", "/healthz", ""), &health)
+	if health.Circuit != circuitOpen {
+		t.Fatalf("health circuit %q, want %q", health.Circuit, circuitOpen)
+	}
+}
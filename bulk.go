@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"net/http"
+	"time"
+)
+
+// maxBulkEvents bounds how many events one POST /events request may create.
+const maxBulkEvents = 100
+
+// bulkResult reports what happened to one event of a POST /events request
+// or a batch.
+type bulkResult struct {
+	Index int `json:"index"`
+	// Status is "created", "partial" (stored, but some languages failed),
+	// "conflict", "invalid" or "error". Event is the event stored, or on a
+	// conflict the one already stored under its ID.
+	Status string            `json:"status"`
+	Event  *EventInfo        `json:"event,omitempty"`
+	Errors map[string]string `json:"errors,omitempty"`
+	Error  string            `json:"error,omitempty"`
+}
+
+// postEvents creates each event of a JSON array as POST /event would,
+// answering 201 when every event was created and 207 with the result of each
+// otherwise. Events are translated one after another, each sharing the
+// TranslationConcurrency limit across its languages, so a batch never runs
+// more translations at once than a single event. Callback URLs are ignored.
+func postEvents(c *gin.Context) {
+	var raw []json.RawMessage
+	if err := c.ShouldBindJSON(&raw); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if len(raw) == 0 || len(raw) > maxBulkEvents {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("Expected between 1 and %d events", maxBulkEvents))
+		return
+	}
+
+	results := make([]bulkResult, len(raw))
+	status := http.StatusCreated
+	for i, item := range raw {
+		results[i] = createBulkEvent(c.Request.Context(), item)
+		results[i].Index = i
+		if results[i].Status != "created" {
+			status = http.StatusMultiStatus
+		}
+		if abortIfCanceled(c) {
+			return
+		}
+	}
+	c.JSON(status, gin.H{"results": results})
+}
+
+func createBulkEvent(ctx context.Context, item json.RawMessage) bulkResult {
+	var event EventInfo
+	if err := json.Unmarshal(item, &event); err != nil {
+		return bulkResult{Status: "invalid", Error: err.Error()}
+	}
+	if err := validateEvent(ctx, &event); err != nil {
+		if fields, ok := validationErrors(err); ok {
+			return bulkResult{Status: "invalid", Errors: fields}
+		}
+		if errors.Is(err, errLanguagesUnavailable) {
+			return bulkResult{Status: "error", Error: err.Error()}
+		}
+		return bulkResult{Status: "invalid", Error: err.Error()}
+	}
+	if err := textLengthError(eventSegments(event)); err != nil {
+		return bulkResult{Status: "invalid", Error: err.Error()}
+	}
+
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	} else if events.exists(event.ID) {
+		return conflictResult(event.ID)
+	}
+	event.CallbackURL = ""
+	event.Slug = uniqueSlug(event.Name)
+	event.CreatedAt = time.Now().UTC()
+	event.UpdatedAt = event.CreatedAt
+
+	failures := translateEvent(ctx, &event)
+	if len(event.Translations) == 0 && len(failures) > 0 {
+		lang, err := firstFailure(failures)
+		return bulkResult{Status: "error", Error: fmt.Sprintf("Error translating to %s: %v", lang, err)}
+	}
+	event.TranslationErrors = failureMessages(failures)
+
+	if err := addEvent(&event); err != nil {
+		if errors.Is(err, errEventExists) {
+			return conflictResult(event.ID)
+		}
+		return bulkResult{Status: "error", Error: err.Error()}
+	}
+	if len(failures) > 0 {
+		return bulkResult{Status: "partial", Event: &event}
+	}
+	return bulkResult{Status: "created", Event: &event}
+}
+
+// conflictResult reports an event whose ID is taken, along with the event
+// stored under it.
+func conflictResult(id string) bulkResult {
+	result := bulkResult{Status: "conflict", Error: "Event already exists"}
+	if existing, ok := events.get(id); ok {
+		result.Event = &existing
+	}
+	return result
+}
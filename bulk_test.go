@@ -0,0 +1,62 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPostEventsMixedBatch(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	createEvent(t, r, mustJSON(EventInfo{ID: "taken", Name: "Existing", Location: "Town Hall", Details: "Music", Languages: []string{"fr"}}))
+
+	batch := "[" +
+		mustJSON(EventInfo{ID: "new", Name: "Concert", Location: "Town Hall", Details: "Music", Languages: []string{"fr"}}) + "," +
+		mustJSON(EventInfo{ID: "taken", Name: "Duplicate", Location: "Town Hall", Details: "Music", Languages: []string{"fr"}}) + "," +
+		`{"name":"No location","details":"Music","languages":["fr"]}` + "]"
+	w := serveRequest(r, "POST", "/events", batch)
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("POST /events: status %d, want %d, body %s", w.Code, http.StatusMultiStatus, w.Body)
+	}
+	var res struct {
+		Results []bulkResult `json:"results"`
+	}
+	decodeJSON(t, w, &res)
+	if len(res.Results) != 3 {
+		t.Fatalf("got %d results, want 3", len(res.Results))
+	}
+
+	want := []string{"created", "conflict", "invalid"}
+	for i, result := range res.Results {
+		if result.Index != i || result.Status != want[i] {
+			t.Errorf("result %d: index %d status %q, want index %d status %q", i, result.Index, result.Status, i, want[i])
+		}
+	}
+	if e := res.Results[0].Event; e == nil || e.Translations["fr"] != "[fr] Concert Location: Town Hall Details: Music" {
+		t.Errorf("created event %+v, want it translated", e)
+	}
+	if e := res.Results[1].Event; e == nil || e.Name != "Existing" {
+		t.Errorf("conflict event %+v, want the stored one", e)
+	}
+	if _, ok := res.Results[2].Errors["location"]; !ok {
+		t.Errorf("invalid errors %v, want location", res.Results[2].Errors)
+	}
+	if n := len(events.list()); n != 2 {
+		t.Errorf("stored %d events, want 2", n)
+	}
+}
+
+func TestPostEventsAllCreated(t *testing.T) {
+	setupTest(t)
+	w := serveRequest(newRouter(), "POST", "/events", "["+eventBody("One", "fr")+","+eventBody("Two", "de")+"]")
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /events: status %d, want %d, body %s", w.Code, http.StatusCreated, w.Body)
+	}
+}
+
+func TestPostEventsEmptyRejected(t *testing.T) {
+	setupTest(t)
+	if w := serveRequest(newRouter(), "POST", "/events", "[]"); w.Code != http.StatusBadRequest {
+		t.Fatalf("POST /events []: status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+}
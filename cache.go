@@ -0,0 +1,68 @@
+package main
+
+import (
+	"container/list"
+	"sync"
+)
+
+type cacheKey struct {
+	text           string
+	sourceLanguage string
+	targetLanguage string
+	opts           TranslateOptions
+}
+
+type cacheEntry struct {
+	key   cacheKey
+	value string
+}
+
+// translationCache is a size-bounded LRU cache of translated text.
+type translationCache struct {
+	sync.Mutex
+	maxEntries int
+	order      *list.List
+	entries    map[cacheKey]*list.Element
+}
+
+func newTranslationCache(maxEntries int) *translationCache {
+	return &translationCache{
+		maxEntries: maxEntries,
+		order:      list.New(),
+		entries:    make(map[cacheKey]*list.Element),
+	}
+}
+
+func (c *translationCache) get(key cacheKey) (string, bool) {
+	if c == nil {
+		return "", false
+	}
+	c.Lock()
+	defer c.Unlock()
+	elem, ok := c.entries[key]
+	if !ok {
+		return "", false
+	}
+	c.order.MoveToFront(elem)
+	return elem.Value.(*cacheEntry).value, true
+}
+
+func (c *translationCache) add(key cacheKey, value string) {
+	if c == nil || c.maxEntries <= 0 {
+		return
+	}
+	c.Lock()
+	defer c.Unlock()
+	if elem, ok := c.entries[key]; ok {
+		elem.Value.(*cacheEntry).value = value
+		c.order.MoveToFront(elem)
+		return
+	}
+
+	c.entries[key] = c.order.PushFront(&cacheEntry{key: key, value: value})
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*cacheEntry).key)
+	}
+}
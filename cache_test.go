@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+)
+
+func TestTranslationServedFromCache(t *testing.T) {
+	setupTest(t)
+	var hits int32
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(azureTranslation))
+	})
+
+	for i, wantCached := range []bool{false, true} {
+		translated, cached, err := translateTexts(context.Background(), []string{"Hello"}, "", "fr", TranslateOptions{})
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if translated[0] != "Bonjour" || cached != wantCached {
+			t.Fatalf("call %d: got %q, cached %v; want Bonjour, cached %v", i, translated[0], cached, wantCached)
+		}
+	}
+	if hits != 1 {
+		t.Fatalf("server hit %d times, want 1", hits)
+	}
+}
+
+func TestTranslationCacheEvictsLeastRecentlyUsed(t *testing.T) {
+	c := newTranslationCache(2)
+	a, b, d := cacheKey{text: "a"}, cacheKey{text: "b"}, cacheKey{text: "d"}
+	c.add(a, "A")
+	c.add(b, "B")
+	c.get(a)
+	c.add(d, "D")
+
+	if _, ok := c.get(b); ok {
+		t.Error("b should have been evicted")
+	}
+	for _, key := range []cacheKey{a, d} {
+		if _, ok := c.get(key); !ok {
+			t.Errorf("%q should still be cached", key.text)
+		}
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+)
+
+// callLimiter bounds the provider calls in flight across every request, so
+// concurrent requests cannot add up to more than the provider allows. A nil
+// limiter lets every call through.
+type callLimiter chan struct{}
+
+func newCallLimiter(limit int) callLimiter {
+	return make(callLimiter, limit)
+}
+
+// callSlots is nil when MAX_INFLIGHT_CALLS is 0.
+var callSlots callLimiter
+
+// acquire waits for a free slot, giving up when ctx is done.
+func (l callLimiter) acquire(ctx context.Context) error {
+	if l == nil {
+		return nil
+	}
+	select {
+	case l <- struct{}{}:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (l callLimiter) release() {
+	if l != nil {
+		<-l
+	}
+}
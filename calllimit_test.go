@@ -0,0 +1,70 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestInflightCallsLimited(t *testing.T) {
+	setupTest(t, "MAX_INFLIGHT_CALLS", "2", "TRANSLATION_CONCURRENCY", "8")
+	var inflight, peak int32
+	useFakeProvider(func(text, from, to string) (string, error) {
+		n := atomic.AddInt32(&inflight, 1)
+		for {
+			seen := atomic.LoadInt32(&peak)
+			if n <= seen || atomic.CompareAndSwapInt32(&peak, seen, n) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		atomic.AddInt32(&inflight, -1)
+		return to + ":" + text, nil
+	})
+	r := newRouter()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 3; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			w := serveRequest(r, "POST", "/translate", `{"text":"Hello","to":["fr","de","es","it"]}`)
+			if w.Code != http.StatusOK {
+				t.Errorf("POST /translate: status %d, body %s", w.Code, w.Body)
+			}
+		}()
+	}
+	wg.Wait()
+	if n := atomic.LoadInt32(&peak); n > 2 {
+		t.Fatalf("%d calls in flight, want at most 2", n)
+	}
+}
+
+func TestCallLimiterAcquireCanceled(t *testing.T) {
+	l := newCallLimiter(1)
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if err := l.acquire(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("acquire on a full limiter: got %v, want %v", err, context.DeadlineExceeded)
+	}
+	l.release()
+	if err := l.acquire(context.Background()); err != nil {
+		t.Fatalf("acquire after release: %v", err)
+	}
+}
+
+func TestNilCallLimiter(t *testing.T) {
+	var l callLimiter
+	for i := 0; i < 3; i++ {
+		if err := l.acquire(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	l.release()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// languageInfo describes one language the provider translates into.
+type languageInfo struct {
+	Code       string `json:"code"`
+	Name       string `json:"name,omitempty"`
+	NativeName string `json:"nativeName,omitempty"`
+	// Dir is the writing direction, "ltr" or "rtl", when the provider
+	// reports it.
+	Dir string `json:"dir,omitempty"`
+	// Scripts are the scripts text in the language can be transliterated
+	// from.
+	Scripts []string `json:"scripts,omitempty"`
+}
+
+// languageLister is implemented by providers that can list their languages.
+type languageLister interface {
+	Languages(ctx context.Context) ([]languageInfo, error)
+}
+
+// languageCatalog caches the provider's language list for ttl.
+type languageCatalog struct {
+	sync.Mutex
+	ttl       time.Duration
+	languages []languageInfo
+	fetched   time.Time
+}
+
+var catalog = &languageCatalog{}
+
+// list returns the cached languages, fetching them from the provider when
+// the cache is empty or older than ttl. Providers that cannot list their
+// languages are described by the codes the supported_language validator
+// accepts.
+func (c *languageCatalog) list(ctx context.Context, now time.Time) ([]languageInfo, error) {
+	c.Lock()
+	defer c.Unlock()
+	if c.languages != nil && now.Sub(c.fetched) < c.ttl {
+		return c.languages, nil
+	}
+
+	lister, ok := provider.(languageLister)
+	if !ok {
+		return supportedLanguageList(), nil
+	}
+	var languages []languageInfo
+	err := withRetry(ctx, func() error {
+		var err error
+		languages, err = lister.Languages(ctx)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(languages, func(i, j int) bool { return languages[i].Code < languages[j].Code })
+	c.languages, c.fetched = languages, now
+	return languages, nil
+}
+
+func supportedLanguageList() []languageInfo {
+	languages := make([]languageInfo, 0, len(supportedLanguages))
+	for code := range supportedLanguages {
+		languages = append(languages, languageInfo{Code: canonicalLanguage(code), Dir: languageDirection(code)})
+	}
+	sort.Slice(languages, func(i, j int) bool { return languages[i].Code < languages[j].Code })
+	return languages
+}
+
+// listLanguages returns the languages events may be translated into.
+func listLanguages(c *gin.Context) {
+	languages, err := catalog.list(c.Request.Context(), time.Now())
+	if err != nil {
+		respondError(c, http.StatusBadGateway, codeLanguagesUnavailable, "Error listing languages: "+err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"languages": languages})
+}
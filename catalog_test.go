@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+const azureLanguagesBody = `{
+	"translation": {
+		"fr": {"name": "French", "nativeName": "Français", "dir": "ltr"},
+		"ar": {"name": "Arabic", "nativeName": "العربية", "dir": "rtl"}
+	},
+	"transliteration": {
+		"ar": {"scripts": [{"code": "Arab"}, {"code": "Latn"}]}
+	}
+}`
+
+// useAzureLanguages serves azureLanguagesBody from GET /languages and counts the
+// requests for it.
+func useAzureLanguages(t *testing.T) *int32 {
+	t.Helper()
+	var hits int32
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/languages" {
+			http.NotFound(w, r)
+			return
+		}
+		atomic.AddInt32(&hits, 1)
+		w.Write([]byte(azureLanguagesBody))
+	})
+	return &hits
+}
+
+func TestListLanguages(t *testing.T) {
+	setupTest(t)
+	hits := useAzureLanguages(t)
+	r := newRouter()
+
+	var res languagesBody
+	for i := 0; i < 2; i++ {
+		w := serveRequest(r, "GET", "/languages", "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET /languages: status %d, body %s", w.Code, w.Body)
+		}
+		decodeJSON(t, w, &res)
+	}
+	if n := atomic.LoadInt32(hits); n != 1 {
+		t.Fatalf("languages fetched %d times, want once", n)
+	}
+
+	if len(res.Languages) != 2 {
+		t.Fatalf("languages %+v, want ar and fr", res.Languages)
+	}
+	ar, fr := res.Languages[0], res.Languages[1]
+	if ar.Code != "ar" || ar.Name != "Arabic" || ar.NativeName != "العربية" || ar.Dir != "rtl" || len(ar.Scripts) != 2 {
+		t.Errorf("ar = %+v", ar)
+	}
+	if fr.Code != "fr" || fr.Name != "French" || fr.Dir != "ltr" || len(fr.Scripts) != 0 {
+		t.Errorf("fr = %+v", fr)
+	}
+}
+
+func TestLanguagesRefetchedAfterTTL(t *testing.T) {
+	setupTest(t, "LANGUAGES_CACHE_TTL", "1h")
+	hits := useAzureLanguages(t)
+	now := time.Now()
+
+	for _, at := range []time.Time{now, now.Add(30 * time.Minute), now.Add(time.Hour)} {
+		if _, err := catalog.list(context.Background(), at); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if n := atomic.LoadInt32(hits); n != 2 {
+		t.Fatalf("languages fetched %d times, want twice", n)
+	}
+}
+
+func TestListLanguagesProviderError(t *testing.T) {
+	setupTest(t, "TRANSLATOR_MAX_RETRIES", "0")
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, `{"error":{"code":401000,"message":"Unauthorized"}}`, http.StatusUnauthorized)
+	})
+
+	w := serveRequest(newRouter(), "GET", "/languages", "")
+	if w.Code != http.StatusBadGateway {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusBadGateway)
+	}
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if res.Error.Code != codeLanguagesUnavailable {
+		t.Fatalf("error code %q, want %q", res.Error.Code, codeLanguagesUnavailable)
+	}
+}
+
+func TestListLanguagesWithoutLister(t *testing.T) {
+	setupTest(t, "SUPPORTED_LANGUAGES", "fr,de")
+	useFakeProvider(nil)
+
+	var res languagesBody
+	decodeJSON(t, serveRequest(newRouter(), "GET", "/languages", ""), &res)
+	if len(res.Languages) != 2 || res.Languages[0].Code != "de" || res.Languages[1].Code != "fr" {
+		t.Fatalf("languages %+v, want the configured de and fr", res.Languages)
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// maxRequestLength is the most characters Azure accepts in one translate
+	// request. Longer texts are split into chunks and batches are split into
+	// several calls to stay under it.
+	maxRequestLength = 50000
+	// maxBatchSize is the most texts sent in one translate request.
+	maxBatchSize = 100
+)
+
+// chunkSpacing is the whitespace trimmed from around a chunk before it is
+// translated, so the chunks can be put back together with their original
+// spacing whatever the provider does to leading and trailing whitespace.
+type chunkSpacing struct {
+	leading  string
+	trailing string
+}
+
+// chunkLayout records how chunkTexts split each text.
+type chunkLayout [][]chunkSpacing
+
+// chunkTexts splits every text longer than limit characters into chunks of at
+// most limit characters, cutting at sentence boundaries where possible and
+// never inside a keyword placeholder. Texts within the limit are passed
+// through unchanged. The returned layout puts translated chunks back together.
+func chunkTexts(texts []string, limit int) ([]string, chunkLayout) {
+	var pieces []string
+	layout := make(chunkLayout, len(texts))
+	for i, text := range texts {
+		if utf8.RuneCountInString(text) <= limit {
+			pieces = append(pieces, text)
+			layout[i] = []chunkSpacing{{}}
+			continue
+		}
+		for _, chunk := range splitChunks(text, limit) {
+			trimmed := strings.TrimLeftFunc(chunk, unicode.IsSpace)
+			leading := chunk[:len(chunk)-len(trimmed)]
+			text := strings.TrimRightFunc(trimmed, unicode.IsSpace)
+			pieces = append(pieces, text)
+			layout[i] = append(layout[i], chunkSpacing{leading: leading, trailing: trimmed[len(text):]})
+		}
+	}
+	return pieces, layout
+}
+
+// join reassembles the translations of the chunks produced by chunkTexts into
+// one translation per original text.
+func (l chunkLayout) join(translated []string) []string {
+	joined := make([]string, len(l))
+	next := 0
+	for i, spacings := range l {
+		var b strings.Builder
+		for _, spacing := range spacings {
+			b.WriteString(spacing.leading)
+			b.WriteString(translated[next])
+			b.WriteString(spacing.trailing)
+			next++
+		}
+		joined[i] = b.String()
+	}
+	return joined
+}
+
+// splitChunks cuts text into pieces of at most limit characters whose
+// concatenation is text. Whole sentences are kept together, then whole words,
+// and only a single word longer than limit is cut mid-word.
+func splitChunks(text string, limit int) []string {
+	var chunks []string
+	for _, sentence := range splitAfter(text, isSentenceEnd) {
+		if utf8.RuneCountInString(sentence) <= limit {
+			chunks = appendPacked(chunks, sentence, limit)
+			continue
+		}
+		for _, word := range splitAfter(sentence, isWordEnd) {
+			if utf8.RuneCountInString(word) <= limit {
+				chunks = appendPacked(chunks, word, limit)
+				continue
+			}
+			for _, piece := range cutRunes(word, limit) {
+				chunks = appendPacked(chunks, piece, limit)
+			}
+		}
+	}
+	return chunks
+}
+
+// appendPacked adds piece to the last chunk if it still fits, or starts a new
+// chunk with it.
+func appendPacked(chunks []string, piece string, limit int) []string {
+	if n := len(chunks); n > 0 && utf8.RuneCountInString(chunks[n-1])+utf8.RuneCountInString(piece) <= limit {
+		chunks[n-1] += piece
+		return chunks
+	}
+	return append(chunks, piece)
+}
+
+// splitAfter splits text after every rune for which end reports true,
+// keeping any whitespace that follows it with the preceding piece.
+func splitAfter(text string, end func(r, next rune) bool) []string {
+	var pieces []string
+	runes := []rune(text)
+	start, offset := 0, 0
+	pending := false
+	for i, r := range runes {
+		offset += utf8.RuneLen(r)
+		next := rune(0)
+		if i+1 < len(runes) {
+			next = runes[i+1]
+		}
+		if end(r, next) {
+			pending = true
+		}
+		if pending && next != 0 && !unicode.IsSpace(next) {
+			pieces = append(pieces, text[start:offset])
+			start, pending = offset, false
+		}
+	}
+	if start < len(text) {
+		pieces = append(pieces, text[start:])
+	}
+	return pieces
+}
+
+// isSentenceEnd reports whether r, followed by next, ends a sentence. CJK full
+// stops need no following space.
+func isSentenceEnd(r, next rune) bool {
+	switch r {
+	case '。', '！', '？':
+		return true
+	case '.', '!', '?', '…':
+		return next == 0 || unicode.IsSpace(next)
+	}
+	return false
+}
+
+func isWordEnd(r, next rune) bool {
+	return unicode.IsSpace(r)
+}
+
+// cutRunes cuts word into pieces of at most limit characters, moving each cut
+// back to the start of any keyword placeholder it would otherwise split.
+func cutRunes(word string, limit int) []string {
+	var pieces []string
+	for utf8.RuneCountInString(word) > limit {
+		cut := len(string([]rune(word)[:limit]))
+		for _, loc := range placeholderPattern.FindAllStringIndex(word, -1) {
+			if loc[0] < cut && cut < loc[1] && loc[0] > 0 {
+				cut = loc[0]
+			}
+		}
+		pieces = append(pieces, word[:cut])
+		word = word[cut:]
+	}
+	return append(pieces, word)
+}
+
+// batches groups texts into runs that fit in one translate request, each with
+// at most maxBatchSize texts and maxRequestLength characters in total.
+func batches(texts []string) [][]string {
+	var groups [][]string
+	var group []string
+	length := 0
+	for _, text := range texts {
+		n := utf8.RuneCountInString(text)
+		if len(group) > 0 && (len(group) == maxBatchSize || length+n > maxRequestLength) {
+			groups = append(groups, group)
+			group, length = nil, 0
+		}
+		group = append(group, text)
+		length += n
+	}
+	if len(group) > 0 {
+		groups = append(groups, group)
+	}
+	return groups
+}
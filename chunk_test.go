@@ -0,0 +1,51 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+func TestChunkTextsReassemblesInOrder(t *testing.T) {
+	var sentences []string
+	for i := 1; i <= 12; i++ {
+		sentences = append(sentences, fmt.Sprintf("Sentence number %d ends here.", i))
+	}
+	long := strings.Join(sentences, " ")
+	texts := []string{"Short title", long}
+
+	const limit = 70
+	pieces, layout := chunkTexts(texts, limit)
+	if len(pieces) <= len(texts) {
+		t.Fatalf("got %d pieces, want the long text split", len(pieces))
+	}
+	for _, piece := range pieces {
+		if n := utf8.RuneCountInString(piece); n > limit {
+			t.Errorf("piece %q has %d characters, over the %d limit", piece, n, limit)
+		}
+		if !strings.HasSuffix(piece, ".") && piece != "Short title" {
+			t.Errorf("piece %q was not cut at a sentence end", piece)
+		}
+	}
+
+	translated := make([]string, len(pieces))
+	for i, piece := range pieces {
+		translated[i] = strings.ToUpper(piece)
+	}
+	joined := layout.join(translated)
+	if len(joined) != 2 || joined[0] != "SHORT TITLE" || joined[1] != strings.ToUpper(long) {
+		t.Fatalf("reassembled %q, want the translated sentences in their original order", joined)
+	}
+}
+
+func TestChunkTextsKeepsPlaceholdersWhole(t *testing.T) {
+	setupTest(t)
+	word := strings.Repeat("x", 8) + "KW1234000PLH" + strings.Repeat("y", 8)
+	pieces, _ := chunkTexts([]string{word}, 12)
+	for _, piece := range pieces {
+		if strings.Contains(piece, "KW") && !strings.Contains(piece, "KW1234000PLH") {
+			t.Fatalf("placeholder split across pieces %q", pieces)
+		}
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// gzipResponses compresses responses of at least minBytes for clients that
+// accept gzip. Smaller responses are sent as they are, since compressing them
+// costs more than it saves. Flushed responses, such as event streams, are
+// never compressed.
+func gzipResponses(minBytes int) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// The response depends on Accept-Encoding whether or not this one
+		// ends up compressed, so caches must key on it. Added rather than
+		// set so the Vary: Origin of CORS responses is kept.
+		c.Writer.Header().Add("Vary", "Accept-Encoding")
+		if !acceptsGzip(c.GetHeader("Accept-Encoding")) || c.Request.Method == http.MethodHead {
+			c.Next()
+			return
+		}
+
+		writer := &gzipWriter{ResponseWriter: c.Writer, minBytes: minBytes}
+		c.Writer = writer
+		defer writer.finish()
+		c.Next()
+	}
+}
+
+// acceptsGzip reports whether an Accept-Encoding header allows gzip, which
+// it does unless gzip is missing or given a zero quality.
+func acceptsGzip(header string) bool {
+	for _, coding := range strings.Split(header, ",") {
+		name, params, _ := strings.Cut(coding, ";")
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		if value, ok := strings.CutPrefix(strings.TrimSpace(params), "q="); ok {
+			q, err := strconv.ParseFloat(value, 64)
+			return err == nil && q > 0
+		}
+		return true
+	}
+	return false
+}
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} { return gzip.NewWriter(nil) },
+}
+
+// gzipWriter holds the response back until it reaches minBytes and then
+// compresses it. A response that finishes below minBytes, or is flushed
+// first, is written uncompressed.
+type gzipWriter struct {
+	gin.ResponseWriter
+	minBytes int
+
+	buffer      bytes.Buffer
+	gz          *gzip.Writer
+	passthrough bool
+}
+
+func (w *gzipWriter) Write(data []byte) (int, error) {
+	switch {
+	case w.gz != nil:
+		return w.gz.Write(data)
+	case w.passthrough:
+		return w.ResponseWriter.Write(data)
+	}
+
+	w.buffer.Write(data)
+	if w.buffer.Len() < w.minBytes {
+		return len(data), nil
+	}
+	if w.Header().Get("Content-Encoding") != "" || !w.bodyAllowed() {
+		w.passthrough = true
+	} else {
+		w.start()
+	}
+	if err := w.drain(); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *gzipWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// start switches the response to gzip once it is known to be large enough.
+// A strong ETag names the uncompressed bytes, so it is weakened for the
+// compressed ones.
+func (w *gzipWriter) start() {
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Del("Content-Length")
+	if tag := w.Header().Get("ETag"); tag != "" && !strings.HasPrefix(tag, "W/") {
+		w.Header().Set("ETag", "W/"+tag)
+	}
+	w.gz = gzipWriters.Get().(*gzip.Writer)
+	w.gz.Reset(w.ResponseWriter)
+}
+
+// drain writes what was held back, compressed if compression started.
+func (w *gzipWriter) drain() error {
+	data := w.buffer.Bytes()
+	w.buffer.Reset()
+	if len(data) == 0 {
+		return nil
+	}
+	if w.gz != nil {
+		_, err := w.gz.Write(data)
+		return err
+	}
+	_, err := w.ResponseWriter.Write(data)
+	return err
+}
+
+func (w *gzipWriter) bodyAllowed() bool {
+	status := w.Status()
+	return status != http.StatusNoContent && status != http.StatusNotModified && status >= http.StatusOK
+}
+
+// Flush sends what was written so far. An uncompressed response stays
+// uncompressed from then on.
+func (w *gzipWriter) Flush() {
+	if w.gz != nil {
+		w.gz.Flush()
+	} else {
+		w.passthrough = true
+		w.drain()
+	}
+	w.ResponseWriter.Flush()
+}
+
+// finish writes the rest of the response once the handlers are done.
+func (w *gzipWriter) finish() {
+	if w.gz == nil {
+		w.drain()
+		return
+	}
+	w.gz.Close()
+	gzipWriters.Put(w.gz)
+	w.gz = nil
+}
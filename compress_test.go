@@ -0,0 +1,93 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"testing"
+)
+
+func TestGzipAboveThreshold(t *testing.T) {
+	setupTest(t, "GZIP_MIN_BYTES", "100")
+	r := newRouter()
+	event := createEvent(t, r, eventBody("Concert", "fr", "de", "es"))
+
+	w := serveRequest(r, "GET", eventLocation(event.ID), "", "Accept-Encoding", "gzip")
+	if got := w.Header().Get("Content-Encoding"); got != "gzip" {
+		t.Fatalf("Content-Encoding = %q, want gzip", got)
+	}
+	gz, err := gzip.NewReader(w.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	body, err := io.ReadAll(gz)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got EventInfo
+	if err := json.Unmarshal(body, &got); err != nil {
+		t.Fatalf("decoding %q: %v", body, err)
+	}
+	if got.ID != event.ID {
+		t.Errorf("decompressed event %q, want %q", got.ID, event.ID)
+	}
+}
+
+func TestGzipBelowThreshold(t *testing.T) {
+	setupTest(t, "GZIP_MIN_BYTES", "100000")
+	r := newRouter()
+	event := createEvent(t, r, eventBody("Concert", "fr"))
+
+	w := serveRequest(r, "GET", eventLocation(event.ID), "", "Accept-Encoding", "gzip")
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("Content-Encoding = %q, want none", got)
+	}
+	var got EventInfo
+	decodeJSON(t, w, &got)
+	if got.ID != event.ID {
+		t.Errorf("event %q, want %q", got.ID, event.ID)
+	}
+}
+
+func TestGzipNotAccepted(t *testing.T) {
+	setupTest(t, "GZIP_MIN_BYTES", "100")
+	r := newRouter()
+	event := createEvent(t, r, eventBody("Concert", "fr", "de", "es"))
+
+	w := serveRequest(r, "GET", eventLocation(event.ID), "", "Accept-Encoding", "gzip;q=0")
+	if got := w.Header().Get("Content-Encoding"); got != "" {
+		t.Fatalf("Content-Encoding = %q, want none", got)
+	}
+}
+
+func TestGzipKeepsCORSVary(t *testing.T) {
+	setupTest(t, "GZIP_MIN_BYTES", "100", "ALLOWED_ORIGINS", "https://app.example.com")
+	w := serveRequest(newRouter(), "GET", "/events", "", "Origin", "https://app.example.com", "Accept-Encoding", "gzip")
+
+	vary := make(map[string]bool)
+	for _, value := range w.Header().Values("Vary") {
+		vary[value] = true
+	}
+	if !vary["Origin"] || !vary["Accept-Encoding"] {
+		t.Fatalf("Vary = %q, want Origin and Accept-Encoding", w.Header().Values("Vary"))
+	}
+}
+
+func TestGzipWeakensETag(t *testing.T) {
+	setupTest(t, "GZIP_MIN_BYTES", "100")
+	r := newRouter()
+	event := createEvent(t, r, eventBody("Concert", "fr", "de", "es"))
+
+	plain := serveRequest(r, "GET", eventLocation(event.ID), "")
+	compressed := serveRequest(r, "GET", eventLocation(event.ID), "", "Accept-Encoding", "gzip")
+	strong, weak := plain.Header().Get("ETag"), compressed.Header().Get("ETag")
+	if weak != "W/"+strong {
+		t.Fatalf("gzipped ETag %q, want the weak form of %q", weak, strong)
+	}
+
+	w := serveRequest(r, "GET", eventLocation(event.ID), "", "Accept-Encoding", "gzip", "If-None-Match", weak)
+	if w.Code != http.StatusNotModified {
+		t.Fatalf("If-None-Match %s: status %d, want %d", weak, w.Code, http.StatusNotModified)
+	}
+}
@@ -0,0 +1,537 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	defaultEndpoint        = "https://api.cognitive.microsofttranslator.com"
+	defaultRegion          = "eastus"
+	defaultAzureAPIVersion = "3.0"
+	defaultMaxRetries      = 3
+	defaultRetryBaseDelay  = 500 * time.Millisecond
+	defaultCacheEntries    = 1000
+	defaultConcurrency     = 4
+	defaultRequestTimeout  = 10 * time.Second
+	defaultShutdownTimeout = 15 * time.Second
+	defaultBatchRetention  = 24 * time.Hour
+	defaultPort            = "8080"
+	defaultRateLimitBurst  = 5
+	defaultIdempotencyTTL  = 24 * time.Hour
+	defaultMaxTextLength   = 50000
+	defaultMaxBodyBytes    = 1 << 20
+	defaultGzipMinBytes    = 1024
+	defaultRoundTripScore  = 0.5
+	defaultBreakerCooldown = 30 * time.Second
+	defaultQueueTimeout    = 5 * time.Second
+	defaultLanguagesTTL    = 24 * time.Hour
+	defaultRedisKeyPrefix  = "customtranslator:event:"
+)
+
+// apiVersionPattern matches version strings such as "3.0" and
+// "3.0-preview.1".
+var apiVersionPattern = regexp.MustCompile(`^\d+\.\d+(-[A-Za-z]+(\.\d+)?)?$`)
+
+// categoryPattern matches Azure categories: "general" or a Custom Translator
+// category ID such as "a2eb72f9-43a8-46bd-82fa-4693c8b64c3c-TECH".
+var categoryPattern = regexp.MustCompile(`^[A-Za-z0-9][A-Za-z0-9_-]{0,99}$`)
+
+var (
+	defaultCORSMethods = []string{"GET", "POST", "PUT", "PATCH", "DELETE"}
+	defaultCORSHeaders = []string{"Content-Type", "X-API-Key", "X-Admin-Token", "Idempotency-Key", "If-None-Match"}
+)
+
+type Config struct {
+	// Host and Port form the listen address. An empty Host listens on all
+	// interfaces and Port "0" picks a free port.
+	Host string
+	Port string
+
+	// Provider selects the translation backend: "azure", "google", or the
+	// offline "mock" provider.
+	Provider string
+
+	Endpoint        string
+	Region          string
+	SubscriptionKey string
+	// AzureAPIVersion is sent as Azure's api-version parameter, e.g. "3.0"
+	// or a preview version such as "3.0-preview.1".
+	AzureAPIVersion string
+	// AzureCategory is the Custom Translator category used by events that
+	// do not name their own.
+	AzureCategory string
+
+	GoogleEndpoint string
+	GoogleAPIKey   string
+
+	// ProxyURL is the proxy provider requests go through.
+	// When nil, HTTP_PROXY, HTTPS_PROXY and NO_PROXY are honored instead.
+	ProxyURL *url.URL
+
+	// SupportedLanguages replaces the provider's built-in list of accepted
+	// target language codes when set.
+	SupportedLanguages []string
+
+	// DefaultLanguages are translated into when a request names no target
+	// languages. Languages given in a request always take precedence.
+	DefaultLanguages []string
+	// WildcardLanguageLimit is the most languages a "*" language may
+	// expand to before the request is rejected. Zero disables wildcards.
+	WildcardLanguageLimit int
+
+	// LanguageFallbacks maps a language to the one tried instead when the
+	// provider reports it unsupported, e.g. "pt-BR" to "pt". Fallbacks are
+	// followed in turn, so a fallback may have its own.
+	LanguageFallbacks map[string]string
+
+	// SponsoredPlacement maps a language to where the sponsored message goes
+	// in its translations: "inline" (the default), "prepend", "append" or
+	// "omit".
+	SponsoredPlacement map[string]string
+
+	// MaxRetries is the number of additional attempts made after a
+	// transient translation failure.
+	MaxRetries     int
+	RetryBaseDelay time.Duration
+	// RequestTimeout bounds a single call to the translation API.
+	RequestTimeout time.Duration
+	// TranslationDeadline bounds all the translations of one request. When
+	// it passes, remaining languages are canceled and the request is
+	// answered with 504 and what was translated so far. Zero disables it.
+	TranslationDeadline time.Duration
+
+	// LanguagesCacheTTL is how long the provider's language list served by
+	// GET /languages is cached.
+	LanguagesCacheTTL time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive transient
+	// provider failures after which calls fail fast with 503 for
+	// CircuitBreakerCooldown. Zero disables the breaker.
+	CircuitBreakerThreshold int
+	CircuitBreakerCooldown  time.Duration
+
+	// CacheMaxEntries bounds the translation cache; zero disables caching.
+	CacheMaxEntries int
+
+	// SanitizeText strips control and zero-width characters from text before
+	// it is translated, and NormalizeNFC normalizes it to Unicode NFC. Both
+	// are off by default so text reaches the provider as clients sent it.
+	SanitizeText bool
+	NormalizeNFC bool
+
+	// PlaceholderFormat spells the placeholders keywords are replaced with
+	// during translation; "%s" stands for the digits identifying each one.
+	PlaceholderFormat string
+
+	// OutputEscaping is how translated text is written in responses: "raw"
+	// leaves it as it is, "html" HTML-escapes it and "unicode" writes JSON
+	// responses with every non-ASCII character \u-escaped.
+	OutputEscaping string
+
+	// DetailsTemplate is the text/template, executed with the EventInfo, that
+	// builds the text of an event's details segment.
+	DetailsTemplate string
+
+	// RewritesFile is a JSON array of find/replace rules applied to the
+	// translations into a language, or into every language, see
+	// rewriteRule.
+	RewritesFile string
+
+	// ValidateLinkURLs requires the keys of an event's LinkNames, the links'
+	// URLs, to be http or https URLs, which are normalized before the event
+	// is stored. It is off by default so existing keys keep being accepted.
+	ValidateLinkURLs bool
+
+	// MaxTextLength is the most characters an event may send for
+	// translation; longer events are rejected with 413. Zero disables the
+	// check.
+	MaxTextLength int
+
+	// MaxBodyBytes is the largest request body accepted by endpoints that
+	// take one; larger bodies are rejected with 413. Zero disables the
+	// limit.
+	MaxBodyBytes int64
+	// GzipMinBytes is the smallest response compressed for clients that
+	// accept gzip. Zero disables compression.
+	GzipMinBytes int
+
+	// RoundTripThreshold is the round-trip score below which a translation
+	// is flagged as low confidence.
+	RoundTripThreshold float64
+
+	// TranslationConcurrency limits how many target languages of a single
+	// event are translated at the same time.
+	TranslationConcurrency int
+	// CallRate paces provider calls to this many per second across every
+	// request; a call that would wait more than QueueTimeout for its turn
+	// fails with 503 instead. Zero disables pacing.
+	CallRate     float64
+	QueueTimeout time.Duration
+	// MaxInflightCalls limits the provider calls made at the same time
+	// across every request. Zero leaves them unlimited.
+	MaxInflightCalls int
+
+	// StoreBackend is where events are kept: "memory", optionally persisted
+	// to EventsFile, or "redis" to share them between instances through
+	// the server at RedisURL, under keys starting with RedisKeyPrefix.
+	StoreBackend   string
+	RedisURL       string
+	RedisKeyPrefix string
+
+	// EventsFile is where events are persisted. When empty, events are only
+	// kept in memory.
+	EventsFile string
+	// BatchesFile is where the progress of POST /batch jobs is saved, so
+	// jobs unfinished when the server stopped resume when it starts again.
+	// When empty, jobs are only kept in memory.
+	BatchesFile string
+	// BatchRetention is how long a finished batch job is kept for GET
+	// /batch/:id before it is dropped; zero keeps jobs forever.
+	BatchRetention time.Duration
+
+	LogLevel slog.Level
+	// LogRedactFields are the log attributes, such as "details" or
+	// "response", whose values are hidden from the logs, replaced by
+	// "[REDACTED]" or, when LogRedaction is "hash", by a hash of the value.
+	LogRedactFields []string
+	LogRedaction    string
+
+	// APIKeys are the keys accepted in the X-API-Key header of write
+	// requests, and of reads too when APIKeysForReads is set. No keys
+	// leaves the API open.
+	APIKeys         []string
+	APIKeysForReads bool
+
+	// AllowedOrigins are the browser origins allowed to call the API, with
+	// "*" allowing any. Without any, browsers are limited to same-origin
+	// requests. CORSAllowedMethods and CORSAllowedHeaders are answered to
+	// their preflight requests.
+	AllowedOrigins     []string
+	CORSAllowedMethods []string
+	CORSAllowedHeaders []string
+
+	// AdminToken guards administrative endpoints. When empty they always
+	// reject requests.
+	AdminToken string
+
+	// RateLimitRPS is the sustained number of translating requests each
+	// client may make per second, with bursts up to RateLimitBurst. Zero
+	// disables rate limiting.
+	RateLimitRPS   float64
+	RateLimitBurst int
+
+	// IdempotencyTTL is how long the response to a request with an
+	// Idempotency-Key header is kept for replay.
+	IdempotencyTTL time.Duration
+
+	// WebhookSecret keys the HMAC-SHA256 signature sent with event
+	// callbacks. Callbacks are unsigned when it is empty.
+	WebhookSecret string
+
+	// RefreshInterval is how often stale events, those changed since they
+	// were translated, are retranslated. Zero disables the refresher.
+	RefreshInterval time.Duration
+
+	// ShutdownTimeout is how long in-flight requests may take to finish
+	// once the server is asked to stop.
+	ShutdownTimeout time.Duration
+
+	// MetricsEnabled registers the Prometheus /metrics endpoint.
+	MetricsEnabled bool
+
+	// OTLPEndpoint is the OpenTelemetry collector traces are exported to.
+	// Tracing is disabled when it is empty.
+	OTLPEndpoint string
+}
+
+func loadConfig() (Config, error) {
+	cfg := Config{
+		Host:            os.Getenv("HOST"),
+		Port:            getEnv("PORT", defaultPort),
+		Provider:        getEnv("TRANSLATION_PROVIDER", "azure"),
+		Endpoint:        getEnv("AZURE_TRANSLATOR_ENDPOINT", defaultEndpoint),
+		Region:          getEnv("AZURE_TRANSLATOR_REGION", defaultRegion),
+		SubscriptionKey: os.Getenv("AZURE_TRANSLATOR_KEY"),
+		AzureAPIVersion: getEnv("AZURE_API_VERSION", defaultAzureAPIVersion),
+		AzureCategory:   os.Getenv("AZURE_CATEGORY"),
+		GoogleEndpoint:  getEnv("GOOGLE_TRANSLATE_ENDPOINT", defaultGoogleEndpoint),
+		GoogleAPIKey:    os.Getenv("GOOGLE_TRANSLATE_API_KEY"),
+		EventsFile:      os.Getenv("EVENTS_FILE"),
+		BatchesFile:     os.Getenv("BATCHES_FILE"),
+		StoreBackend:    getEnv("STORE_BACKEND", "memory"),
+		RedisURL:        os.Getenv("REDIS_URL"),
+		RedisKeyPrefix:  getEnv("REDIS_KEY_PREFIX", defaultRedisKeyPrefix),
+		AdminToken:      os.Getenv("ADMIN_TOKEN"),
+		WebhookSecret:   os.Getenv("WEBHOOK_SECRET"),
+		OTLPEndpoint:    os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"),
+		DetailsTemplate: getEnv("DETAILS_TEMPLATE", defaultDetailsTemplate),
+		RewritesFile:    os.Getenv("TRANSLATION_REWRITES_FILE"),
+		OutputEscaping:  getEnv("OUTPUT_ESCAPING", escapeRaw),
+		LogRedaction:    getEnv("LOG_REDACTION", redactMask),
+
+		PlaceholderFormat: getEnv("KEYWORD_PLACEHOLDER_FORMAT", defaultPlaceholderFormat),
+
+		SupportedLanguages: getEnvList("SUPPORTED_LANGUAGES"),
+		DefaultLanguages:   getEnvList("DEFAULT_LANGUAGES"),
+		APIKeys:            getEnvList("API_KEYS"),
+		AllowedOrigins:     getEnvList("ALLOWED_ORIGINS"),
+		CORSAllowedMethods: getEnvList("CORS_ALLOWED_METHODS"),
+		CORSAllowedHeaders: getEnvList("CORS_ALLOWED_HEADERS"),
+		LogRedactFields:    getEnvList("LOG_REDACT_FIELDS"),
+	}
+
+	if len(cfg.CORSAllowedMethods) == 0 {
+		cfg.CORSAllowedMethods = defaultCORSMethods
+	}
+	if len(cfg.CORSAllowedHeaders) == 0 {
+		cfg.CORSAllowedHeaders = defaultCORSHeaders
+	}
+
+	switch cfg.Provider {
+	case "azure":
+		if cfg.SubscriptionKey == "" {
+			return cfg, fmt.Errorf("AZURE_TRANSLATOR_KEY must be set")
+		}
+		if !apiVersionPattern.MatchString(cfg.AzureAPIVersion) {
+			return cfg, fmt.Errorf("AZURE_API_VERSION must look like 3.0 or 3.0-preview.1, got %q", cfg.AzureAPIVersion)
+		}
+		if cfg.AzureCategory != "" && !categoryPattern.MatchString(cfg.AzureCategory) {
+			return cfg, fmt.Errorf("AZURE_CATEGORY must be a Custom Translator category ID, got %q", cfg.AzureCategory)
+		}
+	case "google":
+		if cfg.GoogleAPIKey == "" {
+			return cfg, fmt.Errorf("GOOGLE_TRANSLATE_API_KEY must be set")
+		}
+	case "mock":
+	default:
+		return cfg, fmt.Errorf("TRANSLATION_PROVIDER must be azure, google or mock, got %q", cfg.Provider)
+	}
+
+	switch cfg.StoreBackend {
+	case "memory":
+	case "redis":
+		if cfg.RedisURL == "" {
+			return cfg, fmt.Errorf("REDIS_URL must be set")
+		}
+	default:
+		return cfg, fmt.Errorf("STORE_BACKEND must be memory or redis, got %q", cfg.StoreBackend)
+	}
+
+	if proxy := os.Getenv("TRANSLATOR_PROXY_URL"); proxy != "" {
+		u, err := url.Parse(proxy)
+		if err != nil || u.Host == "" || (u.Scheme != "http" && u.Scheme != "https" && u.Scheme != "socks5") {
+			return cfg, fmt.Errorf("TRANSLATOR_PROXY_URL must be an http, https or socks5 URL, got %q", proxy)
+		}
+		cfg.ProxyURL = u
+	}
+
+	switch cfg.OutputEscaping {
+	case escapeRaw, escapeHTML, escapeUnicode:
+	default:
+		return cfg, fmt.Errorf("OUTPUT_ESCAPING must be raw, html or unicode, got %q", cfg.OutputEscaping)
+	}
+
+	switch cfg.LogRedaction {
+	case redactMask, redactHash:
+	default:
+		return cfg, fmt.Errorf("LOG_REDACTION must be mask or hash, got %q", cfg.LogRedaction)
+	}
+
+	var err error
+	if cfg.LanguageFallbacks, err = getEnvLanguageMap("LANGUAGE_FALLBACKS"); err != nil {
+		return cfg, err
+	}
+	if cfg.SponsoredPlacement, err = getEnvSponsoredPlacement("SPONSORED_MESSAGE_PLACEMENT"); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxRetries, err = getEnvInt("TRANSLATOR_MAX_RETRIES", defaultMaxRetries); err != nil {
+		return cfg, err
+	}
+	if cfg.RetryBaseDelay, err = getEnvDuration("TRANSLATOR_RETRY_BASE_DELAY", defaultRetryBaseDelay); err != nil {
+		return cfg, err
+	}
+
+	if cfg.RequestTimeout, err = getEnvDuration("TRANSLATOR_TIMEOUT", defaultRequestTimeout); err != nil {
+		return cfg, err
+	}
+	if cfg.TranslationDeadline, err = getEnvDuration("TRANSLATION_DEADLINE", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.RateLimitRPS, err = getEnvFloat("RATE_LIMIT_RPS", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.LanguagesCacheTTL, err = getEnvDuration("LANGUAGES_CACHE_TTL", defaultLanguagesTTL); err != nil {
+		return cfg, err
+	}
+	if cfg.CircuitBreakerThreshold, err = getEnvInt("CIRCUIT_BREAKER_THRESHOLD", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.CircuitBreakerCooldown, err = getEnvDuration("CIRCUIT_BREAKER_COOLDOWN", defaultBreakerCooldown); err != nil {
+		return cfg, err
+	}
+	if cfg.RoundTripThreshold, err = getEnvFloat("ROUND_TRIP_THRESHOLD", defaultRoundTripScore); err != nil {
+		return cfg, err
+	}
+	if cfg.RateLimitBurst, err = getEnvInt("RATE_LIMIT_BURST", defaultRateLimitBurst); err != nil {
+		return cfg, err
+	}
+	if cfg.IdempotencyTTL, err = getEnvDuration("IDEMPOTENCY_TTL", defaultIdempotencyTTL); err != nil {
+		return cfg, err
+	}
+	if cfg.RefreshInterval, err = getEnvDuration("REFRESH_INTERVAL", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.ShutdownTimeout, err = getEnvDuration("SHUTDOWN_TIMEOUT", defaultShutdownTimeout); err != nil {
+		return cfg, err
+	}
+	if cfg.BatchRetention, err = getEnvDuration("BATCH_RETENTION", defaultBatchRetention); err != nil {
+		return cfg, err
+	}
+	if cfg.CacheMaxEntries, err = getEnvInt("TRANSLATION_CACHE_SIZE", defaultCacheEntries); err != nil {
+		return cfg, err
+	}
+
+	if err := cfg.LogLevel.UnmarshalText([]byte(getEnv("LOG_LEVEL", "info"))); err != nil {
+		return cfg, fmt.Errorf("LOG_LEVEL must be debug, info, warn or error: %v", err)
+	}
+	if cfg.APIKeysForReads, err = getEnvBool("API_KEYS_FOR_READS", false); err != nil {
+		return cfg, err
+	}
+	if cfg.MetricsEnabled, err = getEnvBool("METRICS_ENABLED", false); err != nil {
+		return cfg, err
+	}
+	if cfg.TranslationConcurrency, err = getEnvInt("TRANSLATION_CONCURRENCY", defaultConcurrency); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxInflightCalls, err = getEnvInt("MAX_INFLIGHT_CALLS", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.CallRate, err = getEnvFloat("TRANSLATOR_CALL_RATE", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.QueueTimeout, err = getEnvDuration("TRANSLATOR_QUEUE_TIMEOUT", defaultQueueTimeout); err != nil {
+		return cfg, err
+	}
+	if cfg.SanitizeText, err = getEnvBool("SANITIZE_TEXT", false); err != nil {
+		return cfg, err
+	}
+	if cfg.NormalizeNFC, err = getEnvBool("NORMALIZE_NFC", false); err != nil {
+		return cfg, err
+	}
+	if cfg.WildcardLanguageLimit, err = getEnvInt("WILDCARD_LANGUAGE_LIMIT", 0); err != nil {
+		return cfg, err
+	}
+	if cfg.GzipMinBytes, err = getEnvInt("GZIP_MIN_BYTES", defaultGzipMinBytes); err != nil {
+		return cfg, err
+	}
+	if cfg.MaxTextLength, err = getEnvInt("MAX_TEXT_LENGTH", defaultMaxTextLength); err != nil {
+		return cfg, err
+	}
+	if cfg.ValidateLinkURLs, err = getEnvBool("VALIDATE_LINK_URLS", false); err != nil {
+		return cfg, err
+	}
+	maxBodyBytes, err := getEnvInt("MAX_BODY_BYTES", defaultMaxBodyBytes)
+	if err != nil {
+		return cfg, err
+	}
+	cfg.MaxBodyBytes = int64(maxBodyBytes)
+
+	return cfg, nil
+}
+
+func (cfg Config) ListenAddress() string {
+	return net.JoinHostPort(cfg.Host, cfg.Port)
+}
+
+func getEnv(key, fallback string) string {
+	if value, ok := os.LookupEnv(key); ok && value != "" {
+		return value
+	}
+	return fallback
+}
+
+// getEnvList splits a comma-separated variable, dropping empty entries.
+func getEnvList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// getEnvLanguageMap parses a comma-separated list of from=to language pairs,
+// giving both codes canonical casing.
+func getEnvLanguageMap(key string) (map[string]string, error) {
+	list := getEnvList(key)
+	if len(list) == 0 {
+		return nil, nil
+	}
+	languages := make(map[string]string, len(list))
+	for _, pair := range list {
+		from, to, ok := strings.Cut(pair, "=")
+		from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+		if !ok || from == "" || to == "" {
+			return nil, fmt.Errorf("%s must be a list of from=to language pairs, got %q", key, pair)
+		}
+		languages[canonicalLanguage(from)] = canonicalLanguage(to)
+	}
+	return languages, nil
+}
+
+func getEnvInt(key string, fallback int) (int, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer, got %q", key, value)
+	}
+	return n, nil
+}
+
+func getEnvFloat(key string, fallback float64) (float64, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil || f < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative number, got %q", key, value)
+	}
+	return f, nil
+}
+
+func getEnvBool(key string, fallback bool) (bool, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, fmt.Errorf("%s must be a boolean, got %q", key, value)
+	}
+	return b, nil
+}
+
+func getEnvDuration(key string, fallback time.Duration) (time.Duration, error) {
+	value := os.Getenv(key)
+	if value == "" {
+		return fallback, nil
+	}
+	d, err := time.ParseDuration(value)
+	if err != nil || d < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative duration, got %q", key, value)
+	}
+	return d, nil
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestListenAddressFromEnvironment(t *testing.T) {
+	// Find a free port, then ask the server to listen on it.
+	probe, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := strconv.Itoa(probe.Addr().(*net.TCPAddr).Port)
+	probe.Close()
+
+	setupTest(t, "HOST", "127.0.0.1", "PORT", port)
+	if got, want := config.ListenAddress(), "127.0.0.1:"+port; got != want {
+		t.Fatalf("listen address %q, want %q", got, want)
+	}
+	ln, err := net.Listen("tcp", config.ListenAddress())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	if got := ln.Addr().(*net.TCPAddr).Port; strconv.Itoa(got) != port {
+		t.Fatalf("listening on port %d, want %s", got, port)
+	}
+}
+
+func TestListenAddressDefault(t *testing.T) {
+	setupTest(t, "HOST", "", "PORT", "")
+	if got := config.ListenAddress(); got != ":"+defaultPort {
+		t.Fatalf("listen address %q, want :%s", got, defaultPort)
+	}
+}
+
+func TestMissingAzureKeyFailsFast(t *testing.T) {
+	t.Setenv("TRANSLATION_PROVIDER", "azure")
+	t.Setenv("AZURE_TRANSLATOR_KEY", "")
+	_, err := loadConfig()
+	if err == nil || !strings.Contains(err.Error(), "AZURE_TRANSLATOR_KEY") {
+		t.Fatalf("loadConfig without a key: got %v, want an error naming AZURE_TRANSLATOR_KEY", err)
+	}
+}
+
+func TestAzureDefaults(t *testing.T) {
+	t.Setenv("TRANSLATION_PROVIDER", "azure")
+	t.Setenv("AZURE_TRANSLATOR_KEY", "test-key")
+	t.Setenv("AZURE_TRANSLATOR_ENDPOINT", "")
+	t.Setenv("AZURE_TRANSLATOR_REGION", "")
+	cfg, err := loadConfig()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Endpoint != defaultEndpoint || cfg.Region != defaultRegion || cfg.SubscriptionKey != "test-key" {
+		t.Errorf("endpoint %q, region %q, key %q, want the defaults and test-key", cfg.Endpoint, cfg.Region, cfg.SubscriptionKey)
+	}
+
+	t.Setenv("AZURE_TRANSLATOR_ENDPOINT", "https://translator.example")
+	t.Setenv("AZURE_TRANSLATOR_REGION", "westeurope")
+	if cfg, err = loadConfig(); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Endpoint != "https://translator.example" || cfg.Region != "westeurope" {
+		t.Errorf("endpoint %q, region %q, want the configured ones", cfg.Endpoint, cfg.Region)
+	}
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestConflictReturnsExistingEvent(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	existing := createEvent(t, r, mustJSON(EventInfo{ID: "concert", Name: "Concert", Location: "Town Hall", Details: "Music", Languages: []string{"fr", "de"}}))
+
+	w := serveRequest(r, "POST", "/event", mustJSON(EventInfo{ID: "concert", Name: "Other", Location: "Park", Details: "Theatre", Languages: []string{"es"}}))
+	if w.Code != http.StatusConflict {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusConflict)
+	}
+	var res conflictBody
+	decodeJSON(t, w, &res)
+	if res.Error.Code != codeEventExists {
+		t.Errorf("error code %q, want %q", res.Error.Code, codeEventExists)
+	}
+	if res.Event.ID != existing.ID || res.Event.Name != "Concert" || res.Event.Location != "Town Hall" {
+		t.Errorf("conflict event %+v, want the stored one", res.Event)
+	}
+	if len(res.Event.Translations) != 2 || res.Event.Translations["fr"] != existing.Translations["fr"] || res.Event.Translations["de"] != existing.Translations["de"] {
+		t.Errorf("conflict translations %v, want %v", res.Event.Translations, existing.Translations)
+	}
+	if stored, _ := events.get("concert"); stored.Name != "Concert" {
+		t.Errorf("stored event renamed to %q", stored.Name)
+	}
+}
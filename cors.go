@@ -0,0 +1,53 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"strings"
+)
+
+// exposedHeaders are the response headers browser clients may read.
+const exposedHeaders = "ETag, Location, Retry-After, Idempotent-Replayed"
+
+// cors answers cross-origin requests from the allowed origins, where "*"
+// allows any origin. Requests from other origins get no CORS headers, so
+// browsers keep them same-origin only, and their preflight requests are
+// rejected with 403.
+func cors(origins, methods, headers []string) gin.HandlerFunc {
+	allowed := make(map[string]bool, len(origins))
+	for _, origin := range origins {
+		allowed[origin] = true
+	}
+	allowMethods := strings.Join(methods, ", ")
+	allowHeaders := strings.Join(headers, ", ")
+
+	return func(c *gin.Context) {
+		origin := c.GetHeader("Origin")
+		if origin == "" {
+			c.Next()
+			return
+		}
+		preflight := c.Request.Method == http.MethodOptions && c.GetHeader("Access-Control-Request-Method") != ""
+
+		if !allowed["*"] && !allowed[origin] {
+			if preflight {
+				abortWithError(c, http.StatusForbidden, codeOriginNotAllowed, "Origin not allowed")
+				return
+			}
+			c.Next()
+			return
+		}
+
+		c.Header("Access-Control-Allow-Origin", origin)
+		c.Header("Vary", "Origin")
+		if preflight {
+			c.Header("Access-Control-Allow-Methods", allowMethods)
+			c.Header("Access-Control-Allow-Headers", allowHeaders)
+			c.Header("Access-Control-Max-Age", "600")
+			c.AbortWithStatus(http.StatusNoContent)
+			return
+		}
+		c.Header("Access-Control-Expose-Headers", exposedHeaders)
+		c.Next()
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCORSAllowedOrigin(t *testing.T) {
+	setupTest(t, "ALLOWED_ORIGINS", "https://app.example.com")
+	w := serveRequest(newRouter(), "GET", "/events", "", "Origin", "https://app.example.com")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusOK)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://app.example.com" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want the origin", got)
+	}
+	if got := w.Header().Get("Access-Control-Expose-Headers"); got != exposedHeaders {
+		t.Errorf("Access-Control-Expose-Headers = %q, want %q", got, exposedHeaders)
+	}
+	if got := w.Header().Values("Vary"); len(got) == 0 || got[0] != "Origin" {
+		t.Errorf("Vary = %q, want Origin", got)
+	}
+}
+
+func TestCORSRejectedOrigin(t *testing.T) {
+	setupTest(t, "ALLOWED_ORIGINS", "https://app.example.com")
+	w := serveRequest(newRouter(), "GET", "/events", "", "Origin", "https://evil.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
+
+func TestCORSPreflight(t *testing.T) {
+	setupTest(t, "ALLOWED_ORIGINS", "https://app.example.com")
+	r := newRouter()
+
+	w := serveRequest(r, "OPTIONS", "/event", "", "Origin", "https://app.example.com", "Access-Control-Request-Method", "POST")
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("allowed preflight: status %d, want %d", w.Code, http.StatusNoContent)
+	}
+	if got, want := w.Header().Get("Access-Control-Allow-Methods"), "GET, POST, PUT, PATCH, DELETE"; got != want {
+		t.Errorf("Access-Control-Allow-Methods = %q, want %q", got, want)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Headers"); got == "" {
+		t.Error("Access-Control-Allow-Headers missing")
+	}
+
+	w = serveRequest(r, "OPTIONS", "/event", "", "Origin", "https://evil.example.com", "Access-Control-Request-Method", "POST")
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("rejected preflight: status %d, want %d", w.Code, http.StatusForbidden)
+	}
+	var rejected errorResponse
+	decodeJSON(t, w, &rejected)
+	if rejected.Error.Code != codeOriginNotAllowed {
+		t.Errorf("rejected preflight: body %s, want code %s", w.Body, codeOriginNotAllowed)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("rejected preflight: Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
+
+func TestCORSUnconfigured(t *testing.T) {
+	setupTest(t)
+	w := serveRequest(newRouter(), "GET", "/events", "", "Origin", "https://app.example.com")
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "" {
+		t.Errorf("Access-Control-Allow-Origin = %q, want none", got)
+	}
+}
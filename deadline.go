@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"net"
+	"net/http"
+)
+
+// translationContext derives the context the translations of a request run
+// in, bounded by config.TranslationDeadline when one is set. Each call within
+// it is still bounded by config.RequestTimeout.
+func translationContext(c *gin.Context) (context.Context, context.CancelFunc) {
+	if config.TranslationDeadline <= 0 {
+		return context.WithCancel(c.Request.Context())
+	}
+	return context.WithTimeout(c.Request.Context(), config.TranslationDeadline)
+}
+
+// respondIfDeadlineExceeded answers 504 with partial, what was translated so
+// far, when the translation deadline of ctx passed before every language
+// finished. A client that went away is handled by abortIfCanceled instead.
+func respondIfDeadlineExceeded(c *gin.Context, ctx context.Context, failures map[string]error, partial interface{}) bool {
+	if len(failures) == 0 || c.Request.Context().Err() != nil || !errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return false
+	}
+	c.JSON(http.StatusGatewayTimeout, gin.H{
+		"error": apiError{
+			Code:    codeDeadlineExceeded,
+			Message: fmt.Sprintf("Translation did not finish within %s", config.TranslationDeadline),
+		},
+		"partial": partial,
+	})
+	return true
+}
+
+// isTimeout reports whether a single translation call timed out.
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestCanceledContextStopsRemainingLanguages(t *testing.T) {
+	setupTest(t, "TRANSLATION_CONCURRENCY", "1")
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := useFakeProvider(func(text, from, to string) (string, error) {
+		cancel()
+		return to + ":" + text, nil
+	})
+
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr", "de", "es"}}
+	failures := translateEvent(ctx, &event)
+	if n := fake.totalCalls(); n != 1 {
+		t.Fatalf("made %d calls, want only the first language translated", n)
+	}
+	if len(event.Translations) != 1 || event.Translations["fr"] == "" {
+		t.Errorf("translations %v, want only fr", event.Translations)
+	}
+	for _, lang := range []string{"de", "es"} {
+		if !errors.Is(failures[lang], context.Canceled) {
+			t.Errorf("%s failure %v, want context.Canceled", lang, failures[lang])
+		}
+	}
+}
+
+func TestTranslationDeadlineReturnsPartial(t *testing.T) {
+	setupTest(t, "TRANSLATION_DEADLINE", "100ms", "TRANSLATION_CONCURRENCY", "1", "TRANSLATOR_MAX_RETRIES", "0")
+	useFakeProvider(func(text, from, to string) (string, error) {
+		if to != "fr" {
+			time.Sleep(300 * time.Millisecond)
+		}
+		return to + ":" + text, nil
+	})
+
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "fr", "de", "es"))
+	if w.Code != http.StatusGatewayTimeout {
+		t.Fatalf("status %d, want 504: %s", w.Code, w.Body)
+	}
+	var res struct {
+		Error   apiError  `json:"error"`
+		Partial EventInfo `json:"partial"`
+	}
+	decodeJSON(t, w, &res)
+	if res.Error.Code != codeDeadlineExceeded || res.Partial.Translations["fr"] == "" {
+		t.Fatalf("got %+v, want the deadline error with the fr translation", res)
+	}
+	if n := len(events.list()); n != 0 {
+		t.Fatalf("stored %d events, want none", n)
+	}
+}
+
+// stuckAzure answers translations into stuck only once the test ends, and
+// the others at once.
+func stuckAzure(t *testing.T, stuck string) {
+	t.Helper()
+	release := make(chan struct{})
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("to") == stuck {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			return
+		}
+		echoAzure(nil)(w, r)
+	})
+	// Cleanups run last first, so release is closed before the server
+	// waits for its handlers.
+	t.Cleanup(func() { close(release) })
+}
+
+func TestCallTimeoutWithinDeadline(t *testing.T) {
+	setupTest(t, "TRANSLATOR_TIMEOUT", "50ms", "TRANSLATION_DEADLINE", "10s", "TRANSLATOR_MAX_RETRIES", "0")
+	stuckAzure(t, "de")
+
+	start := time.Now()
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "de"))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("took %v, want the stuck call cut off after 50ms", elapsed)
+	}
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if w.Code != http.StatusGatewayTimeout || res.Error.Code != codeProviderTimeout {
+		t.Fatalf("status %d, code %q, want %d %q", w.Code, res.Error.Code, http.StatusGatewayTimeout, codeProviderTimeout)
+	}
+}
+
+func TestDeadlineWithinCallTimeout(t *testing.T) {
+	setupTest(t, "TRANSLATOR_TIMEOUT", "10s", "TRANSLATION_DEADLINE", "100ms", "TRANSLATOR_MAX_RETRIES", "0")
+	stuckAzure(t, "de")
+
+	start := time.Now()
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "fr", "de"))
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("took %v, want the request cut off at the 100ms deadline", elapsed)
+	}
+	var res deadlineBody
+	decodeJSON(t, w, &res)
+	if w.Code != http.StatusGatewayTimeout || res.Error.Code != codeDeadlineExceeded {
+		t.Fatalf("status %d, code %q, want %d %q", w.Code, res.Error.Code, http.StatusGatewayTimeout, codeDeadlineExceeded)
+	}
+	if partial, _ := res.Partial.(map[string]interface{}); partial == nil || partial["translations"] == nil {
+		t.Fatalf("partial %v, want the fr translation", res.Partial)
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"sync"
+)
+
+// languageDebug is what was sent to the provider to translate into one
+// language, as returned with ?debug=true.
+type languageDebug struct {
+	// Segments are the prepared texts, with keywords and glossary terms
+	// replaced by placeholders.
+	Segments []previewSegment `json:"segments"`
+	// Placeholders maps each placeholder to the text it is restored to.
+	Placeholders map[string]string `json:"placeholders"`
+	// Calls holds the texts of each provider call made for the language,
+	// after chunking and leaving out those served from the cache.
+	Calls [][]string `json:"calls"`
+}
+
+// translationDebug collects the debugging details of a request. It is only
+// ever returned in the response: it is never stored with the event nor
+// logged.
+type translationDebug struct {
+	sync.Mutex
+	languages map[string]*languageDebug
+}
+
+type translationDebugKey struct{}
+
+// withTranslationDebug attaches a collector to ctx when the request asked for
+// debugging details with ?debug=true.
+func withTranslationDebug(ctx context.Context, enabled bool) (context.Context, *translationDebug) {
+	if !enabled {
+		return ctx, nil
+	}
+	debug := &translationDebug{languages: make(map[string]*languageDebug)}
+	return context.WithValue(ctx, translationDebugKey{}, debug), debug
+}
+
+// withoutTranslationDebug hides the collector of ctx from calls that are not
+// part of what the request asked to see.
+func withoutTranslationDebug(ctx context.Context) context.Context {
+	if debugFromContext(ctx) == nil {
+		return ctx
+	}
+	return context.WithValue(ctx, translationDebugKey{}, (*translationDebug)(nil))
+}
+
+// debugFromContext returns the collector attached to ctx, or nil. All
+// methods accept a nil receiver so callers need not check.
+func debugFromContext(ctx context.Context) *translationDebug {
+	debug, _ := ctx.Value(translationDebugKey{}).(*translationDebug)
+	return debug
+}
+
+func (d *translationDebug) language(lang string) *languageDebug {
+	language, ok := d.languages[lang]
+	if !ok {
+		language = &languageDebug{}
+		d.languages[lang] = language
+	}
+	return language
+}
+
+func (d *translationDebug) recordPrepared(lang string, segments []eventSegment, prepared []string, placeholderMap map[string]string) {
+	if d == nil {
+		return
+	}
+	d.Lock()
+	defer d.Unlock()
+	language := d.language(lang)
+	language.Segments = make([]previewSegment, len(segments))
+	for i, segment := range segments {
+		language.Segments[i] = previewSegment{Role: segment.role, Text: prepared[i]}
+	}
+	language.Placeholders = placeholderMap
+}
+
+func (d *translationDebug) recordCall(lang string, texts []string) {
+	if d == nil {
+		return
+	}
+	d.Lock()
+	defer d.Unlock()
+	language := d.language(lang)
+	language.Calls = append(language.Calls, append([]string(nil), texts...))
+}
+
+// snapshot returns the collected details by language, or nil when debugging
+// was not requested.
+func (d *translationDebug) snapshot() map[string]languageDebug {
+	if d == nil {
+		return nil
+	}
+	d.Lock()
+	defer d.Unlock()
+	languages := make(map[string]languageDebug, len(d.languages))
+	for lang, language := range d.languages {
+		languages[lang] = *language
+	}
+	return languages
+}
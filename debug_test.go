@@ -0,0 +1,85 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestPostEventDebug(t *testing.T) {
+	setupTest(t)
+	logs := captureLogs(t)
+	r := newRouter()
+
+	event := EventInfo{Name: "Jazz", Location: "Hall", Details: "Music", Keywords: []string{"Jazz"}, Languages: []string{"fr"}}
+	w := serveRequest(r, "POST", "/event?debug=true", mustJSON(event))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /event?debug=true: status %d, body %s", w.Code, w.Body)
+	}
+	var res eventWithStats
+	decodeJSON(t, w, &res)
+	fr, ok := res.Debug["fr"]
+	if !ok {
+		t.Fatalf("debug %+v, want details for fr", res.Debug)
+	}
+	if len(fr.Placeholders) != 1 {
+		t.Fatalf("placeholders %v, want one for Jazz", fr.Placeholders)
+	}
+	var placeholder string
+	for p, keyword := range fr.Placeholders {
+		if keyword != "Jazz" {
+			t.Errorf("placeholder %q restores to %q, want Jazz", p, keyword)
+		}
+		placeholder = p
+	}
+	if len(fr.Segments) == 0 || !strings.Contains(fr.Segments[0].Text, placeholder) || strings.Contains(fr.Segments[0].Text, "Jazz") {
+		t.Errorf("segments %+v, want the name sent as %q", fr.Segments, placeholder)
+	}
+	if len(fr.Calls) != 1 {
+		t.Errorf("calls %v, want one", fr.Calls)
+	}
+	if res.Stats != nil {
+		t.Errorf("stats %+v, want none without ?stats=true", res.Stats)
+	}
+
+	if strings.Contains(logs.String(), placeholder) {
+		t.Errorf("placeholder %q logged: %s", placeholder, logs)
+	}
+	w = serveRequest(r, "GET", "/event?id="+res.ID, "")
+	if strings.Contains(w.Body.String(), `"debug"`) || strings.Contains(w.Body.String(), placeholder) {
+		t.Errorf("stored event has debugging details: %s", w.Body)
+	}
+}
+
+func TestPostEventWithoutDebug(t *testing.T) {
+	setupTest(t)
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "fr"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /event: status %d, body %s", w.Code, w.Body)
+	}
+	var fields map[string]json.RawMessage
+	decodeJSON(t, w, &fields)
+	if _, ok := fields["debug"]; ok {
+		t.Errorf("response has debug without ?debug=true: %s", w.Body)
+	}
+}
+
+func TestTranslateDebug(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	w := serveRequest(r, "POST", "/translate?debug=true", `{"text":"Hello","to":["fr","de"]}`)
+	var res translateResponse
+	decodeJSON(t, w, &res)
+	if len(res.Debug) != 2 || len(res.Debug["fr"].Calls) != 1 || res.Debug["fr"].Calls[0][0] != "Hello" {
+		t.Errorf("debug %+v, want the call made for fr and de", res.Debug)
+	}
+
+	w = serveRequest(r, "POST", "/translate", `{"text":"Hello","to":["fr"]}`)
+	res = translateResponse{}
+	decodeJSON(t, w, &res)
+	if res.Debug != nil {
+		t.Errorf("debug %+v without ?debug=true", res.Debug)
+	}
+}
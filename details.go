@@ -0,0 +1,40 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"text/template"
+)
+
+// defaultDetailsTemplate assembles the text of an event's "details" segment.
+const defaultDetailsTemplate = "{{.Name}} Location: {{.Location}} Details: {{.Details}}"
+
+// detailsTemplate is executed with the EventInfo to build the text of its
+// "details" segment. It is replaced from DETAILS_TEMPLATE at startup.
+var detailsTemplate = template.Must(parseDetailsTemplate(defaultDetailsTemplate))
+
+// parseDetailsTemplate parses text and checks it runs against an event, so a
+// template naming an unknown field is rejected at startup rather than on the
+// first request.
+func parseDetailsTemplate(text string) (*template.Template, error) {
+	tmpl, err := template.New("details").Parse(text)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing details template: %v", err)
+	}
+	if err := tmpl.Execute(&strings.Builder{}, EventInfo{}); err != nil {
+		return nil, fmt.Errorf("error executing details template: %v", err)
+	}
+	return tmpl, nil
+}
+
+func assembleDetails(event EventInfo) string {
+	var b strings.Builder
+	if err := detailsTemplate.Execute(&b, event); err != nil {
+		// The template ran against an event at startup, so this only
+		// happens for data it cannot handle; fall back to the default
+		// layout rather than fail the translation.
+		logger.Error("error executing details template", "error", err)
+		return event.Name + " Location: " + event.Location + " Details: " + event.Details
+	}
+	return b.String()
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestCustomDetailsTemplate(t *testing.T) {
+	setupTest(t, "DETAILS_TEMPLATE", "{{.Details}} ({{.Name}}, {{.Location}})")
+	var sent string
+	useFakeProvider(func(text, from, to string) (string, error) {
+		sent = text
+		return text, nil
+	})
+
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "de"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /event: status %d, body %s", w.Code, w.Body)
+	}
+	if want := "An evening of music (Concert, Town Hall)"; sent != want {
+		t.Fatalf("prepared text %q, want %q", sent, want)
+	}
+}
+
+func TestDefaultDetailsTemplate(t *testing.T) {
+	setupTest(t)
+	got := assembleDetails(EventInfo{Name: "Concert", Location: "Town Hall", Details: "Music"})
+	if want := "Concert Location: Town Hall Details: Music"; got != want {
+		t.Fatalf("assembleDetails = %q, want %q", got, want)
+	}
+}
+
+func TestInvalidDetailsTemplateRejected(t *testing.T) {
+	for _, text := range []string{"{{.Name", "{{.Venue}}"} {
+		if _, err := parseDetailsTemplate(text); err == nil {
+			t.Errorf("parseDetailsTemplate(%q) succeeded, want an error", text)
+		}
+	}
+}
@@ -0,0 +1,34 @@
+package main
+
+import (
+	"context"
+)
+
+// languageDetector is implemented by providers that can identify the
+// language of a text.
+type languageDetector interface {
+	Detect(ctx context.Context, text string) (language string, score float64, err error)
+}
+
+// detectLanguage identifies the language of text. It reports false when the
+// provider cannot detect languages or the call fails, leaving the provider to
+// auto-detect during translation as before.
+func detectLanguage(ctx context.Context, text string) (string, float64, bool) {
+	detector, ok := provider.(languageDetector)
+	if !ok {
+		return "", 0, false
+	}
+
+	var language string
+	var score float64
+	err := withRetry(ctx, func() error {
+		var err error
+		language, score, err = detector.Detect(ctx, text)
+		return err
+	})
+	if err != nil {
+		logger.Warn("language detection failed", "error", err)
+		return "", 0, false
+	}
+	return language, score, true
+}
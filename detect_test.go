@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"testing"
+)
+
+// detectingProvider detects every text as language with score.
+type detectingProvider struct {
+	*fakeProvider
+	language string
+	score    float64
+}
+
+func (p detectingProvider) Detect(ctx context.Context, text string) (string, float64, error) {
+	return p.language, p.score, nil
+}
+
+func TestDetectLanguage(t *testing.T) {
+	setupTest(t)
+	var sources []string
+	fake := useFakeProvider(func(text, from, to string) (string, error) {
+		sources = append(sources, from)
+		return to + ":" + text, nil
+	})
+	provider = detectingProvider{fakeProvider: fake, language: "es", score: 0.93}
+
+	event := EventInfo{Name: "Concierto", Location: "Sala", Details: "Música", Languages: []string{"fr"}, DetectLanguage: true}
+	created := createEvent(t, newRouter(), mustJSON(event))
+	if created.DetectedLanguage != "es" || created.DetectionScore != 0.93 {
+		t.Errorf("detected %q with score %v, want es with 0.93", created.DetectedLanguage, created.DetectionScore)
+	}
+	if len(sources) != 1 || sources[0] != "es" {
+		t.Errorf("translated from %v, want the detected es", sources)
+	}
+	if created.Results["fr"].SourceLanguage != "es" {
+		t.Errorf("result source language %q, want es", created.Results["fr"].SourceLanguage)
+	}
+}
+
+func TestDetectLanguageSkippedWithSourceLanguage(t *testing.T) {
+	setupTest(t)
+	fake := useFakeProvider(nil)
+	provider = detectingProvider{fakeProvider: fake, language: "es", score: 0.93}
+
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr"}, DetectLanguage: true, SourceLanguage: "en"}
+	created := createEvent(t, newRouter(), mustJSON(event))
+	if created.DetectedLanguage != "" {
+		t.Errorf("detected %q although the source language was given", created.DetectedLanguage)
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"html"
+	"strings"
+	"unicode/utf16"
+	"unicode/utf8"
+)
+
+// Output escaping modes selected by config.OutputEscaping.
+const (
+	escapeRaw     = "raw"
+	escapeHTML    = "html"
+	escapeUnicode = "unicode"
+)
+
+// escapeOutput HTML-escapes a translated text when config.OutputEscaping is
+// "html". It runs on the final text, after keywords and glossary terms are
+// restored, so they are escaped exactly once.
+func escapeOutput(text string) string {
+	if config.OutputEscaping == escapeHTML {
+		return html.EscapeString(text)
+	}
+	return text
+}
+
+// escapeTranslations applies escapeOutput to every translated text of event.
+func escapeTranslations(event *EventInfo) {
+	if config.OutputEscaping != escapeHTML {
+		return
+	}
+	for lang, text := range event.Translations {
+		event.Translations[lang] = escapeOutput(text)
+	}
+	for lang, result := range event.Results {
+		result.Text = escapeOutput(result.Text)
+		event.Results[lang] = result
+	}
+	for _, names := range event.TranslatedLinkNames {
+		for lang, name := range names {
+			names[lang] = escapeOutput(name)
+		}
+	}
+	for lang, message := range event.TranslatedSponsoredMessage {
+		event.TranslatedSponsoredMessage[lang] = escapeOutput(message)
+	}
+	for _, alternatives := range event.Alternatives {
+		for i, alternative := range alternatives {
+			alternatives[i] = escapeOutput(alternative)
+		}
+	}
+	for lang, romanized := range event.Transliterations {
+		event.Transliterations[lang] = escapeOutput(romanized)
+	}
+	for _, keywords := range event.TranslatedKeywords {
+		for i, keyword := range keywords {
+			keywords[i] = escapeOutput(keyword)
+		}
+	}
+}
+
+// asciiJSON writes JSON responses with every non-ASCII character escaped as
+// \uXXXX, for consumers that cannot handle raw Unicode. Outside JSON strings
+// JSON is pure ASCII, so the escapes always land inside strings.
+func asciiJSON() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Writer = &asciiJSONWriter{ResponseWriter: c.Writer}
+		c.Next()
+	}
+}
+
+type asciiJSONWriter struct {
+	gin.ResponseWriter
+}
+
+func (w *asciiJSONWriter) Write(data []byte) (int, error) {
+	if !strings.Contains(w.Header().Get("Content-Type"), "json") {
+		return w.ResponseWriter.Write(data)
+	}
+	if _, err := w.ResponseWriter.WriteString(escapeNonASCII(string(data))); err != nil {
+		return 0, err
+	}
+	return len(data), nil
+}
+
+func (w *asciiJSONWriter) WriteString(s string) (int, error) {
+	return w.Write([]byte(s))
+}
+
+// escapeNonASCII replaces each non-ASCII character of s with its JSON
+// escape, using a surrogate pair for characters outside the BMP.
+func escapeNonASCII(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < utf8.RuneSelf:
+			b.WriteRune(r)
+		case r > 0xFFFF:
+			high, low := utf16.EncodeRune(r)
+			fmt.Fprintf(&b, `\u%04x\u%04x`, high, low)
+		default:
+			fmt.Fprintf(&b, `\u%04x`, r)
+		}
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"unicode/utf8"
+)
+
+const specialTranslation = "<b>Café</b> & more 🎵"
+
+// postSpecialEvent creates an event whose French translation is the R&B
+// keyword followed by specialTranslation.
+func postSpecialEvent(t *testing.T) *httptest.ResponseRecorder {
+	t.Helper()
+	useFakeProvider(func(text, from, to string) (string, error) {
+		return text + " " + specialTranslation, nil
+	})
+	event := EventInfo{Name: "R&B", Location: "Hall", Details: "Music", Keywords: []string{"R&B"}, Languages: []string{"fr"}}
+	w := serveRequest(newRouter(), "POST", "/event", mustJSON(event))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	return w
+}
+
+func TestOutputEscapingRaw(t *testing.T) {
+	setupTest(t, "DETAILS_TEMPLATE", "{{.Name}}")
+	var created EventInfo
+	decodeJSON(t, postSpecialEvent(t), &created)
+	if got, want := created.Translations["fr"], "R&B "+specialTranslation; got != want {
+		t.Fatalf("translation %q, want %q", got, want)
+	}
+}
+
+func TestOutputEscapingHTML(t *testing.T) {
+	setupTest(t, "DETAILS_TEMPLATE", "{{.Name}}", "OUTPUT_ESCAPING", "html")
+	var created EventInfo
+	decodeJSON(t, postSpecialEvent(t), &created)
+	if got, want := created.Translations["fr"], "R&amp;B &lt;b&gt;Café&lt;/b&gt; &amp; more 🎵"; got != want {
+		t.Fatalf("translation %q, want %q", got, want)
+	}
+	if got := created.Results["fr"].Text; got != created.Translations["fr"] {
+		t.Fatalf("result text %q, want it escaped like the translation", got)
+	}
+}
+
+func TestOutputEscapingUnicode(t *testing.T) {
+	setupTest(t, "DETAILS_TEMPLATE", "{{.Name}}", "OUTPUT_ESCAPING", "unicode")
+	w := postSpecialEvent(t)
+	for _, b := range w.Body.Bytes() {
+		if b >= utf8.RuneSelf {
+			t.Fatalf("body %s has non-ASCII bytes", w.Body)
+		}
+	}
+	var created EventInfo
+	decodeJSON(t, w, &created)
+	if got, want := created.Translations["fr"], "R&B "+specialTranslation; got != want {
+		t.Fatalf("decoded translation %q, want %q", got, want)
+	}
+}
+
+func TestEscapeNonASCII(t *testing.T) {
+	if got, want := escapeNonASCII(`"é🎵"`), `"\u00e9\ud83c\udfb5"`; got != want {
+		t.Fatalf("escapeNonASCII = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"context"
+	"errors"
+)
+
+// fallbackChain is lang followed by the languages tried in turn when the
+// provider does not support it, following config.LanguageFallbacks, e.g.
+// "pt-BR" -> "pt". A cycle in the map ends the chain.
+func fallbackChain(lang string) []string {
+	chain := []string{lang}
+	seen := map[string]bool{lang: true}
+	for next, ok := config.LanguageFallbacks[lang]; ok && !seen[next]; next, ok = config.LanguageFallbacks[next] {
+		seen[next] = true
+		chain = append(chain, next)
+	}
+	return chain
+}
+
+// isUnsupportedLanguage reports whether the provider rejected a translation
+// because it does not support one of its languages.
+func isUnsupportedLanguage(err error) bool {
+	return errors.Is(err, ErrUnsupportedLanguage)
+}
+
+// translateWithFallback translates segments into lang, moving along its
+// fallback chain while the provider rejects the language as unsupported. It
+// returns the fallback language used, or "" when lang itself was translated.
+func translateWithFallback(ctx context.Context, event EventInfo, segments []eventSegment, from, lang string) (segmentTranslation, string, error) {
+	var err error
+	for i, target := range fallbackChain(lang) {
+		var translated segmentTranslation
+		translated, err = translateSegments(ctx, event, segments, from, target)
+		if err == nil {
+			if i == 0 {
+				return translated, "", nil
+			}
+			return translated, target, nil
+		}
+		if !isUnsupportedLanguage(err) {
+			break
+		}
+		logger.Info("language not supported", "language", target, "error", err)
+	}
+	return segmentTranslation{}, "", err
+}
@@ -0,0 +1,69 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// rejectLanguages installs a fake provider that does not support langs.
+func rejectLanguages(langs ...string) *fakeProvider {
+	return useFakeProvider(func(text, from, to string) (string, error) {
+		for _, lang := range langs {
+			if to == lang {
+				return "", fmt.Errorf("%s: %w", to, ErrUnsupportedLanguage)
+			}
+		}
+		return to + ":" + text, nil
+	})
+}
+
+func TestLanguageFallbackApplied(t *testing.T) {
+	setupTest(t, "LANGUAGE_FALLBACKS", "pt-BR=pt")
+	fake := rejectLanguages("pt-BR")
+
+	created := createEvent(t, newRouter(), eventBody("Concert", "pt-BR"))
+	result := created.Results["pt-BR"]
+	if result.FallbackLanguage != "pt" {
+		t.Fatalf("fallback language %q, want pt", result.FallbackLanguage)
+	}
+	if !strings.HasPrefix(created.Translations["pt-BR"], "pt:") {
+		t.Fatalf("translation %q, want the pt translation", created.Translations["pt-BR"])
+	}
+	if fake.callCount("pt-BR") != 1 || fake.callCount("pt") != 1 {
+		t.Fatalf("calls %v, want pt-BR then pt", fake.calls)
+	}
+}
+
+func TestLanguageWithoutFallbackFails(t *testing.T) {
+	setupTest(t)
+	rejectLanguages("pt-BR")
+
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "pt-BR"))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want %d", w.Code, http.StatusBadRequest)
+	}
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if res.Error.Code != codeUnsupportedLanguage {
+		t.Fatalf("error code %q, want %q", res.Error.Code, codeUnsupportedLanguage)
+	}
+}
+
+func TestSupportedLanguageSkipsFallback(t *testing.T) {
+	setupTest(t, "LANGUAGE_FALLBACKS", "pt-BR=pt")
+	fake := rejectLanguages()
+
+	created := createEvent(t, newRouter(), eventBody("Concert", "pt-BR"))
+	if created.Results["pt-BR"].FallbackLanguage != "" || fake.callCount("pt") != 0 {
+		t.Fatalf("result %+v, calls %v, want pt-BR translated directly", created.Results["pt-BR"], fake.calls)
+	}
+}
+
+func TestFallbackChainStopsAtCycle(t *testing.T) {
+	setupTest(t, "LANGUAGE_FALLBACKS", "zh-HK=zh-Hant,zh-Hant=zh-HK")
+	if got := strings.Join(fallbackChain("zh-HK"), ","); got != "zh-HK,zh-Hant" {
+		t.Fatalf("chain %s, want zh-HK,zh-Hant", got)
+	}
+}
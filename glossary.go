@@ -0,0 +1,55 @@
+package main
+
+import (
+	"strings"
+)
+
+// glossaryFor returns the glossary renderings for lang, keyed by the
+// sanitized term. Languages are matched case-insensitively.
+func glossaryFor(glossary map[string]map[string]string, lang string) map[string]string {
+	renderings := make(map[string]string)
+	for term, byLanguage := range glossary {
+		for l, rendering := range byLanguage {
+			if strings.EqualFold(l, lang) {
+				renderings[sanitizeText(term)] = rendering
+			}
+		}
+	}
+	return renderings
+}
+
+func glossaryTerms(renderings map[string]string) []string {
+	terms := make([]string, 0, len(renderings))
+	for term := range renderings {
+		terms = append(terms, term)
+	}
+	return terms
+}
+
+// applyGlossary makes placeholders that stand for a glossary term restore to
+// the term's forced rendering instead of the term itself. Glossary terms are
+// protected with the same placeholders as keywords, so the provider never
+// sees or translates them, and a term listed in both wins as a glossary term.
+func applyGlossary(placeholderMap, renderings map[string]string) map[string]string {
+	if len(renderings) == 0 {
+		return placeholderMap
+	}
+
+	lowered := make(map[string]string, len(renderings))
+	for term, rendering := range renderings {
+		lowered[strings.ToLower(term)] = rendering
+	}
+
+	restore := make(map[string]string, len(placeholderMap))
+	for placeholder, original := range placeholderMap {
+		if rendering, ok := renderings[original]; ok {
+			restore[placeholder] = rendering
+		} else if rendering, ok := lowered[strings.ToLower(original)]; ok {
+			// Case-insensitive matching keeps the casing found in the text.
+			restore[placeholder] = rendering
+		} else {
+			restore[placeholder] = original
+		}
+	}
+	return restore
+}
@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGlossaryOverridesTranslation(t *testing.T) {
+	setupTest(t)
+	event := EventInfo{
+		Name:      "Spring",
+		Location:  "Hall",
+		Details:   "Join the Gala tonight",
+		Languages: []string{"fr", "de", "es"},
+		Glossary: map[string]map[string]string{
+			"Gala": {"fr": "Soirée de gala", "DE": "Festabend"},
+		},
+	}
+	created := createEvent(t, newRouter(), mustJSON(event))
+
+	want := map[string]string{"fr": "Soirée de gala", "de": "Festabend", "es": "Gala"}
+	for lang, rendering := range want {
+		text := created.Translations[lang]
+		if !strings.Contains(text, "Join the "+rendering+" tonight") {
+			t.Errorf("%s translation %q, want the rendering %q", lang, text, rendering)
+		}
+	}
+	if strings.Contains(created.Translations["fr"], "Gala tonight") {
+		t.Errorf("fr translation %q kept the source term", created.Translations["fr"])
+	}
+}
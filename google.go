@@ -0,0 +1,218 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const defaultGoogleEndpoint = "https://translation.googleapis.com/language/translate/v2"
+
+// googleProvider translates through the Google Cloud Translation v2 REST API.
+type googleProvider struct {
+	endpoint string
+	apiKey   string
+	client   *http.Client
+}
+
+func newGoogleProvider(cfg Config, client *http.Client) *googleProvider {
+	return &googleProvider{
+		endpoint: cfg.GoogleEndpoint,
+		apiKey:   cfg.GoogleAPIKey,
+		client:   client,
+	}
+}
+
+type googleTranslateRequest struct {
+	Q      []string `json:"q"`
+	Target string   `json:"target"`
+	Source string   `json:"source,omitempty"`
+	Format string   `json:"format"`
+}
+
+type googleTranslateResponse struct {
+	Data struct {
+		Translations []struct {
+			TranslatedText string `json:"translatedText"`
+		} `json:"translations"`
+	} `json:"data"`
+}
+
+func (p *googleProvider) Translate(ctx context.Context, text, from, to string, opts TranslateOptions) (string, error) {
+	translated, err := p.TranslateBatch(ctx, []string{text}, from, to, opts)
+	if err != nil {
+		return "", err
+	}
+	return translated[0], nil
+}
+
+// TranslateBatch sends every text as its own "q" entry of a single request.
+func (p *googleProvider) TranslateBatch(ctx context.Context, texts []string, from, to string, opts TranslateOptions) ([]string, error) {
+	body := googleTranslateRequest{Q: texts, Target: to, Source: from, Format: "text"}
+	if opts.isHTML() {
+		body.Format = "html"
+	}
+	jsonBody, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	uri := p.endpoint + "?key=" + url.QueryEscape(p.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, bytes.NewBuffer(jsonBody))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProviderUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newGoogleError(resp, respBody)
+	}
+
+	var res googleTranslateResponse
+	if err := json.Unmarshal(respBody, &res); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTranslationDecode, err)
+	}
+
+	if len(res.Data.Translations) == 0 {
+		return nil, fmt.Errorf("%w: no translations found", ErrTranslationDecode)
+	}
+	translated := make([]string, len(res.Data.Translations))
+	for i, item := range res.Data.Translations {
+		translated[i] = item.TranslatedText
+	}
+	return translated, nil
+}
+
+type googleLanguagesResponse struct {
+	Data struct {
+		Languages []struct {
+			Language string `json:"language"`
+			Name     string `json:"name"`
+		} `json:"languages"`
+	} `json:"data"`
+}
+
+// Languages lists Google's supported languages with their English names.
+func (p *googleProvider) Languages(ctx context.Context) ([]languageInfo, error) {
+	uri := p.endpoint + "/languages?target=en&key=" + url.QueryEscape(p.apiKey)
+	req, err := http.NewRequestWithContext(ctx, "GET", uri, nil)
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrProviderUnreachable, err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("error reading response body: %v", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, newGoogleError(resp, respBody)
+	}
+
+	var res googleLanguagesResponse
+	if err := json.Unmarshal(respBody, &res); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrTranslationDecode, err)
+	}
+	languages := make([]languageInfo, len(res.Data.Languages))
+	for i, language := range res.Data.Languages {
+		languages[i] = languageInfo{Code: language.Language, Name: language.Name}
+	}
+	return languages, nil
+}
+
+// googleError reports a non-OK response from the Google Translation API.
+type googleError struct {
+	StatusCode int
+	Status     string
+	Message    string
+	Body       []byte
+	RetryAfter time.Duration
+}
+
+type googleErrorEnvelope struct {
+	Error struct {
+		Code    int    `json:"code"`
+		Message string `json:"message"`
+		Status  string `json:"status"`
+	} `json:"error"`
+}
+
+func newGoogleError(resp *http.Response, body []byte) *googleError {
+	googleErr := &googleError{
+		StatusCode: resp.StatusCode,
+		Body:       body,
+		RetryAfter: parseRetryAfter(resp.Header.Get("Retry-After")),
+	}
+
+	var envelope googleErrorEnvelope
+	if err := json.Unmarshal(body, &envelope); err == nil {
+		googleErr.Status = envelope.Error.Status
+		googleErr.Message = envelope.Error.Message
+	}
+	return googleErr
+}
+
+func (e *googleError) Error() string {
+	if e.Message == "" {
+		return fmt.Sprintf("non-OK HTTP status: %d, response: %s", e.StatusCode, e.Body)
+	}
+	return fmt.Sprintf("google error %s (HTTP %d): %s", e.Status, e.StatusCode, e.Message)
+}
+
+func (e *googleError) HTTPStatus() int {
+	return e.StatusCode
+}
+
+func (e *googleError) ErrorCode() int {
+	return e.StatusCode
+}
+
+func (e *googleError) ResponseBody() []byte {
+	return e.Body
+}
+
+func (e *googleError) RetryDelay() time.Duration {
+	return e.RetryAfter
+}
+
+func (e *googleError) IsAuth() bool {
+	return e.StatusCode == http.StatusUnauthorized ||
+		e.StatusCode == http.StatusForbidden && !e.IsQuota()
+}
+
+func (e *googleError) IsQuota() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.Status == "RESOURCE_EXHAUSTED"
+}
+
+// IsUnsupportedLanguage reports Google rejecting the language pair, or the
+// target language as an invalid value.
+func (e *googleError) IsUnsupportedLanguage() bool {
+	return e.StatusCode == http.StatusBadRequest &&
+		(strings.Contains(e.Message, "language pair") || e.Message == "Invalid Value")
+}
+
+func (e *googleError) Is(target error) bool {
+	return providerErrorIs(e, target)
+}
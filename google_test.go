@@ -0,0 +1,23 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestGoogleUnreachable(t *testing.T) {
+	// A server that is closed before it is called refuses the connection.
+	server := httptest.NewServer(http.NotFoundHandler())
+	server.Close()
+	p := newGoogleProvider(Config{GoogleEndpoint: server.URL, GoogleAPIKey: "test-key"}, server.Client())
+
+	if _, err := p.TranslateBatch(context.Background(), []string{"Concert"}, "", "fr", TranslateOptions{}); !errors.Is(err, ErrProviderUnreachable) {
+		t.Errorf("TranslateBatch: got %v, want ErrProviderUnreachable", err)
+	}
+	if _, err := p.Languages(context.Background()); !errors.Is(err, ErrProviderUnreachable) {
+		t.Errorf("Languages: got %v, want ErrProviderUnreachable", err)
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"time"
+)
+
+const (
+	healthProbeText     = "ok"
+	healthProbeLanguage = "fr"
+	healthProbeTimeout  = 5 * time.Second
+)
+
+// healthz reports whether the service is up and the state of the circuit
+// breaker. With ?deep=true it also makes a small uncached translation to
+// confirm the provider credentials are usable.
+func healthz(c *gin.Context) {
+	circuit, _ := breaker.status(time.Now())
+	if c.Query("deep") != "true" {
+		c.JSON(http.StatusOK, gin.H{"status": "ok", "circuit": circuit})
+		return
+	}
+
+	if err := probeTranslator(c.Request.Context()); err != nil {
+		respondError(c, http.StatusServiceUnavailable, codeProviderError, err.Error())
+		return
+	}
+	c.JSON(http.StatusOK, gin.H{"status": "ok", "circuit": circuit})
+}
+
+// probeTranslator is a variable so the deep health check can be exercised
+// without reaching the provider.
+var probeTranslator = func(ctx context.Context) error {
+	ctx, cancel := context.WithTimeout(ctx, healthProbeTimeout)
+	defer cancel()
+
+	_, err := provider.Translate(ctx, healthProbeText, "", healthProbeLanguage, TranslateOptions{})
+	return err
+}
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestHealthzShallow(t *testing.T) {
+	setupTest(t)
+	probed := false
+	probe := probeTranslator
+	t.Cleanup(func() { probeTranslator = probe })
+	probeTranslator = func(ctx context.Context) error {
+		probed = true
+		return errors.New("unreachable")
+	}
+
+	w := serveRequest(newRouter(), "GET", "/healthz", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200", w.Code)
+	}
+	if probed {
+		t.Fatal("the shallow check called the translator")
+	}
+}
+
+func TestHealthzDeepFailure(t *testing.T) {
+	setupTest(t)
+	probe := probeTranslator
+	t.Cleanup(func() { probeTranslator = probe })
+	probeTranslator = func(ctx context.Context) error {
+		return errors.New("unreachable")
+	}
+
+	w := serveRequest(newRouter(), "GET", "/healthz?deep=true", "")
+	if w.Code != http.StatusServiceUnavailable {
+		t.Fatalf("status %d, want 503", w.Code)
+	}
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if res.Error.Code != codeProviderError || res.Error.Message != "unreachable" {
+		t.Fatalf("got %+v", res)
+	}
+}
+
+func TestHealthzDeepSuccess(t *testing.T) {
+	setupTest(t)
+	w := serveRequest(newRouter(), "GET", "/healthz?deep=true", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200 with the mock provider: %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,155 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"github.com/gin-gonic/gin"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// idempotentResponse is the stored outcome of the first request made with an
+// Idempotency-Key. Until that request finishes, done is false.
+type idempotentResponse struct {
+	done        bool
+	fingerprint [sha256.Size]byte
+	status      int
+	contentType string
+	body        []byte
+	expires     time.Time
+}
+
+// idempotencyStore remembers the successful responses to requests carrying an
+// Idempotency-Key header for ttl, so a retried request is answered with the
+// original response instead of being processed again.
+type idempotencyStore struct {
+	sync.Mutex
+	ttl       time.Duration
+	responses map[string]*idempotentResponse
+	lastSweep time.Time
+}
+
+func newIdempotencyStore(ttl time.Duration) *idempotencyStore {
+	return &idempotencyStore{
+		ttl:       ttl,
+		responses: make(map[string]*idempotentResponse),
+		lastSweep: time.Now(),
+	}
+}
+
+// begin returns the stored response for key, or reserves key for a new
+// request when there is none.
+func (s *idempotencyStore) begin(key string, fingerprint [sha256.Size]byte, now time.Time) (*idempotentResponse, bool) {
+	s.Lock()
+	defer s.Unlock()
+
+	if now.Sub(s.lastSweep) > s.ttl {
+		for k, response := range s.responses {
+			if response.done && now.After(response.expires) {
+				delete(s.responses, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	if response, ok := s.responses[key]; ok && (!response.done || now.Before(response.expires)) {
+		return response, true
+	}
+	s.responses[key] = &idempotentResponse{fingerprint: fingerprint}
+	return nil, false
+}
+
+func (s *idempotencyStore) finish(key string, response *idempotentResponse) {
+	s.Lock()
+	defer s.Unlock()
+	s.responses[key] = response
+}
+
+// release forgets a reserved key so the request can be retried.
+func (s *idempotencyStore) release(key string) {
+	s.Lock()
+	defer s.Unlock()
+	delete(s.responses, key)
+}
+
+type recordingWriter struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *recordingWriter) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *recordingWriter) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// middleware replays the stored response when a request repeats an
+// Idempotency-Key. Keys are scoped to the client, as clientKey identifies it,
+// and to the request URI, and reusing one with a different body is rejected
+// with 422. Only 2xx responses are stored, so a failed request may be retried
+// with the same key.
+func (s *idempotencyStore) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		header := c.GetHeader("Idempotency-Key")
+		if header == "" {
+			c.Next()
+			return
+		}
+
+		body, err := ioutil.ReadAll(c.Request.Body)
+		if err != nil {
+			abortWithError(c, http.StatusBadRequest, codeInvalidRequest, "Error reading request body")
+			return
+		}
+		c.Request.Body = ioutil.NopCloser(bytes.NewReader(body))
+
+		key := clientKey(c) + " " + c.Request.Method + " " + c.Request.URL.RequestURI() + " " + header
+		fingerprint := sha256.Sum256(body)
+		stored, ok := s.begin(key, fingerprint, time.Now())
+		switch {
+		case !ok:
+		case stored.fingerprint != fingerprint:
+			abortWithError(c, http.StatusUnprocessableEntity, codeIdempotencyMismatch, "Idempotency-Key was already used with a different request body")
+			return
+		case !stored.done:
+			abortWithError(c, http.StatusConflict, codeIdempotencyPending, "A request with this Idempotency-Key is still in progress")
+			return
+		default:
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(stored.status, stored.contentType, stored.body)
+			c.Abort()
+			return
+		}
+
+		writer := &recordingWriter{ResponseWriter: c.Writer}
+		c.Writer = writer
+		// Unless a 2xx response is stored, the key is released even when a
+		// handler panics, so it is never left reserved.
+		finished := false
+		defer func() {
+			if !finished {
+				s.release(key)
+			}
+		}()
+		c.Next()
+
+		if writer.Status() < 200 || writer.Status() >= 300 {
+			return
+		}
+		finished = true
+		s.finish(key, &idempotentResponse{
+			done:        true,
+			fingerprint: fingerprint,
+			status:      writer.Status(),
+			contentType: writer.Header().Get("Content-Type"),
+			body:        writer.body.Bytes(),
+			expires:     time.Now().Add(s.ttl),
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestIdempotentPostTranslatesOnce(t *testing.T) {
+	setupTest(t)
+	fake := useFakeProvider(nil)
+	r := newRouter()
+	body := eventBody("Concert", "fr")
+
+	first := serveRequest(r, "POST", "/event", body, "Idempotency-Key", "abc")
+	second := serveRequest(r, "POST", "/event", body, "Idempotency-Key", "abc")
+	if first.Code != http.StatusCreated || second.Code != http.StatusCreated {
+		t.Fatalf("statuses %d and %d, want 201 twice", first.Code, second.Code)
+	}
+	if second.Header().Get("Idempotent-Replayed") != "true" || second.Body.String() != first.Body.String() {
+		t.Errorf("second response %s was not the replayed first %s", second.Body, first.Body)
+	}
+	if n := fake.totalCalls(); n != 1 {
+		t.Errorf("made %d translation calls, want 1", n)
+	}
+	if n := len(events.list()); n != 1 {
+		t.Errorf("stored %d events, want 1", n)
+	}
+}
+
+func TestIdempotencyKeyReusedWithOtherBody(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	serveRequest(r, "POST", "/event", eventBody("Concert", "fr"), "Idempotency-Key", "abc")
+	w := serveRequest(r, "POST", "/event", eventBody("Opera", "fr"), "Idempotency-Key", "abc")
+	if w.Code != http.StatusUnprocessableEntity {
+		t.Fatalf("status %d, want 422", w.Code)
+	}
+}
+
+func TestIdempotencyKeyReleasedAfterPanic(t *testing.T) {
+	setupTest(t)
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ interface{}) {
+		abortWithError(c, http.StatusInternalServerError, codeInternal, "Internal server error")
+	}))
+	calls := 0
+	r.POST("/event", newIdempotencyStore(time.Hour).middleware(), func(c *gin.Context) {
+		calls++
+		if calls == 1 {
+			panic("boom")
+		}
+		c.Status(http.StatusCreated)
+	})
+
+	if w := serveRequest(r, "POST", "/event", "{}", "Idempotency-Key", "abc"); w.Code != http.StatusInternalServerError {
+		t.Fatalf("first request: status %d, want 500", w.Code)
+	}
+	if w := serveRequest(r, "POST", "/event", "{}", "Idempotency-Key", "abc"); w.Code != http.StatusCreated {
+		t.Fatalf("retry after the panic: status %d, want 201 rather than a stuck key", w.Code)
+	}
+}
+
+func TestIdempotencyKeyReleasedAfterFailure(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	if w := serveRequest(r, "POST", "/event", `{"name":"Concert"}`, "Idempotency-Key", "abc"); w.Code != http.StatusBadRequest {
+		t.Fatalf("first request: status %d, want 400", w.Code)
+	}
+	if w := serveRequest(r, "POST", "/event", `{"name":"Concert"}`, "Idempotency-Key", "abc"); w.Code != http.StatusBadRequest || w.Header().Get("Idempotent-Replayed") != "" {
+		t.Fatalf("retry: status %d, want the request handled again", w.Code)
+	}
+}
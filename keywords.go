@@ -0,0 +1,420 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"hash/fnv"
+	"math/rand"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// minPlaceholderWidth is the smallest number of digits used in a keyword
+// placeholder. Every placeholder generated for one text shares the same width,
+// so no placeholder can be a prefix or substring of another.
+const minPlaceholderWidth = 3
+
+const (
+	// minNonceLength is the number of digits of the nonce that starts every
+	// placeholder of a text. It doubles whenever nonceAttempts nonces of the
+	// current length all occur in the text.
+	minNonceLength = 4
+	nonceAttempts  = 3
+)
+
+// defaultPlaceholderFormat spells keyword placeholders; "%s" stands for the
+// digits identifying each one.
+const defaultPlaceholderFormat = "KW%sPLH"
+
+// placeholderBefore and placeholderAfter surround the digits of every
+// placeholder, and placeholderPattern matches any placeholder.
+// spacedPlaceholderPattern also matches placeholders the provider altered
+// with inserted whitespace or changed case. They are set by
+// setPlaceholderFormat.
+var (
+	placeholderBefore        = "KW"
+	placeholderAfter         = "PLH"
+	placeholderPattern       = regexp.MustCompile(`KW\d+PLH`)
+	spacedPlaceholderPattern = spacedPattern("KW", "PLH")
+)
+
+// setPlaceholderFormat makes placeholders follow format, which must contain
+// "%s" exactly once with text around it that does not touch the digits.
+func setPlaceholderFormat(format string) error {
+	before, after, ok := strings.Cut(format, "%s")
+	if !ok || strings.Contains(before+after, "%") || before == "" || after == "" {
+		return fmt.Errorf("placeholder format must contain %%s once between other text, got %q", format)
+	}
+	if isDigit(before[len(before)-1]) || isDigit(after[0]) || strings.Contains(format, segmentSeparator) {
+		return fmt.Errorf("placeholder format must not have digits next to %%s, got %q", format)
+	}
+	placeholderBefore, placeholderAfter = before, after
+	placeholderPattern = regexp.MustCompile(regexp.QuoteMeta(before) + `\d+` + regexp.QuoteMeta(after))
+	spacedPlaceholderPattern = spacedPattern(before, after)
+	return nil
+}
+
+// spacedPattern matches a placeholder surrounded by before and after with
+// any whitespace between its characters, ignoring case, e.g. "kw 12 PLH".
+func spacedPattern(before, after string) *regexp.Regexp {
+	spaced := func(s string) string {
+		chars := make([]string, 0, len(s))
+		for _, r := range s {
+			chars = append(chars, regexp.QuoteMeta(string(r)))
+		}
+		return strings.Join(chars, `\s*`)
+	}
+	return regexp.MustCompile(`(?i)` + spaced(before) + `\s*\d(?:\s*\d)*\s*` + spaced(after))
+}
+
+// placeholders generates the placeholders of one text. They all start with
+// a nonce that does not occur in the text, so text that happens to look like
+// a placeholder is never mistaken for one.
+type placeholders struct {
+	prefix string
+	width  int
+}
+
+// newPlaceholders picks placeholders for count keywords in text. The nonce is
+// derived from the text rather than drawn at random so the same text always
+// yields the same prepared text and its translation can be cached.
+func newPlaceholders(text string, count int) placeholders {
+	for length := minNonceLength; ; length *= 2 {
+		for attempt := 0; attempt < nonceAttempts; attempt++ {
+			prefix := placeholderBefore + nonce(text, length, attempt)
+			if !strings.Contains(text, prefix) {
+				return placeholders{prefix: prefix, width: placeholderWidth(count)}
+			}
+		}
+	}
+}
+
+func (p placeholders) format(index int) string {
+	return fmt.Sprintf("%s%0*d%s", p.prefix, p.width, index, placeholderAfter)
+}
+
+func nonce(text string, length, attempt int) string {
+	h := fnv.New64a()
+	fmt.Fprintf(h, "%d:%d:%s", length, attempt, text)
+	r := rand.New(rand.NewSource(int64(h.Sum64())))
+	digits := make([]byte, length)
+	for i := range digits {
+		digits[i] = byte('0' + r.Intn(10))
+	}
+	return string(digits)
+}
+
+func isDigit(b byte) bool {
+	return '0' <= b && b <= '9'
+}
+
+type keywordOptions struct {
+	caseInsensitive bool
+	wholeWord       bool
+	// literals also protects the tokens matched by literalPattern.
+	literals bool
+}
+
+func keywordOptionsFor(event EventInfo) keywordOptions {
+	return keywordOptions{
+		caseInsensitive: event.CaseInsensitiveKeywords,
+		wholeWord:       event.WholeWordKeywords,
+		literals:        event.PreserveLiterals,
+	}
+}
+
+func replaceKeywordsWithPlaceholders(text string, keywords []string, opts keywordOptions) (string, map[string]string) {
+	keywords = longestFirst(keywords)
+	if len(keywords) == 0 && !opts.literals {
+		return text, make(map[string]string)
+	}
+
+	if opts.caseInsensitive || opts.wholeWord || opts.literals {
+		return replaceMatchedKeywords(text, keywordPattern(keywords, opts))
+	}
+
+	placeholderMap := make(map[string]string, len(keywords))
+	ph := newPlaceholders(text, len(keywords))
+	pairs := make([]string, 0, len(keywords)*2)
+	for i, keyword := range keywords {
+		placeholder := ph.format(i)
+		pairs = append(pairs, keyword, placeholder)
+		placeholderMap[placeholder] = keyword
+	}
+
+	// A single-pass replacer keeps later keywords from matching inside
+	// placeholders that were already substituted.
+	return strings.NewReplacer(pairs...).Replace(text), placeholderMap
+}
+
+// replaceMatchedKeywords gives every distinct matched occurrence its own
+// placeholder so restoration brings back the casing used in the source text.
+func replaceMatchedKeywords(text string, re *regexp.Regexp) (string, map[string]string) {
+	var occurrences []string
+	seen := make(map[string]bool)
+	for _, match := range re.FindAllString(text, -1) {
+		if !seen[match] {
+			seen[match] = true
+			occurrences = append(occurrences, match)
+		}
+	}
+
+	placeholderMap := make(map[string]string, len(occurrences))
+	placeholders := make(map[string]string, len(occurrences))
+	ph := newPlaceholders(text, len(occurrences))
+	for i, occurrence := range occurrences {
+		placeholder := ph.format(i)
+		placeholders[occurrence] = placeholder
+		placeholderMap[placeholder] = occurrence
+	}
+
+	text = re.ReplaceAllStringFunc(text, func(match string) string {
+		return placeholders[match]
+	})
+	return text, placeholderMap
+}
+
+// keywordPattern matches any of keywords, then with opts.literals any
+// literal. Keywords come first so a keyword starting with a number, such as
+// "2024 Gala", is still protected whole.
+func keywordPattern(keywords []string, opts keywordOptions) *regexp.Regexp {
+	alternatives := make([]string, 0, len(keywords)+1)
+	for _, keyword := range keywords {
+		quoted := regexp.QuoteMeta(keyword)
+		if opts.wholeWord {
+			quoted = wordBoundaries(keyword, quoted)
+		}
+		if opts.caseInsensitive {
+			quoted = "(?i:" + quoted + ")"
+		}
+		alternatives = append(alternatives, quoted)
+	}
+	if opts.literals {
+		alternatives = append(alternatives, literalPattern.String())
+	}
+	return regexp.MustCompile("(?:" + strings.Join(alternatives, "|") + ")")
+}
+
+// wordBoundaries anchors a quoted keyword on word boundaries. RE2's \b only
+// understands ASCII word characters, so an edge that is not one is left
+// unanchored rather than making the keyword impossible to match.
+func wordBoundaries(keyword, quoted string) string {
+	if isWordByte(keyword[0]) {
+		quoted = `\b` + quoted
+	}
+	if isWordByte(keyword[len(keyword)-1]) {
+		quoted += `\b`
+	}
+	return quoted
+}
+
+func isWordByte(b byte) bool {
+	return b == '_' || isDigit(b) || 'a' <= b && b <= 'z' || 'A' <= b && b <= 'Z'
+}
+
+// longestFirst drops empty and repeated keywords and orders the rest by
+// descending length. Both the replacer and regexp alternation prefer earlier
+// entries, so a phrase such as "art festival" is protected whole rather than
+// losing its "art" to a shorter keyword.
+func longestFirst(keywords []string) []string {
+	ordered := make([]string, 0, len(keywords))
+	seen := make(map[string]bool, len(keywords))
+	for _, keyword := range keywords {
+		if keyword != "" && !seen[keyword] {
+			seen[keyword] = true
+			ordered = append(ordered, keyword)
+		}
+	}
+	sort.SliceStable(ordered, func(i, j int) bool {
+		return len(ordered[i]) > len(ordered[j])
+	})
+	return ordered
+}
+
+func placeholderWidth(count int) int {
+	width := len(strconv.Itoa(count - 1))
+	if width < minPlaceholderWidth {
+		width = minPlaceholderWidth
+	}
+	return width
+}
+
+// segmentSeparator joins texts so their keywords share one placeholder map.
+// Keywords containing it are ignored so the texts split apart cleanly again.
+const segmentSeparator = "\x00"
+
+func replaceKeywordsInSegments(texts []string, keywords []string, opts keywordOptions) ([]string, map[string]string) {
+	usable := make([]string, 0, len(keywords))
+	for _, keyword := range keywords {
+		if !strings.Contains(keyword, segmentSeparator) {
+			usable = append(usable, keyword)
+		}
+	}
+
+	joined, placeholderMap := replaceKeywordsWithPlaceholders(strings.Join(texts, segmentSeparator), usable, opts)
+	return strings.Split(joined, segmentSeparator), placeholderMap
+}
+
+// replacePlaceholdersWithKeywords restores every placeholder of text, the
+// translation of source, to its keyword. Providers sometimes space out
+// placeholders or change their case in translation, so placeholders are
+// matched ignoring case and whitespace inside them. Placeholders that still
+// cannot be restored are logged. The spaces around each restored keyword are
+// then made to follow source, see respace.
+func replacePlaceholdersWithKeywords(text, source string, placeholderMap map[string]string) string {
+	if len(placeholderMap) == 0 {
+		return text
+	}
+	keywords := make(map[string]string, len(placeholderMap))
+	var sample string
+	for placeholder, keyword := range placeholderMap {
+		sample = strings.ToLower(placeholder)
+		keywords[sample] = keyword
+	}
+	// Every placeholder of the text has the same length and nonce, which
+	// tells them apart from text that merely looks like one.
+	prefix := sample[:len(placeholderBefore)+minNonceLength]
+	spacing := sourceSpacing(source)
+	seen := make(map[string]int)
+
+	var restored strings.Builder
+	var unrestored []string
+	cursor := 0
+	for _, loc := range spacedPlaceholderPattern.FindAllStringIndex(text, -1) {
+		if loc[0] < cursor {
+			continue
+		}
+		match := text[loc[0]:loc[1]]
+		placeholder := strings.ToLower(strings.Join(strings.Fields(match), ""))
+		keyword, ok := keywords[placeholder]
+		if !ok {
+			if len(placeholder) == len(sample) && strings.HasPrefix(placeholder, prefix) {
+				unrestored = append(unrestored, match)
+			}
+			continue
+		}
+		restored.WriteString(text[cursor:loc[0]])
+		cursor = loc[1]
+		around := occurrenceSpacing(spacing[placeholder], seen[placeholder])
+		seen[placeholder]++
+		respaceBefore(&restored, around)
+		restored.WriteString(keyword)
+		cursor += respaceAfter(&restored, text[cursor:], around)
+	}
+	restored.WriteString(text[cursor:])
+	if len(unrestored) > 0 {
+		logger.Warn("placeholders not restored", "placeholders", unrestored)
+	}
+	return restored.String()
+}
+
+// placeholderSpacing describes the source text around a placeholder: whether
+// whitespace separated it from its neighbours and, where none did, which
+// character it touched.
+type placeholderSpacing struct {
+	spaceBefore, spaceAfter bool
+	prev, next              rune
+}
+
+// sourceSpacing records the spacing around every occurrence of each
+// placeholder of source, in order, keyed by the lowercased placeholder.
+func sourceSpacing(source string) map[string][]placeholderSpacing {
+	spacing := make(map[string][]placeholderSpacing)
+	for _, loc := range placeholderPattern.FindAllStringIndex(source, -1) {
+		placeholder := strings.ToLower(source[loc[0]:loc[1]])
+		var around placeholderSpacing
+		if prev, _ := utf8.DecodeLastRuneInString(source[:loc[0]]); loc[0] > 0 {
+			around.spaceBefore = unicode.IsSpace(prev)
+			around.prev = prev
+		}
+		if next, _ := utf8.DecodeRuneInString(source[loc[1]:]); loc[1] < len(source) {
+			around.spaceAfter = unicode.IsSpace(next)
+			around.next = next
+		}
+		spacing[placeholder] = append(spacing[placeholder], around)
+	}
+	return spacing
+}
+
+// occurrenceSpacing pairs the nth occurrence of a placeholder in the
+// translation with the nth in the source. Translations rarely reorder
+// repeated keywords, and extra occurrences take the spacing of the last.
+func occurrenceSpacing(spacing []placeholderSpacing, n int) placeholderSpacing {
+	if len(spacing) == 0 {
+		return placeholderSpacing{}
+	}
+	if n >= len(spacing) {
+		n = len(spacing) - 1
+	}
+	return spacing[n]
+}
+
+// respaceBefore adjusts the plain spaces at the end of restored, the
+// translation up to a keyword, to the source: a keyword that followed a space
+// gets exactly one between it and a word, and one the provider moved away
+// from the character it touched in the source is joined to it again. Other
+// whitespace, such as line breaks, is left as it is.
+func respaceBefore(restored *strings.Builder, around placeholderSpacing) {
+	text := restored.String()
+	trimmed := strings.TrimRight(text, " ")
+	spaces := len(text) - len(trimmed)
+	last, _ := utf8.DecodeLastRuneInString(trimmed)
+	switch {
+	case trimmed == "":
+		return
+	case around.spaceBefore && spaces == 0 && isSpacedWordRune(last):
+		restored.WriteByte(' ')
+	case around.spaceBefore && spaces > 1:
+		restored.Reset()
+		restored.WriteString(trimmed + " ")
+	case !around.spaceBefore && spaces == 1 && last == around.prev:
+		restored.Reset()
+		restored.WriteString(trimmed)
+	}
+}
+
+// respaceAfter does what respaceBefore does on the other side of a keyword,
+// with rest the translation following it. It returns how many bytes of rest
+// were dropped.
+func respaceAfter(restored *strings.Builder, rest string, around placeholderSpacing) int {
+	trimmed := strings.TrimLeft(rest, " ")
+	spaces := len(rest) - len(trimmed)
+	next, _ := utf8.DecodeRuneInString(trimmed)
+	switch {
+	case trimmed == "":
+		return 0
+	case around.spaceAfter && spaces == 0 && isSpacedWordRune(next):
+		restored.WriteByte(' ')
+	case around.spaceAfter && spaces > 1:
+		return spaces - 1
+	case !around.spaceAfter && spaces == 1 && next == around.next:
+		return 1
+	}
+	return 0
+}
+
+// isSpacedWordRune reports whether r is a letter or digit of a script that
+// separates words with spaces. Scripts such as Han, Kana or Thai do not, so
+// no space is added next to them.
+func isSpacedWordRune(r rune) bool {
+	if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+		return false
+	}
+	return !unicode.In(r, unicode.Han, unicode.Hiragana, unicode.Katakana, unicode.Thai, unicode.Lao, unicode.Khmer, unicode.Myanmar)
+}
+
+// translateKeywords translates each keyword of event on its own into lang.
+// It reports false when the translation fails, in which case the event
+// simply has no translated keywords for lang.
+func translateKeywords(ctx context.Context, event EventInfo, from, lang string) ([]string, bool) {
+	translated, _, err := translateTexts(ctx, sanitizeTexts(event.Keywords), from, lang, translateOptionsFor(event))
+	if err != nil {
+		logger.Warn("keyword translation failed", "language", lang, "error", err)
+		return nil, false
+	}
+	return translated, true
+}
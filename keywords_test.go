@@ -0,0 +1,286 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestKeywordRoundTripManyKeywords(t *testing.T) {
+	setupTest(t)
+	keywords := []string{
+		"Fest", "Festival", "Festivals", "Festival Hall", "Jazz",
+		"Jazz Night", "Jazzy", "Rock", "Rocket", "Rockets",
+		"Band", "Bandstand", "Gala", "Galaxy", "Galaxy Hall",
+	}
+	text := strings.Join(keywords, ", ")
+
+	prepared, placeholderMap := replaceKeywordsWithPlaceholders(text, keywords, keywordOptions{})
+	if len(placeholderMap) != len(keywords) {
+		t.Fatalf("got %d placeholders, want %d", len(placeholderMap), len(keywords))
+	}
+	for _, keyword := range keywords {
+		if strings.Contains(prepared, keyword) {
+			t.Errorf("prepared text %q still contains %q", prepared, keyword)
+		}
+	}
+
+	translated := "[fr] " + prepared
+	if got, want := replacePlaceholdersWithKeywords(translated, prepared, placeholderMap), "[fr] "+text; got != want {
+		t.Fatalf("restored %q, want %q", got, want)
+	}
+}
+
+func TestKeywordsCaseInsensitive(t *testing.T) {
+	setupTest(t)
+	text := "JAZZ night: jazz for everyone, Jazz all day"
+
+	prepared, placeholderMap := replaceKeywordsWithPlaceholders(text, []string{"Jazz"}, keywordOptions{caseInsensitive: true})
+	if strings.Contains(strings.ToLower(prepared), "jazz") {
+		t.Fatalf("prepared text %q still contains a keyword", prepared)
+	}
+	if len(placeholderMap) != 3 {
+		t.Fatalf("got %d placeholders, want one per casing: %v", len(placeholderMap), placeholderMap)
+	}
+	if got := replacePlaceholdersWithKeywords(prepared, prepared, placeholderMap); got != text {
+		t.Fatalf("restored %q, want the original casing %q", got, text)
+	}
+}
+
+func TestKeywordsExactMatchByDefault(t *testing.T) {
+	setupTest(t)
+	prepared, _ := replaceKeywordsWithPlaceholders("JAZZ and Jazz", []string{"Jazz"}, keywordOptions{})
+	if !strings.HasPrefix(prepared, "JAZZ and ") || strings.Contains(prepared, "Jazz") {
+		t.Fatalf("prepared %q, want only the exact-case keyword replaced", prepared)
+	}
+}
+
+func TestKeywordsWholeWord(t *testing.T) {
+	setupTest(t)
+	text := "Start the art walk"
+
+	prepared, placeholderMap := replaceKeywordsWithPlaceholders(text, []string{"art"}, keywordOptions{wholeWord: true})
+	if !strings.HasPrefix(prepared, "Start the ") || strings.Contains(prepared, " art ") {
+		t.Fatalf("prepared %q, want \"Start\" untouched and the standalone art replaced", prepared)
+	}
+	if len(placeholderMap) != 1 {
+		t.Fatalf("got %d placeholders, want 1", len(placeholderMap))
+	}
+	if got := replacePlaceholdersWithKeywords(prepared, prepared, placeholderMap); got != text {
+		t.Fatalf("restored %q, want %q", got, text)
+	}
+}
+
+func TestTextLookingLikePlaceholders(t *testing.T) {
+	setupTest(t)
+	text := "Jazz at KW0PLH, see KW1PLH and kw00plh"
+
+	prepared, placeholderMap := replaceKeywordsWithPlaceholders(text, []string{"Jazz"}, keywordOptions{})
+	if len(placeholderMap) != 1 {
+		t.Fatalf("placeholders %v, want one", placeholderMap)
+	}
+	for placeholder := range placeholderMap {
+		if strings.Contains(text, placeholder) {
+			t.Fatalf("placeholder %q occurs in the source text", placeholder)
+		}
+	}
+	if got := replacePlaceholdersWithKeywords(prepared, prepared, placeholderMap); got != text {
+		t.Fatalf("restored %q, want %q", got, text)
+	}
+}
+
+func TestEventWithPlaceholderLookalikeText(t *testing.T) {
+	setupTest(t)
+	event := EventInfo{
+		Name:      "Jazz",
+		Location:  "Room KW0PLH",
+		Details:   "Ask for KW1PLH",
+		Keywords:  []string{"Jazz"},
+		Languages: []string{"fr"},
+	}
+	created := createEvent(t, newRouter(), mustJSON(event))
+	if got, want := created.Translations["fr"], "[fr] Jazz Location: Room KW0PLH Details: Ask for KW1PLH"; got != want {
+		t.Fatalf("translation %q, want %q", got, want)
+	}
+}
+
+func TestCustomPlaceholderFormat(t *testing.T) {
+	setupTest(t, "KEYWORD_PLACEHOLDER_FORMAT", "__X%sX__")
+	prepared, placeholderMap := replaceKeywordsWithPlaceholders("Jazz night", []string{"Jazz"}, keywordOptions{})
+	if !strings.HasPrefix(prepared, "__X") || !strings.HasSuffix(prepared, "X__ night") {
+		t.Fatalf("prepared %q, want the custom format", prepared)
+	}
+	if got := replacePlaceholdersWithKeywords(prepared, prepared, placeholderMap); got != "Jazz night" {
+		t.Fatalf("restored %q, want %q", got, "Jazz night")
+	}
+}
+
+func TestInvalidPlaceholderFormat(t *testing.T) {
+	for _, format := range []string{"KWPLH", "%sPLH", "KW%s", "KW1%sPLH", "KW%s%sPLH"} {
+		if err := setPlaceholderFormat(format); err == nil {
+			t.Errorf("setPlaceholderFormat(%q) succeeded, want an error", format)
+		}
+	}
+	if err := setPlaceholderFormat(defaultPlaceholderFormat); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestOverlappingKeywordsLongestFirst(t *testing.T) {
+	setupTest(t)
+	text := "The art festival celebrates art"
+
+	for _, keywords := range [][]string{{"art", "art festival"}, {"art festival", "art"}} {
+		prepared, placeholderMap := replaceKeywordsWithPlaceholders(text, keywords, keywordOptions{})
+		if len(placeholderMap) != 2 {
+			t.Fatalf("keywords %q: placeholders %v, want two", keywords, placeholderMap)
+		}
+		var phrase string
+		for placeholder, keyword := range placeholderMap {
+			if keyword == "art festival" {
+				phrase = placeholder
+			}
+		}
+		if !strings.Contains(prepared, "The "+phrase+" celebrates ") {
+			t.Errorf("keywords %q: prepared %q, want art festival protected as one phrase", keywords, prepared)
+		}
+		if got := replacePlaceholdersWithKeywords(prepared, prepared, placeholderMap); got != text {
+			t.Errorf("keywords %q: restored %q, want %q", keywords, got, text)
+		}
+	}
+}
+
+func TestDuplicateKeywordsDeduplicated(t *testing.T) {
+	setupTest(t)
+	_, placeholderMap := replaceKeywordsWithPlaceholders("Jazz and Rock", []string{"Jazz", "Rock", "Jazz"}, keywordOptions{})
+	if len(placeholderMap) != 2 {
+		t.Fatalf("placeholders %v, want one per distinct keyword", placeholderMap)
+	}
+}
+
+// spaceOut spells each placeholder of text the way some providers return
+// them, e.g. "KW 0123 plh".
+func spaceOut(text string) string {
+	return placeholderPattern.ReplaceAllStringFunc(text, func(p string) string {
+		digits := p[len(placeholderBefore) : len(p)-len(placeholderAfter)]
+		return placeholderBefore + " " + digits + " " + strings.ToLower(placeholderAfter)
+	})
+}
+
+func TestSpacedPlaceholdersRestored(t *testing.T) {
+	setupTest(t)
+	useFakeProvider(func(text, from, to string) (string, error) {
+		return spaceOut(text), nil
+	})
+	event := EventInfo{Name: "Jazz", Location: "Hall", Details: "Jazz night", Keywords: []string{"Jazz"}, Languages: []string{"fr"}}
+
+	created := createEvent(t, newRouter(), mustJSON(event))
+	if got, want := created.Translations["fr"], "Jazz Location: Hall Details: Jazz night"; got != want {
+		t.Fatalf("translation %q, want %q", got, want)
+	}
+}
+
+func TestPlaceholderWithSpacesBetweenDigits(t *testing.T) {
+	setupTest(t)
+	prepared, placeholderMap := replaceKeywordsWithPlaceholders("See Jazz now", []string{"Jazz"}, keywordOptions{})
+	var placeholder string
+	for p := range placeholderMap {
+		placeholder = p
+	}
+	spaced := strings.Join(strings.Split(strings.ToLower(placeholder), ""), " ")
+
+	translated := strings.Replace(prepared, placeholder, spaced, 1)
+	if got := replacePlaceholdersWithKeywords(translated, prepared, placeholderMap); got != "See Jazz now" {
+		t.Fatalf("restored %q from %q, want %q", got, translated, "See Jazz now")
+	}
+}
+
+func TestUnrestoredPlaceholdersLogged(t *testing.T) {
+	setupTest(t)
+	logs := captureLogs(t)
+	prepared, placeholderMap := replaceKeywordsWithPlaceholders("See Jazz now", []string{"Jazz"}, keywordOptions{})
+	var placeholder string
+	for p := range placeholderMap {
+		placeholder = p
+	}
+	// The provider changed the last digit of the placeholder.
+	digits := len(placeholder) - len(placeholderAfter) - 1
+	corrupted := placeholder[:digits] + string('0'+(placeholder[digits]-'0'+1)%10) + placeholder[digits+1:]
+
+	replacePlaceholdersWithKeywords(strings.Replace(prepared, placeholder, corrupted, 1), prepared, placeholderMap)
+	if !strings.Contains(logs.String(), "placeholders not restored") || !strings.Contains(logs.String(), corrupted) {
+		t.Fatalf("logs %s, want a warning naming %s", logs, corrupted)
+	}
+}
+
+// keywordEvent creates an event with the keywords Jazz and Blues, which a
+// fake provider translates like any other text.
+func keywordEvent(t *testing.T, translateKeywords bool) (EventInfo, *fakeProvider) {
+	t.Helper()
+	fake := useFakeProvider(nil)
+	event := EventInfo{
+		Name:              "Jazz",
+		Location:          "Hall",
+		Details:           "Jazz and Blues",
+		Keywords:          []string{"Jazz", "Blues"},
+		TranslateKeywords: translateKeywords,
+		Languages:         []string{"fr", "de"},
+	}
+	return createEvent(t, newRouter(), mustJSON(event)), fake
+}
+
+func TestTranslatedKeywords(t *testing.T) {
+	setupTest(t, "DETAILS_TEMPLATE", "{{.Details}}")
+	created, _ := keywordEvent(t, true)
+
+	for _, lang := range []string{"fr", "de"} {
+		if got, want := created.Translations[lang], lang+":Jazz and Blues"; got != want {
+			t.Errorf("%s translation %q, want the keywords protected: %q", lang, got, want)
+		}
+		keywords := created.TranslatedKeywords[lang]
+		if len(keywords) != 2 || keywords[0] != lang+":Jazz" || keywords[1] != lang+":Blues" {
+			t.Errorf("%s keywords %q, want each translated in order", lang, keywords)
+		}
+	}
+}
+
+func TestKeywordsNotTranslatedByDefault(t *testing.T) {
+	setupTest(t, "DETAILS_TEMPLATE", "{{.Details}}")
+	created, fake := keywordEvent(t, false)
+
+	if created.TranslatedKeywords != nil {
+		t.Errorf("translated keywords %v, want none", created.TranslatedKeywords)
+	}
+	if fake.callCount("fr") != 1 {
+		t.Errorf("fr calls %d, want only the details", fake.callCount("fr"))
+	}
+}
+
+func TestRestoredKeywordSpacingFollowsSource(t *testing.T) {
+	setupTest(t)
+	tests := []struct {
+		source, keyword string
+		// translation is the provider's output with %[1]s for the
+		// placeholder.
+		translation, want string
+	}{
+		{"See Jazz now", "Jazz", "Voir%[1]smaintenant", "Voir Jazz maintenant"},
+		{"See Jazz now", "Jazz", "Voir   %[1]s   maintenant", "Voir Jazz maintenant"},
+		{"Tickets (Jazz) here", "Jazz", "Billets ( %[1]s ) ici", "Billets (Jazz) ici"},
+		{"Jazz, tonight", "Jazz", "%[1]s , ce soir", "Jazz, ce soir"},
+		{"See Jazz now", "Jazz", "Voir\n%[1]s\nmaintenant", "Voir\nJazz\nmaintenant"},
+		{"See Jazz now", "Jazz", "今%[1]s見る", "今Jazz見る"},
+		{"Jazz and Jazz", "Jazz", "%[1]set%[1]s", "Jazz et Jazz"},
+	}
+	for _, tt := range tests {
+		prepared, placeholderMap := replaceKeywordsWithPlaceholders(tt.source, []string{tt.keyword}, keywordOptions{})
+		var placeholder string
+		for p := range placeholderMap {
+			placeholder = p
+		}
+		translated := fmt.Sprintf(tt.translation, placeholder)
+		if got := replacePlaceholdersWithKeywords(translated, prepared, placeholderMap); got != tt.want {
+			t.Errorf("restored %q from %q, want %q", got, translated, tt.want)
+		}
+	}
+}
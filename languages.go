@@ -0,0 +1,251 @@
+package main
+
+import (
+	"github.com/go-playground/validator/v10"
+	"golang.org/x/text/language"
+	"golang.org/x/text/language/display"
+	"sort"
+	"strings"
+)
+
+// iso6391Codes is the set of two-letter ISO 639-1 language codes.
+var iso6391Codes = map[string]bool{
+	"aa": true, "ab": true, "ae": true, "af": true, "ak": true, "am": true, "an": true, "ar": true,
+	"as": true, "av": true, "ay": true, "az": true, "ba": true, "be": true, "bg": true, "bh": true,
+	"bi": true, "bm": true, "bn": true, "bo": true, "br": true, "bs": true, "ca": true, "ce": true,
+	"ch": true, "co": true, "cr": true, "cs": true, "cu": true, "cv": true, "cy": true, "da": true,
+	"de": true, "dv": true, "dz": true, "ee": true, "el": true, "en": true, "eo": true, "es": true,
+	"et": true, "eu": true, "fa": true, "ff": true, "fi": true, "fj": true, "fo": true, "fr": true,
+	"fy": true, "ga": true, "gd": true, "gl": true, "gn": true, "gu": true, "gv": true, "ha": true,
+	"he": true, "hi": true, "ho": true, "hr": true, "ht": true, "hu": true, "hy": true, "hz": true,
+	"ia": true, "id": true, "ie": true, "ig": true, "ii": true, "ik": true, "io": true, "is": true,
+	"it": true, "iu": true, "ja": true, "jv": true, "ka": true, "kg": true, "ki": true, "kj": true,
+	"kk": true, "kl": true, "km": true, "kn": true, "ko": true, "kr": true, "ks": true, "ku": true,
+	"kv": true, "kw": true, "ky": true, "la": true, "lb": true, "lg": true, "li": true, "ln": true,
+	"lo": true, "lt": true, "lu": true, "lv": true, "mg": true, "mh": true, "mi": true, "mk": true,
+	"ml": true, "mn": true, "mr": true, "ms": true, "mt": true, "my": true, "na": true, "nb": true,
+	"nd": true, "ne": true, "ng": true, "nl": true, "nn": true, "no": true, "nr": true, "nv": true,
+	"ny": true, "oc": true, "oj": true, "om": true, "or": true, "os": true, "pa": true, "pi": true,
+	"pl": true, "ps": true, "pt": true, "qu": true, "rm": true, "rn": true, "ro": true, "ru": true,
+	"rw": true, "sa": true, "sc": true, "sd": true, "se": true, "sg": true, "si": true, "sk": true,
+	"sl": true, "sm": true, "sn": true, "so": true, "sq": true, "sr": true, "ss": true, "st": true,
+	"su": true, "sv": true, "sw": true, "ta": true, "te": true, "tg": true, "th": true, "ti": true,
+	"tk": true, "tl": true, "tn": true, "to": true, "tr": true, "ts": true, "tt": true, "tw": true,
+	"ty": true, "ug": true, "uk": true, "ur": true, "uz": true, "ve": true, "vi": true, "vo": true,
+	"wa": true, "wo": true, "xh": true, "yi": true, "yo": true, "za": true, "zh": true, "zu": true,
+}
+
+func isISO6391(fl validator.FieldLevel) bool {
+	return iso6391Codes[fl.Field().String()]
+}
+
+// azureLanguages are the target languages supported by Azure Translator.
+var azureLanguages = languageSet(
+	"af", "am", "ar", "as", "az", "ba", "bg", "bho", "bn", "bo", "brx", "bs",
+	"ca", "cs", "cy", "da", "de", "doi", "dsb", "dv", "el", "en", "es", "et",
+	"eu", "fa", "fi", "fil", "fj", "fo", "fr", "fr-CA", "ga", "gl", "gom", "gu",
+	"ha", "he", "hi", "hne", "hr", "hsb", "ht", "hu", "hy", "id", "ig", "ikt",
+	"is", "it", "iu", "iu-Latn", "ja", "ka", "kk", "km", "kmr", "kn", "ko", "ks",
+	"ku", "ky", "lb", "ln", "lo", "lt", "lug", "lv", "lzh", "mai", "mg", "mi",
+	"mk", "ml", "mn-Cyrl", "mn-Mong", "mni", "mr", "ms", "mt", "mww", "my", "nb",
+	"ne", "nl", "nso", "nya", "or", "otq", "pa", "pl", "prs", "ps", "pt", "pt-PT",
+	"ro", "ru", "run", "rw", "sd", "si", "sk", "sl", "sm", "sn", "so", "sq",
+	"sr-Cyrl", "sr-Latn", "st", "sv", "sw", "ta", "te", "th", "ti", "tk",
+	"tlh-Latn", "tlh-Piqd", "tn", "to", "tr", "tt", "ty", "ug", "uk", "ur", "uz",
+	"vi", "xh", "yo", "yua", "yue", "zh-Hans", "zh-Hant", "zu",
+)
+
+// googleLanguages are the target languages supported by Google Translate.
+var googleLanguages = languageSet(
+	"af", "ak", "am", "ar", "as", "ay", "az", "be", "bg", "bho", "bm", "bn", "bs",
+	"ca", "ceb", "ckb", "co", "cs", "cy", "da", "de", "doi", "dv", "ee", "el",
+	"en", "eo", "es", "et", "eu", "fa", "fi", "fr", "fy", "ga", "gd", "gl", "gn",
+	"gom", "gu", "ha", "haw", "he", "hi", "hmn", "hr", "ht", "hu", "hy", "id",
+	"ig", "ilo", "is", "it", "ja", "jv", "ka", "kk", "km", "kn", "ko", "kri",
+	"ku", "ky", "la", "lb", "lg", "ln", "lo", "lt", "lus", "lv", "mai", "mg",
+	"mi", "mk", "ml", "mn", "mni-Mtei", "mr", "ms", "mt", "my", "ne", "nl", "no",
+	"nso", "ny", "om", "or", "pa", "pl", "ps", "pt", "qu", "ro", "ru", "rw", "sa",
+	"sd", "si", "sk", "sl", "sm", "sn", "so", "sq", "sr", "st", "su", "sv", "sw",
+	"ta", "te", "tg", "th", "ti", "tk", "tl", "tr", "ts", "tt", "ug", "uk", "ur",
+	"uz", "vi", "xh", "yi", "yo", "zh", "zh-CN", "zh-TW", "zu",
+)
+
+// rtlLanguages are the languages written right to left, and rtlScripts the
+// scripts that are, so that e.g. "pa-Arab" is right to left although "pa" is
+// not.
+var (
+	rtlLanguages = languageSet(
+		"ar", "arc", "azb", "bal", "ckb", "dv", "fa", "glk", "he", "iw", "ji",
+		"ks", "lrc", "mzn", "prs", "ps", "sd", "sdh", "syr", "ug", "ur", "yi",
+	)
+	rtlScripts = languageSet("Adlm", "Arab", "Hebr", "Mand", "Nkoo", "Rohg", "Samr", "Syrc", "Thaa")
+)
+
+// languageDirection returns "rtl" for languages written right to left and
+// "ltr" for every other. An explicit script subtag decides over the
+// language, so "az-Arab" is right to left and "ku-Latn" left to right.
+func languageDirection(lang string) string {
+	subtags := strings.Split(strings.ToLower(lang), "-")
+	for _, subtag := range subtags[1:] {
+		if len(subtag) == 4 && isLetters(subtag) {
+			if rtlScripts[subtag] {
+				return "rtl"
+			}
+			return "ltr"
+		}
+	}
+	if rtlLanguages[subtags[0]] {
+		return "rtl"
+	}
+	return "ltr"
+}
+
+// supportedLanguages is the set of target languages accepted by the
+// supported_language validator. A nil set accepts any code.
+var supportedLanguages map[string]bool
+
+func languageSet(codes ...string) map[string]bool {
+	set := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		set[strings.ToLower(code)] = true
+	}
+	return set
+}
+
+// supportedLanguagesFor returns the language codes cfg's provider accepts.
+// SUPPORTED_LANGUAGES overrides the built-in list for any provider.
+func supportedLanguagesFor(cfg Config) map[string]bool {
+	if len(cfg.SupportedLanguages) > 0 {
+		return languageSet(cfg.SupportedLanguages...)
+	}
+	switch cfg.Provider {
+	case "azure":
+		return azureLanguages
+	case "google":
+		return googleLanguages
+	default:
+		return nil
+	}
+}
+
+// normalizeLanguages trims language codes and gives them canonical casing,
+// dropping empty and repeated ones while keeping the order in which they
+// first appear, so each language is translated once and stored under its own
+// key. Regional variants such as "en" and "en-US" stay distinct; codes that
+// only differ in casing are merged with a warning.
+func normalizeLanguages(languages []string) []string {
+	if languages == nil {
+		return nil
+	}
+	normalized := make([]string, 0, len(languages))
+	requested := make(map[string]string, len(languages))
+	for _, language := range languages {
+		language = strings.TrimSpace(language)
+		code := canonicalLanguage(language)
+		if code == "" {
+			continue
+		}
+		if first, seen := requested[code]; seen {
+			if first != language {
+				logger.Warn("languages merged into one code", "languages", []string{first, language}, "code", code)
+			}
+			continue
+		}
+		requested[code] = language
+		normalized = append(normalized, code)
+	}
+	return normalized
+}
+
+// canonicalLanguage cases a BCP 47 style code the way providers spell them:
+// a lowercase language, a title-case script and an uppercase region, e.g.
+// "zh-Hans" or "pt-BR".
+func canonicalLanguage(code string) string {
+	subtags := strings.Split(strings.ToLower(code), "-")
+	for i := 1; i < len(subtags); i++ {
+		switch subtag := subtags[i]; {
+		case len(subtag) == 4 && isLetters(subtag):
+			subtags[i] = strings.ToUpper(subtag[:1]) + subtag[1:]
+		case len(subtag) == 2 && isLetters(subtag):
+			subtags[i] = strings.ToUpper(subtag)
+		}
+	}
+	return strings.Join(subtags, "-")
+}
+
+func isLetters(s string) bool {
+	for i := 0; i < len(s); i++ {
+		if s[i] < 'a' || s[i] > 'z' {
+			return false
+		}
+	}
+	return true
+}
+
+// requestedLanguages normalizes the languages of a request. A request that
+// names no languages gets config.DefaultLanguages instead; any language given
+// in the request replaces the defaults entirely rather than adding to them.
+func requestedLanguages(languages []string) []string {
+	if normalized := normalizeLanguages(languages); len(normalized) > 0 {
+		return normalized
+	}
+	return normalizeLanguages(config.DefaultLanguages)
+}
+
+// isSupportedLanguage validates a field with languageSupported.
+func isSupportedLanguage(fl validator.FieldLevel) bool {
+	return languageSupported(fl.Field().String())
+}
+
+// languageSupported accepts a language that is supported itself or has a
+// supported language in its fallback chain.
+func languageSupported(code string) bool {
+	if supportedLanguages == nil {
+		return true
+	}
+	for _, lang := range fallbackChain(code) {
+		if supportedLanguages[strings.ToLower(lang)] {
+			return true
+		}
+	}
+	return false
+}
+
+// languageTranslation is one entry of an event's translations in the order
+// parameter translationOrder asks for.
+type languageTranslation struct {
+	Language string `json:"language"`
+	// Name is the language's English display name, or its code when it has
+	// none.
+	Name string `json:"name"`
+	Text string `json:"text"`
+}
+
+// languageDisplayName names code in English, e.g. "German" for "de" and
+// "Simplified Chinese" for "zh-Hans".
+func languageDisplayName(code string) string {
+	tag, err := language.Parse(code)
+	if err != nil {
+		return code
+	}
+	if name := display.English.Tags().Name(tag); name != "" {
+		return name
+	}
+	return code
+}
+
+// translationsByName lists translations sorted by language display name,
+// ties broken by code so the order never depends on map iteration.
+func translationsByName(translations map[string]string) []languageTranslation {
+	list := make([]languageTranslation, 0, len(translations))
+	for code, text := range translations {
+		list = append(list, languageTranslation{Language: code, Name: languageDisplayName(code), Text: text})
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if a, b := strings.ToLower(list[i].Name), strings.ToLower(list[j].Name); a != b {
+			return a < b
+		}
+		return list[i].Language < list[j].Language
+	})
+	return list
+}
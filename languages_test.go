@@ -0,0 +1,194 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestSupportedLanguageValidation(t *testing.T) {
+	tests := []struct {
+		provider string
+		language string
+		want     int
+	}{
+		{"azure", "fr", http.StatusCreated},
+		{"azure", "zh-Hans", http.StatusCreated},
+		{"azure", "xx-bogus", http.StatusBadRequest},
+		{"azure", "zh-TW", http.StatusBadRequest},
+		{"google", "zh-TW", http.StatusCreated},
+		{"google", "zh-Hans", http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.provider+"/"+tt.language, func(t *testing.T) {
+			setupTest(t)
+			supportedLanguages = supportedLanguagesFor(Config{Provider: tt.provider})
+
+			w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", tt.language))
+			if w.Code != tt.want {
+				t.Fatalf("status %d, want %d: %s", w.Code, tt.want, w.Body)
+			}
+		})
+	}
+}
+
+func TestSupportedLanguagesOverride(t *testing.T) {
+	set := supportedLanguagesFor(Config{Provider: "azure", SupportedLanguages: []string{"fr", "de"}})
+	if !set["fr"] || !set["de"] || set["es"] {
+		t.Fatalf("got %v, want only fr and de", set)
+	}
+}
+
+func TestDuplicateLanguagesTranslatedOnce(t *testing.T) {
+	setupTest(t)
+	fake := useFakeProvider(nil)
+
+	created := createEvent(t, newRouter(), eventBody("Concert", "fr", "FR", " fr ", "pt-br", "pt-BR", "de"))
+	if got := strings.Join(created.Languages, ","); got != "fr,pt-BR,de" {
+		t.Errorf("languages %s, want fr,pt-BR,de in first-seen order", got)
+	}
+	for _, lang := range []string{"fr", "pt-BR", "de"} {
+		if n := fake.callCount(lang); n != 1 {
+			t.Errorf("%s translated %d times, want once", lang, n)
+		}
+	}
+	if len(created.Translations) != 3 {
+		t.Errorf("translations %v, want 3", created.Translations)
+	}
+}
+
+func TestDefaultLanguages(t *testing.T) {
+	setupTest(t, "DEFAULT_LANGUAGES", "es,it")
+	r := newRouter()
+
+	defaulted := createEvent(t, r, `{"name":"Concert","location":"Hall","details":"Music"}`)
+	if got := strings.Join(defaulted.Languages, ","); got != "es,it" || len(defaulted.Translations) != 2 {
+		t.Errorf("omitted languages: got %s with %d translations, want the defaults es,it", got, len(defaulted.Translations))
+	}
+
+	explicit := createEvent(t, r, eventBody("Opera", "fr"))
+	if got := strings.Join(explicit.Languages, ","); got != "fr" || len(explicit.Translations) != 1 {
+		t.Errorf("explicit languages: got %s, want only fr", got)
+	}
+}
+
+func TestNoLanguagesWithoutDefaults(t *testing.T) {
+	setupTest(t)
+	if w := serveRequest(newRouter(), "POST", "/event", `{"name":"Concert","location":"Hall","details":"Music"}`); w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400 without languages or defaults", w.Code)
+	}
+}
+
+func TestRegionalVariantsStayDistinct(t *testing.T) {
+	setupTest(t)
+	created := createEvent(t, newRouter(), eventBody("Concert", "en", "en-US"))
+	if len(created.Translations) != 2 || created.Translations["en"] == "" || created.Translations["en-US"] == "" {
+		t.Fatalf("translations %v, want en and en-US kept apart", created.Translations)
+	}
+}
+
+func TestMergedLanguagesLogWarning(t *testing.T) {
+	setupTest(t)
+	logs := captureLogs(t)
+
+	if got := strings.Join(normalizeLanguages([]string{"pt-br", "pt-BR", "en", "en-US"}), ","); got != "pt-BR,en,en-US" {
+		t.Fatalf("normalized %s, want pt-BR,en,en-US", got)
+	}
+	if !strings.Contains(logs.String(), "languages merged into one code") || !strings.Contains(logs.String(), `"code":"pt-BR"`) {
+		t.Fatalf("logs %s, want a warning about pt-br and pt-BR", logs)
+	}
+	if strings.Count(logs.String(), "merged") != 1 {
+		t.Fatalf("logs %s, want one warning", logs)
+	}
+}
+
+func TestLanguageDirection(t *testing.T) {
+	tests := map[string]string{
+		"ar":      "rtl",
+		"he":      "rtl",
+		"fa":      "rtl",
+		"ur":      "rtl",
+		"en":      "ltr",
+		"fr-CA":   "ltr",
+		"zh-Hans": "ltr",
+		"az-Arab": "rtl",
+		"ku-Latn": "ltr",
+		"AR-eg":   "rtl",
+	}
+	for lang, want := range tests {
+		if got := languageDirection(lang); got != want {
+			t.Errorf("languageDirection(%q) = %q, want %q", lang, got, want)
+		}
+	}
+}
+
+func TestResultDirection(t *testing.T) {
+	setupTest(t)
+	created := createEvent(t, newRouter(), eventBody("Concert", "ar", "en", "he"))
+	for lang, want := range map[string]string{"ar": "rtl", "en": "ltr", "he": "rtl"} {
+		if got := created.Results[lang].Direction; got != want {
+			t.Errorf("%s direction %q, want %q", lang, got, want)
+		}
+	}
+}
+
+func TestTranslationsByName(t *testing.T) {
+	translations := map[string]string{"ja": "こんにちは", "de": "Hallo", "es": "Hola", "fr": "Bonjour", "zh-Hans": "你好", "xx-bogus!": "?"}
+	first := translationsByName(translations)
+	var got []string
+	for _, translation := range first {
+		got = append(got, translation.Language+"="+translation.Name)
+	}
+	want := []string{"fr=French", "de=German", "ja=Japanese", "zh-Hans=Simplified Chinese", "es=Spanish", "xx-bogus!=xx-bogus!"}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("order %v, want %v", got, want)
+	}
+	for i := 0; i < 20; i++ {
+		if again := translationsByName(translations); !reflect.DeepEqual(again, first) {
+			t.Fatalf("run %d ordered %v, want %v", i, again, first)
+		}
+	}
+}
+
+func TestGetEventTranslationOrder(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "ja", "fr", "de", "es"))
+	want := []string{"fr", "de", "ja", "es"}
+
+	for i := 0; i < 5; i++ {
+		var view eventView
+		decodeJSON(t, serveRequest(r, "GET", "/event?translationOrder=name&id="+created.ID, ""), &view)
+		var page struct {
+			Events []eventView `json:"events"`
+		}
+		decodeJSON(t, serveRequest(r, "GET", "/events?translationOrder=name", ""), &page)
+		if len(page.Events) != 1 {
+			t.Fatalf("listed %d events, want 1", len(page.Events))
+		}
+
+		for _, view := range []eventView{view, page.Events[0]} {
+			var order []string
+			for _, translation := range view.OrderedTranslations {
+				order = append(order, translation.Language)
+			}
+			if !reflect.DeepEqual(order, want) {
+				t.Errorf("order %v, want %v", order, want)
+			}
+			if len(view.Translations) != 4 {
+				t.Errorf("translations map %v, want it kept", view.Translations)
+			}
+		}
+	}
+
+	var fields map[string]json.RawMessage
+	decodeJSON(t, serveRequest(r, "GET", "/event?id="+created.ID, ""), &fields)
+	if _, ok := fields["orderedTranslations"]; ok {
+		t.Error("orderedTranslations returned without translationOrder=name")
+	}
+	if w := serveRequest(r, "GET", "/event?translationOrder=code&id="+created.ID, ""); w.Code != http.StatusBadRequest {
+		t.Errorf("translationOrder=code: status %d, want 400", w.Code)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"fmt"
+	"github.com/go-playground/validator/v10"
+	"net/url"
+	"sort"
+	"strings"
+)
+
+// normalizeLinkNames gives each link URL of linkNames a lowercase scheme and
+// host and drops a trailing slash from its path, so the same link is stored
+// under one key. The keys are the links' URLs and the values their names,
+// which are translated, so it is the keys that must be URLs. Keys that do not
+// parse as URLs are kept as they are for the link_url validator to report,
+// and links that normalize to the same URL are rejected rather than merged.
+// Without config.ValidateLinkURLs the keys are left as the client sent them.
+func normalizeLinkNames(linkNames map[string]string) (map[string]string, error) {
+	if linkNames == nil || !config.ValidateLinkURLs {
+		return linkNames, nil
+	}
+	keys := make([]string, 0, len(linkNames))
+	for key := range linkNames {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	normalized := make(map[string]string, len(linkNames))
+	original := make(map[string]string, len(linkNames))
+	for _, key := range keys {
+		link := normalizeLinkURL(key)
+		if first, seen := original[link]; seen {
+			return nil, fmt.Errorf("links %q and %q are the same URL, %s", first, key, link)
+		}
+		normalized[link] = linkNames[key]
+		original[link] = key
+	}
+	return normalized, nil
+}
+
+func normalizeLinkURL(link string) string {
+	link = strings.TrimSpace(link)
+	u, err := url.Parse(link)
+	if err != nil || !isHTTPURL(u) {
+		return link
+	}
+	u.Scheme = strings.ToLower(u.Scheme)
+	u.Host = strings.ToLower(u.Host)
+	u.Path = strings.TrimRight(u.Path, "/")
+	u.RawPath = ""
+	return u.String()
+}
+
+// isLinkURL reports whether a link key is an absolute http or https URL.
+// With config.ValidateLinkURLs unset any key is accepted.
+func isLinkURL(fl validator.FieldLevel) bool {
+	if !config.ValidateLinkURLs {
+		return true
+	}
+	u, err := url.Parse(fl.Field().String())
+	return err == nil && isHTTPURL(u)
+}
+
+func isHTTPURL(u *url.URL) bool {
+	scheme := strings.ToLower(u.Scheme)
+	return (scheme == "http" || scheme == "https") && u.Host != ""
+}
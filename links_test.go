@@ -0,0 +1,110 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestLinkNamesTranslatedSeparately(t *testing.T) {
+	setupTest(t)
+	fake := useFakeProvider(nil)
+	event := EventInfo{
+		Name:      "Concert",
+		Location:  "Hall",
+		Details:   "Music",
+		Languages: []string{"fr", "de"},
+		LinkNames: map[string]string{
+			"https://example.com/tickets": "Buy tickets",
+			"https://example.com/map":     "Directions",
+		},
+	}
+	created := createEvent(t, newRouter(), mustJSON(event))
+
+	for link, name := range event.LinkNames {
+		for _, lang := range event.Languages {
+			if got, want := created.TranslatedLinkNames[link][lang], lang+":"+name; got != want {
+				t.Errorf("%s in %s: %q, want %q", link, lang, got, want)
+			}
+		}
+	}
+	// Details and both links are translated into each language.
+	if fake.callCount("fr") != 3 || fake.callCount("de") != 3 {
+		t.Errorf("calls %v, want 3 texts per language", fake.calls)
+	}
+	if want := "fr:" + assembleDetails(created) + " fr:Directions fr:Buy tickets"; created.Translations["fr"] != want {
+		t.Errorf("fr translation %q, want %q", created.Translations["fr"], want)
+	}
+}
+
+// postLinks posts an event with linkNames and returns the response.
+func postLinks(t *testing.T, linkNames map[string]string) *httptest.ResponseRecorder {
+	t.Helper()
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr"}, LinkNames: linkNames}
+	return serveRequest(newRouter(), "POST", "/event", mustJSON(event))
+}
+
+func TestLinkURLsNormalized(t *testing.T) {
+	setupTest(t, "VALIDATE_LINK_URLS", "true")
+	w := postLinks(t, map[string]string{"HTTPS://Example.COM/tickets/": "Buy tickets"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	var created EventInfo
+	decodeJSON(t, w, &created)
+	if _, ok := created.LinkNames["https://example.com/tickets"]; !ok || len(created.LinkNames) != 1 {
+		t.Fatalf("link names %v, want the normalized URL", created.LinkNames)
+	}
+}
+
+func TestInvalidLinkNamesRejected(t *testing.T) {
+	setupTest(t, "VALIDATE_LINK_URLS", "true")
+	tests := []struct {
+		name      string
+		linkNames map[string]string
+		key       string
+	}{
+		{"empty name", map[string]string{"https://example.com/tickets": ""}, "https://example.com/tickets"},
+		{"malformed URL", map[string]string{"not a url": "Buy tickets"}, "not a url"},
+		{"relative URL", map[string]string{"/tickets": "Buy tickets"}, "/tickets"},
+		{"other scheme", map[string]string{"ftp://example.com/tickets": "Buy tickets"}, "ftp://example.com/tickets"},
+	}
+	for _, tt := range tests {
+		w := postLinks(t, tt.linkNames)
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, http.StatusBadRequest)
+			continue
+		}
+		var res errorResponse
+		decodeJSON(t, w, &res)
+		found := false
+		for field := range res.Error.Fields {
+			found = found || strings.Contains(field, tt.key)
+		}
+		if !found {
+			t.Errorf("%s: fields %v, want one naming %q", tt.name, res.Error.Fields, tt.key)
+		}
+	}
+}
+
+func TestSameLinkURLsRejected(t *testing.T) {
+	setupTest(t, "VALIDATE_LINK_URLS", "true")
+	w := postLinks(t, map[string]string{"https://example.com/tickets": "Buy tickets", "HTTPS://example.com/tickets/": "Tickets"})
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, body %s, want 400 for two keys with the same URL", w.Code, w.Body)
+	}
+}
+
+func TestLinkURLValidationOffByDefault(t *testing.T) {
+	setupTest(t)
+	w := postLinks(t, map[string]string{"tickets": "Buy tickets", "HTTPS://Example.COM/": "Home"})
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	var created EventInfo
+	decodeJSON(t, w, &created)
+	if len(created.LinkNames) != 2 || created.LinkNames["tickets"] == "" || created.LinkNames["HTTPS://Example.COM/"] == "" {
+		t.Errorf("link names %v, want the keys as sent", created.LinkNames)
+	}
+}
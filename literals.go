@@ -0,0 +1,16 @@
+package main
+
+import (
+	"regexp"
+)
+
+// literalPattern matches the tokens an event with PreserveLiterals keeps out
+// of translation: URLs, ISO 8601 dates and numbers such as "3/4", "1,000.50"
+// or "19:30", which providers would otherwise reformat for the target
+// locale. Earlier alternatives win, so a date is protected whole rather than
+// as separate numbers. Trailing punctuation is left out of URLs, and the
+// segment separator is never part of a match.
+var literalPattern = regexp.MustCompile(
+	`\b(?:https?://|www\.)[^\s\x00<>"]*[^\s\x00<>".,;:!?')\]]` +
+		`|\b\d{4}-\d{2}-\d{2}(?:T\d{2}:\d{2}(?::\d{2})?(?:Z|[+-]\d{2}:?\d{2})?)?\b` +
+		`|\b\d+(?:[.,:/]\d+)*\b`)
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLiteralPattern(t *testing.T) {
+	tests := map[string][]string{
+		"Doors at 19:30, tickets 3/4 off":            {"19:30", "3/4"},
+		"On 2024-07-01T19:30+02:00 for 1,000.50 EUR": {"2024-07-01T19:30+02:00", "1,000.50"},
+		"See https://example.com/a?b=1.":             {"https://example.com/a?b=1"},
+		"Visit www.example.com, then":                {"www.example.com"},
+		"No literals here":                           nil,
+	}
+	for text, want := range tests {
+		got := literalPattern.FindAllString(text, -1)
+		if strings.Join(got, "|") != strings.Join(want, "|") {
+			t.Errorf("literals of %q = %q, want %q", text, got, want)
+		}
+	}
+}
+
+// useLocalizingProvider translates by reformatting numbers and dates the way
+// a provider localizing for French might, leaving placeholders alone.
+func useLocalizingProvider() {
+	localize := strings.NewReplacer("/", ".", "-", ".", ":", "h", ",", " ", "https", "http")
+	useFakeProvider(func(text, from, to string) (string, error) {
+		return localize.Replace(text), nil
+	})
+}
+
+func TestPreserveLiterals(t *testing.T) {
+	setupTest(t)
+	useLocalizingProvider()
+	event := EventInfo{
+		Name:             "Jazz",
+		Location:         "Hall",
+		Details:          "On 2024-07-01 at 19:30, 3/4 off at https://example.com/jazz",
+		Keywords:         []string{"Jazz"},
+		PreserveLiterals: true,
+		Languages:        []string{"fr"},
+	}
+
+	created := createEvent(t, newRouter(), mustJSON(event))
+	translation := created.Translations["fr"]
+	for _, literal := range []string{"Jazz", "2024-07-01", "19:30", "3/4", "https://example.com/jazz"} {
+		if !strings.Contains(translation, literal) {
+			t.Errorf("translation %q lost %q", translation, literal)
+		}
+	}
+}
+
+func TestLiteralsTranslatedByDefault(t *testing.T) {
+	setupTest(t)
+	useLocalizingProvider()
+	event := EventInfo{Name: "Jazz", Location: "Hall", Details: "On 2024-07-01 at 19:30", Languages: []string{"fr"}}
+
+	created := createEvent(t, newRouter(), mustJSON(event))
+	if translation := created.Translations["fr"]; strings.Contains(translation, "19:30") {
+		t.Errorf("translation %q kept 19:30 without preserveLiterals", translation)
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// requestStats accumulates translation activity for a single HTTP request.
+type requestStats struct {
+	sync.Mutex
+	event        string
+	languages    int
+	calls        int
+	cacheHits    int
+	callDuration time.Duration
+	// languageDurations is how long each language took to translate.
+	languageDurations map[string]time.Duration
+}
+
+type requestStatsKey struct{}
+
+func withRequestStats(ctx context.Context, stats *requestStats) context.Context {
+	return context.WithValue(ctx, requestStatsKey{}, stats)
+}
+
+// statsFromContext returns the stats attached to ctx, or nil. All methods
+// accept a nil receiver so callers need not check.
+func statsFromContext(ctx context.Context) *requestStats {
+	stats, _ := ctx.Value(requestStatsKey{}).(*requestStats)
+	return stats
+}
+
+func (s *requestStats) setEvent(name string, languages int) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.event = name
+	s.languages = languages
+}
+
+func (s *requestStats) recordCall(d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.calls++
+	s.callDuration += d
+}
+
+func (s *requestStats) recordCacheHit() {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	s.cacheHits++
+}
+
+func (s *requestStats) recordLanguage(lang string, d time.Duration) {
+	if s == nil {
+		return
+	}
+	s.Lock()
+	defer s.Unlock()
+	if s.languageDurations == nil {
+		s.languageDurations = make(map[string]time.Duration)
+	}
+	s.languageDurations[lang] = d
+}
+
+// translationStats is the translation work of a request, as returned by
+// POST /event?stats=true. Durations are in milliseconds.
+type translationStats struct {
+	Calls             int                `json:"calls"`
+	CacheHits         int                `json:"cacheHits"`
+	CallDurationMs    float64            `json:"callDurationMs"`
+	LanguageDurations map[string]float64 `json:"languageDurationsMs"`
+}
+
+func (s *requestStats) snapshot() translationStats {
+	if s == nil {
+		return translationStats{}
+	}
+	s.Lock()
+	defer s.Unlock()
+	stats := translationStats{
+		Calls:             s.calls,
+		CacheHits:         s.cacheHits,
+		CallDurationMs:    milliseconds(s.callDuration),
+		LanguageDurations: make(map[string]float64, len(s.languageDurations)),
+	}
+	for lang, d := range s.languageDurations {
+		stats.LanguageDurations[lang] = milliseconds(d)
+	}
+	return stats
+}
+
+func milliseconds(d time.Duration) float64 {
+	return float64(d) / float64(time.Millisecond)
+}
+
+// newLogger logs JSON lines at level and above, hiding the values of the
+// redacted fields as described by redactAttrs.
+func newLogger(level slog.Level, redacted []string, redaction string) *slog.Logger {
+	return slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{
+		Level:       level,
+		ReplaceAttr: redactAttrs(redacted, redaction),
+	}))
+}
+
+// requestLogger emits one structured log line per request, including the
+// translation work the request caused.
+func requestLogger(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+		stats := &requestStats{}
+		c.Request = c.Request.WithContext(withRequestStats(c.Request.Context(), stats))
+
+		c.Next()
+
+		stats.Lock()
+		defer stats.Unlock()
+		attrs := []slog.Attr{
+			slog.String("method", c.Request.Method),
+			slog.String("path", c.Request.URL.Path),
+			slog.Int("status", c.Writer.Status()),
+			slog.Duration("latency", time.Since(start)),
+		}
+		if stats.event != "" {
+			attrs = append(attrs,
+				slog.String("event", stats.event),
+				slog.Int("languages", stats.languages),
+				slog.Int("translationCalls", stats.calls),
+				slog.Int("cacheHits", stats.cacheHits),
+				slog.Duration("translationTime", stats.callDuration),
+			)
+		}
+
+		level := slog.LevelInfo
+		if c.Writer.Status() >= 500 {
+			level = slog.LevelError
+		}
+		logger.LogAttrs(c.Request.Context(), level, "request", attrs...)
+	}
+}
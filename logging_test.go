@@ -0,0 +1,137 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+// captureLogs makes the logger write JSON lines to the returned buffer.
+func captureLogs(t *testing.T) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logger = slog.New(slog.NewJSONHandler(&buf, nil))
+	return &buf
+}
+
+// requestLogLine returns the fields of the first "request" log line.
+func requestLogLine(t *testing.T, logs *bytes.Buffer) map[string]interface{} {
+	t.Helper()
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatalf("decoding log line %q: %v", line, err)
+		}
+		if fields["msg"] == "request" {
+			return fields
+		}
+	}
+	t.Fatalf("no request log line in %s", logs)
+	return nil
+}
+
+func TestRequestLoggerFields(t *testing.T) {
+	setupTest(t)
+	logs := captureLogs(t)
+	createEvent(t, newRouter(), eventBody("Concert", "fr", "de"))
+
+	fields := requestLogLine(t, logs)
+	want := map[string]interface{}{
+		"method":           "POST",
+		"path":             "/event",
+		"status":           float64(201),
+		"event":            "Concert",
+		"languages":        float64(2),
+		"translationCalls": float64(2),
+		"cacheHits":        float64(0),
+	}
+	for key, value := range want {
+		if fields[key] != value {
+			t.Errorf("%s = %v, want %v", key, fields[key], value)
+		}
+	}
+	for _, key := range []string{"latency", "translationTime"} {
+		if _, ok := fields[key]; !ok {
+			t.Errorf("%s missing from %v", key, fields)
+		}
+	}
+}
+
+// captureRedactedLogs is captureLogs with the fields redacted as
+// LOG_REDACT_FIELDS and LOG_REDACTION would.
+func captureRedactedLogs(t *testing.T, fields []string, mode string) *bytes.Buffer {
+	t.Helper()
+	var buf bytes.Buffer
+	logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{ReplaceAttr: redactAttrs(fields, mode)}))
+	return &buf
+}
+
+func TestLogRedaction(t *testing.T) {
+	setupTest(t)
+	logs := captureRedactedLogs(t, []string{"Details", "sponsoredMessage"}, redactMask)
+	logger.Info("event stored", "event", "Concert", "details", "Call 555-0100", "languages", 2,
+		slog.Group("payload", "sponsoredMessage", "Code SECRET"))
+
+	out := logs.String()
+	for _, secret := range []string{"555-0100", "SECRET"} {
+		if strings.Contains(out, secret) {
+			t.Errorf("log %s contains %q", out, secret)
+		}
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(logs.Bytes(), &fields); err != nil {
+		t.Fatal(err)
+	}
+	if fields["details"] != redactedValue {
+		t.Errorf("details = %v, want %q", fields["details"], redactedValue)
+	}
+	if fields["languages"] != float64(2) || fields["event"] != "Concert" {
+		t.Errorf("log %s, want the event name and language count kept", out)
+	}
+}
+
+func TestLogRedactionHash(t *testing.T) {
+	setupTest(t)
+	logs := captureRedactedLogs(t, []string{"details"}, redactHash)
+	logger.Info("first", "details", "Call 555-0100")
+	logger.Info("second", "details", "Call 555-0100")
+	logger.Info("third", "details", "Something else")
+
+	var hashes []string
+	for _, line := range strings.Split(strings.TrimSpace(logs.String()), "\n") {
+		var fields map[string]interface{}
+		if err := json.Unmarshal([]byte(line), &fields); err != nil {
+			t.Fatal(err)
+		}
+		hash, _ := fields["details"].(string)
+		if !strings.HasPrefix(hash, "sha256:") {
+			t.Fatalf("details = %q, want a sha256 hash", hash)
+		}
+		hashes = append(hashes, hash)
+	}
+	if hashes[0] != hashes[1] || hashes[0] == hashes[2] {
+		t.Errorf("hashes %v, want equal values to hash alike and others not", hashes)
+	}
+}
+
+func TestRequestLogRedaction(t *testing.T) {
+	setupTest(t)
+	logs := captureRedactedLogs(t, []string{"event"}, redactMask)
+	createEvent(t, newRouter(), eventBody("Concert", "fr", "de"))
+
+	fields := requestLogLine(t, logs)
+	if fields["event"] != redactedValue {
+		t.Errorf("event = %v, want %q", fields["event"], redactedValue)
+	}
+	if fields["languages"] != float64(2) {
+		t.Errorf("languages = %v, want 2", fields["languages"])
+	}
+}
+
+func TestNoRedactionByDefault(t *testing.T) {
+	if redactAttrs(nil, redactMask) != nil {
+		t.Fatal("redactAttrs with no fields is not nil")
+	}
+}
@@ -1,165 +1,1067 @@
 package main
 
 import (
-	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
-	"io/ioutil"
+	"github.com/google/uuid"
+	"github.com/prometheus/client_golang/prometheus"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/sync/errgroup"
+	"log"
+	"log/slog"
+	"math"
+	"net"
 	"net/http"
+	"net/url"
+	"os"
+	"os/signal"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 )
 
 type EventInfo struct {
+	// ID identifies the event in the store. It is generated when an event is
+	// created without one. Name is only displayed and need not be unique.
+	ID               string            `json:"id"`
 	Name             string            `json:"name" validate:"required"`
 	Location         string            `json:"location" validate:"required"`
 	Details          string            `json:"details" validate:"required"`
-	LinkNames        map[string]string `json:"linkNames" validate:"dive,keys,required,endkeys,required"`
+	LinkNames        map[string]string `json:"linkNames" validate:"dive,keys,required,link_url,endkeys,required"`
 	SponsoredMessage string            `json:"sponsoredMessage"`
-	Languages        []string          `json:"languages" validate:"required,dive,required"`
+	Languages        []string          `json:"languages" validate:"required,min=1,dive,required,supported_language"`
 	Keywords         []string          `json:"keywords" validate:"dive,required"`
-	Translations     map[string]string `json:"translations"`
-}
 
-type TranslationRequest struct {
-	Text string `json:"Text"`
+	// Slug is a URL-safe form of Name, unique among the stored events and
+	// assigned when the event is created. Events can be fetched by it in
+	// place of their name.
+	Slug string `json:"slug"`
+
+	// CreatedAt is when the event was created and UpdatedAt when it was
+	// last changed or retranslated. Both are set by the server; values sent
+	// by clients are ignored.
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Translations holds the translated text per language. Results carries
+	// the same translations along with how each was produced.
+	Translations map[string]string            `json:"translations"`
+	Results      map[string]TranslationResult `json:"results,omitempty"`
+	// TranslationErrors maps each language that could not be translated to
+	// the reason.
+	TranslationErrors map[string]string `json:"translationErrors,omitempty"`
+	// TranslationHash identifies the input the translations were made
+	// from. The refresher retranslates events whose input no longer matches
+	// it.
+	TranslationHash string `json:"translationHash,omitempty"`
+
+	// TranslatedLinkNames maps each link key to its name translated into
+	// every language. The keys themselves are never translated.
+	TranslatedLinkNames map[string]map[string]string `json:"translatedLinkNames,omitempty"`
+	// TranslatedSponsoredMessage maps each language to the translated
+	// sponsored message. It is nil when there is no sponsored message.
+	TranslatedSponsoredMessage map[string]string `json:"translatedSponsoredMessage,omitempty"`
+
+	// Transliterate requests a Latin-script rendering of each translation
+	// whose script supports it, returned in Transliterations.
+	Transliterate    bool              `json:"transliterate"`
+	Transliterations map[string]string `json:"transliterations,omitempty"`
+
+	// TranslateKeywords also translates each keyword on its own into every
+	// language, returned in TranslatedKeywords in the order of Keywords.
+	// Keywords are still kept as they are in the translated text.
+	TranslateKeywords  bool                `json:"translateKeywords"`
+	TranslatedKeywords map[string][]string `json:"translatedKeywords,omitempty"`
+
+	// SourceLanguage is sent to the provider as the "from" language. When
+	// empty, the provider detects the source language itself.
+	SourceLanguage string `json:"sourceLanguage" validate:"omitempty,iso639_1"`
+
+	// DetectLanguage asks the provider to identify the source language before
+	// translating when SourceLanguage is empty. The result is reported in
+	// DetectedLanguage and DetectionScore and used as the "from" language.
+	DetectLanguage   bool    `json:"detectLanguage"`
+	DetectedLanguage string  `json:"detectedLanguage,omitempty"`
+	DetectionScore   float64 `json:"detectionScore,omitempty"`
+
+	// Glossary forces the translation of terms: term -> language -> the
+	// rendering to use instead of the provider's translation.
+	Glossary map[string]map[string]string `json:"glossary" validate:"dive,keys,required,endkeys,dive,keys,required,endkeys,required"`
+
+	CaseInsensitiveKeywords bool `json:"caseInsensitiveKeywords"`
+	WholeWordKeywords       bool `json:"wholeWordKeywords"`
+	// PreserveLiterals keeps numbers, ISO dates and URLs as they are, the
+	// way keywords are kept.
+	PreserveLiterals bool `json:"preserveLiterals"`
+
+	// TextType is "plain" (the default) or "html", which keeps markup in
+	// the text intact through translation.
+	TextType string `json:"textType" validate:"omitempty,oneof=plain html"`
+
+	// ProfanityAction is how Azure treats profanity in translations:
+	// "NoAction" (the default), "Marked" or "Deleted".
+	ProfanityAction string `json:"profanityAction" validate:"omitempty,oneof=NoAction Marked Deleted"`
+
+	// Category selects an Azure Custom Translator model by its category ID.
+	// When empty, config.AzureCategory applies.
+	Category string `json:"category" validate:"omitempty,azure_category"`
+
+	// CallbackURL makes POST /event answer 202 Accepted at once and post the
+	// translated event to this URL when done.
+	CallbackURL string `json:"callbackUrl" validate:"omitempty,url,startswith=http"`
+
+	// IncludeAlternatives asks for every candidate translation the provider
+	// offers, returned in Alternatives with the primary translation first.
+	IncludeAlternatives bool                `json:"includeAlternatives"`
+	Alternatives        map[string][]string `json:"alternatives,omitempty"`
+
+	// VerifyRoundTrip translates each translation back into the source
+	// language and scores in Results how much of the meaning survived. It
+	// doubles the provider calls, and needs SourceLanguage or a detected
+	// language to translate back into.
+	VerifyRoundTrip bool `json:"verifyRoundTrip"`
 }
 
-type TranslationResponse struct {
-	Translations []struct {
-		Text string `json:"text"`
-	} `json:"translations"`
+// TranslationResult describes the translation of an event into one language.
+type TranslationResult struct {
+	Text     string `json:"text"`
+	Provider string `json:"provider"`
+	// FromCache is true when every part of the text was served from the
+	// translation cache without calling the provider.
+	FromCache bool `json:"fromCache"`
+	// SourceLanguage is the "from" language sent to the provider; empty when
+	// the provider detected it.
+	SourceLanguage string `json:"sourceLanguage,omitempty"`
+	// FallbackLanguage is the language the text was translated into instead,
+	// when the provider did not support the requested one.
+	FallbackLanguage string `json:"fallbackLanguage,omitempty"`
+	// Direction is the writing direction of the text, "ltr" or "rtl", so
+	// clients can render it without a lookup of their own.
+	Direction string `json:"direction"`
+	// RoundTripScore is the word overlap, between 0 and 1, of the source
+	// text with the translation translated back. LowConfidence flags scores
+	// below config.RoundTripThreshold.
+	RoundTripScore *float64 `json:"roundTripScore,omitempty"`
+	LowConfidence  bool     `json:"lowConfidence,omitempty"`
 }
 
 var (
-	events map[string]EventInfo
+	events eventStore
+	config Config
+	cache  *translationCache
+
+	httpClient     *http.Client
+	callbackClient *http.Client
+	provider       TranslationProvider
+
+	logger  *slog.Logger
+	metrics *translatorMetrics
 
 	validate *validator.Validate
 )
 
 func init() {
-	events = make(map[string]EventInfo)
+	events = newMemoryEventStore()
+	logger = slog.Default()
 	validate = validator.New()
+	validate.RegisterTagNameFunc(jsonFieldName)
+	validate.RegisterValidation("iso639_1", isISO6391)
+	validate.RegisterValidation("supported_language", isSupportedLanguage)
+	validate.RegisterValidation("link_url", isLinkURL)
+	validate.RegisterValidation("azure_category", isAzureCategory)
 }
 
-func translateText(text, targetLanguage, url, subscriptionKey, location string) (string, error) {
-	body := []TranslationRequest{{Text: text}}
-	jsonBody, err := json.Marshal(body)
-	if err != nil {
-		return "", fmt.Errorf("error marshaling json: %v", err)
+// eventSegment is one piece of an event that is translated on its own, so
+// segment boundaries survive translation.
+type eventSegment struct {
+	// role is "details", "link:<key>" or "sponsoredMessage", or "text" for
+	// POST /translate.
+	role string
+	text string
+}
+
+func eventSegments(event EventInfo) []eventSegment {
+	segments := []eventSegment{{role: "details", text: assembleDetails(event)}}
+
+	keys := make([]string, 0, len(event.LinkNames))
+	for key := range event.LinkNames {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		segments = append(segments, eventSegment{role: "link:" + key, text: event.LinkNames[key]})
 	}
 
-	req, err := http.NewRequest("POST", url+"&to="+targetLanguage, bytes.NewBuffer(jsonBody))
-	if err != nil {
-		return "", fmt.Errorf("error creating request: %v", err)
+	if event.SponsoredMessage != "" {
+		segments = append(segments, eventSegment{role: "sponsoredMessage", text: event.SponsoredMessage})
 	}
-	req.Header.Add("Ocp-Apim-Subscription-Key", subscriptionKey)
-	req.Header.Add("Content-Type", "application/json")
-	req.Header.Add("Ocp-Apim-Subscription-Region", location)
+	return segments
+}
 
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return "", fmt.Errorf("error making translation request: %v", err)
+func joinSegments(segments []eventSegment) string {
+	texts := make([]string, len(segments))
+	for i, segment := range segments {
+		texts[i] = segment.text
 	}
-	defer resp.Body.Close()
+	return strings.Join(texts, " ")
+}
+
+// segmentTranslation is the translation of an event's segments into one
+// language.
+type segmentTranslation struct {
+	segments []eventSegment
+	// fromCache is true when every segment was served from the cache.
+	fromCache bool
+	// alternatives holds every candidate translation of the joined segments
+	// when the event asked for them, starting with the primary one.
+	alternatives []string
+}
+
+// translateSegments translates every segment into lang in as few provider
+// calls as possible and returns the translated segments with their roles. The
+// event's keywords are kept as they are and its glossary terms are replaced
+// with the forced rendering for lang.
+func translateSegments(ctx context.Context, event EventInfo, segments []eventSegment, from, lang string) (segmentTranslation, error) {
+	prepared, placeholderMap := prepareSegments(event, segments, lang)
+	debugFromContext(ctx).recordPrepared(lang, segments, prepared, placeholderMap)
+
+	// Placeholders are in place before chunking so none is cut in two.
+	pieces, layout := chunkTexts(prepared, maxRequestLength)
 
-	respBody, err := ioutil.ReadAll(resp.Body)
+	var result segmentTranslation
+	var candidates [][]string
+	var err error
+	if event.IncludeAlternatives {
+		candidates, err = translateAlternatives(ctx, pieces, from, lang, translateOptionsFor(event))
+	} else {
+		var translated []string
+		translated, result.fromCache, err = translateTexts(ctx, pieces, from, lang, translateOptionsFor(event))
+		candidates = [][]string{translated}
+	}
 	if err != nil {
-		return "", fmt.Errorf("error reading response body: %v", err)
+		return segmentTranslation{}, err
+	}
+
+	for _, translated := range candidates {
+		translated = layout.join(translated)
+		restored := make([]eventSegment, len(segments))
+		for i, segment := range segments {
+			text := replacePlaceholdersWithKeywords(translated[i], prepared[i], placeholderMap)
+			restored[i] = eventSegment{role: segment.role, text: applyTransforms(lang, text)}
+		}
+		if result.segments == nil {
+			result.segments = restored
+		}
+		if event.IncludeAlternatives {
+			result.alternatives = append(result.alternatives, joinSegments(restored))
+		}
 	}
+	return result, nil
+}
 
-	if resp.StatusCode != http.StatusOK {
-		return "", fmt.Errorf("non-OK HTTP status: %d, response: %s", resp.StatusCode, respBody)
+func translateOptionsFor(event EventInfo) TranslateOptions {
+	category := event.Category
+	if category == "" {
+		category = config.AzureCategory
 	}
+	return TranslateOptions{TextType: event.TextType, ProfanityAction: event.ProfanityAction, Category: category}
+}
 
-	var res []TranslationResponse
-	if err := json.Unmarshal(respBody, &res); err != nil {
-		return "", fmt.Errorf("error decoding response body: %v", err)
+// prepareSegments returns the segment texts exactly as they are sent to the
+// provider for lang, sanitized and with keywords and glossary terms replaced
+// by placeholders, and what each placeholder is restored to afterwards.
+func prepareSegments(event EventInfo, segments []eventSegment, lang string) ([]string, map[string]string) {
+	texts := make([]string, len(segments))
+	for i, segment := range segments {
+		texts[i] = sanitizeText(segment.text)
 	}
+	// Keywords and terms are sanitized like the text so they still match.
+	glossary := glossaryFor(event.Glossary, lang)
+	terms := sanitizeTexts(append(glossaryTerms(glossary), event.Keywords...))
+	prepared, placeholderMap := replaceKeywordsInSegments(texts, terms, keywordOptionsFor(event))
+	return prepared, applyGlossary(placeholderMap, glossary)
+}
 
-	if len(res) > 0 && len(res[0].Translations) > 0 {
-		return res[0].Translations[0].Text, nil
+// translateEvent fills in the event's translations for every requested
+// language, running up to config.TranslationConcurrency translations at once.
+// Each language is attempted independently; the ones that failed are returned
+// and left out of the event.
+func translateEvent(ctx context.Context, event *EventInfo) map[string]error {
+	segments := eventSegments(*event)
+
+	from := event.SourceLanguage
+	event.DetectedLanguage, event.DetectionScore = "", 0
+	if event.DetectLanguage && from == "" {
+		if lang, score, ok := detectLanguage(ctx, joinSegments(segments)); ok {
+			event.DetectedLanguage, event.DetectionScore = lang, score
+			from = lang
+		}
 	}
 
-	return "", fmt.Errorf("no translations found in the response")
+	var mu sync.Mutex
+	translations := make(map[string]string)
+	results := make(map[string]TranslationResult)
+	var linkNames map[string]map[string]string
+	if len(event.LinkNames) > 0 {
+		linkNames = make(map[string]map[string]string, len(event.LinkNames))
+		for key := range event.LinkNames {
+			linkNames[key] = make(map[string]string)
+		}
+	}
+	var sponsoredMessages map[string]string
+	if event.SponsoredMessage != "" {
+		sponsoredMessages = make(map[string]string)
+	}
+	var alternatives map[string][]string
+	if event.IncludeAlternatives {
+		alternatives = make(map[string][]string)
+	}
+	var transliterations map[string]string
+	if event.Transliterate {
+		transliterations = make(map[string]string)
+	}
+	var translatedKeywords map[string][]string
+	if event.TranslateKeywords && len(event.Keywords) > 0 {
+		translatedKeywords = make(map[string][]string)
+	}
+	failures := make(map[string]error)
+
+	var g errgroup.Group
+	if config.TranslationConcurrency > 0 {
+		g.SetLimit(config.TranslationConcurrency)
+	}
+	for _, lang := range event.Languages {
+		lang := lang
+		g.Go(func() error {
+			ctx, span := tracer.Start(ctx, "translate.language", trace.WithAttributes(
+				attribute.String("translation.target_language", lang),
+			))
+			start := time.Now()
+			segments := segmentsFor(segments, lang)
+			translated, fallback, err := translateWithFallback(ctx, *event, segments, from, lang)
+			statsFromContext(ctx).recordLanguage(lang, time.Since(start))
+			span.SetAttributes(attribute.Bool("translation.cache_hit", translated.fromCache))
+			endSpan(span, err)
+			if err != nil {
+				mu.Lock()
+				failures[lang] = err
+				mu.Unlock()
+				return nil
+			}
+			text := joinTranslatedSegments(translated.segments, lang)
+			// The text is in the fallback language when one was used.
+			target := lang
+			if fallback != "" {
+				target = fallback
+			}
+
+			var romanized string
+			var ok bool
+			if event.Transliterate {
+				romanized, ok = transliterateText(ctx, text, target)
+			}
+			var keywords []string
+			var keywordsOK bool
+			if translatedKeywords != nil {
+				keywords, keywordsOK = translateKeywords(ctx, *event, from, target)
+			}
+			result := TranslationResult{
+				Text:             text,
+				Provider:         config.Provider,
+				FromCache:        translated.fromCache,
+				SourceLanguage:   from,
+				FallbackLanguage: fallback,
+				Direction:        languageDirection(target),
+			}
+			if event.VerifyRoundTrip {
+				if score, scored := roundTripScore(ctx, *event, segments, translated.segments, from, target); scored {
+					result.RoundTripScore = &score
+					result.LowConfidence = score < config.RoundTripThreshold
+				}
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			translations[lang] = text
+			results[lang] = result
+			if alternatives != nil {
+				alternatives[lang] = translated.alternatives
+			}
+			for _, segment := range translated.segments {
+				if key := strings.TrimPrefix(segment.role, "link:"); key != segment.role {
+					linkNames[key][lang] = segment.text
+				} else if segment.role == "sponsoredMessage" {
+					sponsoredMessages[lang] = segment.text
+				}
+			}
+			if ok {
+				transliterations[lang] = romanized
+			}
+			if keywordsOK {
+				translatedKeywords[lang] = keywords
+			}
+			return nil
+		})
+	}
+	g.Wait()
+
+	event.Translations = translations
+	event.Results = results
+	event.TranslatedLinkNames = linkNames
+	event.TranslatedSponsoredMessage = sponsoredMessages
+	event.Alternatives = alternatives
+	event.Transliterations = transliterations
+	event.TranslatedKeywords = translatedKeywords
+	event.TranslationHash = translationHash(*event)
+	escapeTranslations(event)
+	return failures
+}
+
+// firstFailure picks the failure reported to the client when no language
+// could be translated, choosing by language code so the response is stable.
+func firstFailure(failures map[string]error) (string, error) {
+	langs := make([]string, 0, len(failures))
+	for lang := range failures {
+		langs = append(langs, lang)
+	}
+	sort.Strings(langs)
+	return langs[0], failures[langs[0]]
+}
+
+func failureMessages(failures map[string]error) map[string]string {
+	if len(failures) == 0 {
+		return nil
+	}
+	messages := make(map[string]string, len(failures))
+	for lang, err := range failures {
+		messages[lang] = err.Error()
+	}
+	return messages
 }
 
-func replaceKeywordsWithPlaceholders(text string, keywords []string) (string, map[string]string) {
-	placeholderMap := make(map[string]string)
-	for i, keyword := range keywords {
-		placeholder := fmt.Sprintf("KW%dPLH", i)
-		text = strings.ReplaceAll(text, keyword, placeholder)
-		placeholderMap[placeholder] = keyword
+// respondTranslationError maps a failed translation to an HTTP response.
+// The provider rejecting our credentials, being unreachable or answering with
+// something we cannot decode is an upstream problem (502) and a call timing
+// out is 504, while an exhausted quota is surfaced to the client as 429 and an
+// unsupported language as 400. An open circuit breaker or a call queue too
+// long to wait through is 503.
+func respondTranslationError(c *gin.Context, lang string, err error) {
+	body := apiError{Message: fmt.Sprintf("Error translating to %s: %v", lang, err)}
+
+	if errors.Is(err, errCircuitOpen) {
+		if _, remaining := breaker.status(time.Now()); remaining > 0 {
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(remaining.Seconds()))))
+		}
+		body.Code = codeCircuitOpen
+		c.JSON(http.StatusServiceUnavailable, errorResponse{body})
+		return
+	}
+	if errors.Is(err, errQueueTimeout) {
+		body.Code = codeQueueTimeout
+		c.JSON(http.StatusServiceUnavailable, errorResponse{body})
+		return
+	}
+
+	var upstreamErr providerError
+	if errors.As(err, &upstreamErr) {
+		logger.Warn("translation failed",
+			"language", lang,
+			"status", upstreamErr.HTTPStatus(),
+			"response", string(upstreamErr.ResponseBody()))
+		body.ProviderCode = upstreamErr.ErrorCode()
 	}
-	return text, placeholderMap
+
+	status := http.StatusInternalServerError
+	body.Code = codeTranslationFailed
+	switch {
+	case errors.Is(err, ErrProviderQuota):
+		status, body.Code = http.StatusTooManyRequests, codeProviderQuota
+	case errors.Is(err, ErrUnsupportedLanguage):
+		status, body.Code = http.StatusBadRequest, codeUnsupportedLanguage
+	case isTimeout(err):
+		status, body.Code = http.StatusGatewayTimeout, codeProviderTimeout
+	case errors.Is(err, ErrProviderAuth), errors.Is(err, ErrProviderUnreachable), errors.Is(err, ErrTranslationDecode):
+		status, body.Code = http.StatusBadGateway, codeProviderError
+	}
+
+	c.JSON(status, errorResponse{body})
 }
 
-func replacePlaceholdersWithKeywords(text string, placeholderMap map[string]string) string {
-	for placeholder, keyword := range placeholderMap {
-		text = strings.ReplaceAll(text, placeholder, keyword)
+// statusClientClosedRequest is the non-standard status recorded for requests
+// whose client went away before they were answered.
+const statusClientClosedRequest = 499
+
+// abortIfCanceled stops handling a request whose client has gone away, so
+// nothing is stored from a translation that was cut short. Translation calls
+// not yet made when the client left are skipped.
+func abortIfCanceled(c *gin.Context) bool {
+	if err := c.Request.Context().Err(); err != nil {
+		logger.Info("request canceled", "path", c.Request.URL.Path, "error", err)
+		abortWithError(c, statusClientClosedRequest, codeRequestCanceled, "Request canceled")
+		return true
 	}
-	return text
+	return false
 }
 
 func postEvent(c *gin.Context) {
 	var event EventInfo
-	if err := c.BindJSON(&event); err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	if !bindEvent(c, &event) {
+		return
+	}
+
+	if !checkTextLength(c, eventSegments(event)) {
+		return
+	}
+	if c.Query("dryRun") == "true" {
+		c.JSON(http.StatusOK, previewEvent(event))
+		return
+	}
+
+	if event.ID == "" {
+		event.ID = uuid.NewString()
+	} else if events.exists(event.ID) {
+		respondConflict(c, event.ID)
+		return
+	}
+	event.Slug = uniqueSlug(event.Name)
+	event.CreatedAt = time.Now().UTC()
+	event.UpdatedAt = event.CreatedAt
+
+	if event.CallbackURL != "" {
+		translateInBackground(event)
+		c.Header("Location", eventLocation(event.ID))
+		c.JSON(http.StatusAccepted, event)
+		return
+	}
+
+	statsFromContext(c.Request.Context()).setEvent(event.Name, len(event.Languages))
+	ctx, cancel := translationContext(c)
+	defer cancel()
+	ctx, debug := withTranslationDebug(ctx, c.Query("debug") == "true")
+	failures := translateEvent(ctx, &event)
+	if abortIfCanceled(c) {
+		return
+	}
+	event.TranslationErrors = failureMessages(failures)
+	if respondIfDeadlineExceeded(c, ctx, failures, event) {
+		return
+	}
+	if len(event.Translations) == 0 && len(failures) > 0 {
+		lang, err := firstFailure(failures)
+		respondTranslationError(c, lang, err)
+		return
+	}
+
+	if err := addEvent(&event); err != nil {
+		if errors.Is(err, errEventExists) {
+			respondConflict(c, event.ID)
+		} else {
+			respondError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		}
+		return
+	}
+
+	c.Header("Location", eventLocation(event.ID))
+	// Some languages failed: the event is stored with what succeeded.
+	status := http.StatusCreated
+	if len(failures) > 0 {
+		status = http.StatusMultiStatus
+	}
+	if c.Query("stats") == "true" || debug != nil {
+		res := eventWithStats{EventInfo: event, Debug: debug.snapshot()}
+		if c.Query("stats") == "true" {
+			stats := statsFromContext(c.Request.Context()).snapshot()
+			res.Stats = &stats
+		}
+		c.JSON(status, res)
+		return
+	}
+	c.JSON(status, event)
+}
+
+// respondConflict answers 409 with the event already stored under id, so the
+// client can tell whether to update it without fetching it first.
+func respondConflict(c *gin.Context, id string) {
+	body := gin.H{"error": apiError{Code: codeEventExists, Message: "Event already exists"}}
+	if existing, ok := events.get(id); ok {
+		body["event"] = existing
+	}
+	c.JSON(http.StatusConflict, body)
+}
+
+// eventWithStats is the response to POST /event?stats=true or ?debug=true:
+// the event's fields with the translation statistics of the request or what
+// was sent to the provider alongside them.
+type eventWithStats struct {
+	EventInfo
+	Stats *translationStats        `json:"stats,omitempty"`
+	Debug map[string]languageDebug `json:"debug,omitempty"`
+}
+
+// eventLocation is the URL an event can be fetched from.
+func eventLocation(id string) string {
+	return "/event?" + url.Values{"id": {id}}.Encode()
+}
+
+// findEvent looks an event up by id or, for clients that predate IDs, by
+// name. Of several events sharing a name, the first in listing order is
+// returned.
+func findEvent(id, name string) (EventInfo, bool) {
+	if id != "" {
+		return events.get(id)
+	}
+	return events.findByName(name)
+}
+
+// queriedEvent finds the event named by the ?id= or the older ?type= query
+// parameter. ?type= also accepts the event's slug, which unlike its name
+// needs no escaping in a URL.
+func queriedEvent(c *gin.Context) (EventInfo, bool) {
+	if event, ok := findEvent(c.Query("id"), c.Query("type")); ok || c.Query("id") != "" {
+		return event, ok
+	}
+	return events.findBySlug(c.Query("type"))
+}
+
+func updateEvent(c *gin.Context) {
+	var event EventInfo
+	if !bindEvent(c, &event) {
 		return
 	}
 
-	if _, exists := events[event.Name]; exists {
-		c.JSON(http.StatusConflict, gin.H{"message": "Event already exists"})
+	existing, ok := findEvent(event.ID, event.Name)
+	if !ok {
+		respondError(c, http.StatusNotFound, codeEventNotFound, "Event not found")
 		return
 	}
+	event.ID = existing.ID
+	event.Slug = existing.Slug
+	event.CreatedAt = existing.CreatedAt
+	if event.Slug == "" {
+		// Events stored before slugs existed get one on their next update.
+		event.Slug = uniqueSlug(event.Name)
+	}
 
-	var detailsBuilder strings.Builder
-	detailsBuilder.WriteString(event.Name + " ")
-	detailsBuilder.WriteString("Location: " + event.Location + " ")
-	detailsBuilder.WriteString("Details: " + event.Details + " ")
-	for _, link := range event.LinkNames {
-		detailsBuilder.WriteString(link + " ")
+	retranslateAndUpdate(c, existing, event)
+}
+
+// retranslateAndUpdate translates event and replaces the stored copy,
+// existing. Translation happens before touching the store so a failure in any
+// language leaves the previously stored event intact.
+func retranslateAndUpdate(c *gin.Context, existing, event EventInfo) {
+	if !checkTextLength(c, eventSegments(event)) {
+		return
+	}
+	statsFromContext(c.Request.Context()).setEvent(event.Name, len(event.Languages))
+	ctx, cancel := translationContext(c)
+	defer cancel()
+	failures := translateUpdate(ctx, existing, &event)
+	if abortIfCanceled(c) {
+		return
+	}
+	if respondIfDeadlineExceeded(c, ctx, failures, event) {
+		return
+	}
+	if len(failures) > 0 {
+		lang, err := firstFailure(failures)
+		respondTranslationError(c, lang, err)
+		return
 	}
-	detailsBuilder.WriteString(event.SponsoredMessage)
+	event.TranslationErrors = nil
+
+	saveUpdatedEvent(c, event)
+}
 
-	preparedText, placeholderMap := replaceKeywordsWithPlaceholders(detailsBuilder.String(), event.Keywords)
+// translateUpdate translates event, which replaces existing. When only its
+// languages changed, the translations existing already has are kept and only
+// the languages it lacks are translated; any other change that affects the
+// translations retranslates every language.
+func translateUpdate(ctx context.Context, existing EventInfo, event *EventInfo) map[string]error {
+	if !sameTranslationInput(existing, *event) {
+		return translateEvent(ctx, event)
+	}
 
-	endpoint := "https://api.cognitive.microsofttranslator.com"
-	uri := endpoint + "/translate?api-version=3.0"
-	location := "eastus"
-	key := ""
+	partial := *event
+	partial.Languages = nil
+	for _, lang := range event.Languages {
+		if _, ok := existing.Translations[lang]; !ok {
+			partial.Languages = append(partial.Languages, lang)
+		}
+	}
+	if event.DetectLanguage && event.SourceLanguage == "" && existing.DetectedLanguage != "" {
+		// The text is unchanged, so the language detected for it still holds.
+		partial.SourceLanguage, partial.DetectLanguage = existing.DetectedLanguage, false
+	}
 
-	event.Translations = make(map[string]string)
+	// Without missing languages nothing is translated and only the kept
+	// translations are stored.
+	var translated EventInfo
+	failures := make(map[string]error)
+	if len(partial.Languages) > 0 {
+		failures = translateEvent(ctx, &partial)
+		translated = partial
+	}
+	event.Translations = translated.Translations
+	event.Results = translated.Results
+	event.TranslatedLinkNames = translated.TranslatedLinkNames
+	event.TranslatedSponsoredMessage = translated.TranslatedSponsoredMessage
+	event.Alternatives = translated.Alternatives
+	event.Transliterations = translated.Transliterations
+	event.TranslatedKeywords = translated.TranslatedKeywords
+	event.DetectedLanguage, event.DetectionScore = existing.DetectedLanguage, existing.DetectionScore
 	for _, lang := range event.Languages {
-		translatedText, err := translateText(preparedText, lang, uri, key, location)
-		if err != nil {
-			c.JSON(http.StatusInternalServerError, gin.H{"error": fmt.Sprintf("Error translating to %s: %v", lang, err)})
-			return
+		if _, ok := existing.Translations[lang]; ok {
+			copyTranslation(event, existing, lang)
+		}
+	}
+	event.TranslationHash = translationHash(*event)
+	return failures
+}
+
+// copyTranslation copies everything src holds for lang into dst.
+func copyTranslation(dst *EventInfo, src EventInfo, lang string) {
+	if dst.Translations == nil {
+		dst.Translations = make(map[string]string)
+	}
+	dst.Translations[lang] = src.Translations[lang]
+	if result, ok := src.Results[lang]; ok {
+		if dst.Results == nil {
+			dst.Results = make(map[string]TranslationResult)
+		}
+		dst.Results[lang] = result
+	}
+	for key, names := range src.TranslatedLinkNames {
+		if name, ok := names[lang]; ok {
+			if dst.TranslatedLinkNames == nil {
+				dst.TranslatedLinkNames = make(map[string]map[string]string)
+			}
+			if dst.TranslatedLinkNames[key] == nil {
+				dst.TranslatedLinkNames[key] = make(map[string]string)
+			}
+			dst.TranslatedLinkNames[key][lang] = name
+		}
+	}
+	if message, ok := src.TranslatedSponsoredMessage[lang]; ok {
+		if dst.TranslatedSponsoredMessage == nil {
+			dst.TranslatedSponsoredMessage = make(map[string]string)
+		}
+		dst.TranslatedSponsoredMessage[lang] = message
+	}
+	if alternatives, ok := src.Alternatives[lang]; ok {
+		if dst.Alternatives == nil {
+			dst.Alternatives = make(map[string][]string)
+		}
+		dst.Alternatives[lang] = alternatives
+	}
+	if romanized, ok := src.Transliterations[lang]; ok {
+		if dst.Transliterations == nil {
+			dst.Transliterations = make(map[string]string)
+		}
+		dst.Transliterations[lang] = romanized
+	}
+	if keywords, ok := src.TranslatedKeywords[lang]; ok {
+		if dst.TranslatedKeywords == nil {
+			dst.TranslatedKeywords = make(map[string][]string)
+		}
+		dst.TranslatedKeywords[lang] = keywords
+	}
+}
+
+func saveUpdatedEvent(c *gin.Context, event EventInfo) {
+	event.UpdatedAt = time.Now().UTC()
+	if err := events.update(event); err != nil {
+		if errors.Is(err, errEventNotFound) {
+			respondError(c, http.StatusNotFound, codeEventNotFound, "Event not found")
+		} else {
+			respondError(c, http.StatusInternalServerError, codeInternal, err.Error())
 		}
+		return
+	}
+	c.JSON(http.StatusOK, event)
+}
 
-		finalText := replacePlaceholdersWithKeywords(translatedText, placeholderMap)
-		event.Translations[lang] = finalText
+// eventView is an event as GET /event and GET /events return it. With
+// ?translationOrder=name, OrderedTranslations also lists its translations
+// sorted by language display name; Translations is kept for older clients.
+type eventView struct {
+	EventInfo
+	OrderedTranslations []languageTranslation `json:"orderedTranslations,omitempty"`
+}
+
+func newEventView(event EventInfo, byName bool) eventView {
+	view := eventView{EventInfo: event}
+	if byName {
+		view.OrderedTranslations = translationsByName(event.Translations)
 	}
+	return view
+}
 
-	events[event.Name] = event
-	c.JSON(http.StatusCreated, event)
+// translationOrder reports whether the request asked for translations sorted
+// by language name, writing a 400 response and returning false for ok when
+// it asked for an order that does not exist.
+func translationOrder(c *gin.Context) (byName, ok bool) {
+	switch order := c.Query("translationOrder"); order {
+	case "":
+		return false, true
+	case "name":
+		return true, true
+	default:
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, fmt.Sprintf("translationOrder must be name, got %q", order))
+		return false, false
+	}
 }
 
 func getEvent(c *gin.Context) {
-	eventType := c.Query("type")
+	byName, ok := translationOrder(c)
+	if !ok {
+		return
+	}
+	event, ok := queriedEvent(c)
+	if !ok {
+		respondError(c, http.StatusNotFound, codeEventNotFound, "Event not found")
+		return
+	}
+	if c.Query("lang") == "" {
+		c.Writer.Header().Add("Vary", "Accept-Language")
+	}
+	if lang, ok := preferredTranslation(c, event); ok {
+		event = onlyTranslation(event, lang)
+		c.Header("Content-Language", lang)
+	}
 
-	if event, ok := events[eventType]; ok {
-		c.JSON(http.StatusOK, event)
-	} else {
-		c.JSON(http.StatusNotFound, gin.H{"error": "Event not found"})
+	body, err := json.Marshal(newEventView(event, byName))
+	if err != nil {
+		respondError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+	tag := etag(body)
+	c.Header("ETag", tag)
+	if etagMatches(c.GetHeader("If-None-Match"), tag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+	c.Data(http.StatusOK, "application/json; charset=utf-8", body)
+}
+
+// etag is a strong entity tag for a serialized response body.
+func etag(body []byte) string {
+	sum := sha256.Sum256(body)
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// etagMatches reports whether an If-None-Match header lists tag, comparing
+// weakly as RFC 9110 requires for If-None-Match.
+func etagMatches(header, tag string) bool {
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == tag {
+			return true
+		}
+	}
+	return false
+}
+
+const (
+	defaultPageLimit = 50
+	maxPageLimit     = 200
+)
+
+// eventPage is one page of the event listing.
+type eventPage struct {
+	Events []eventView `json:"events"`
+	Total  int         `json:"total"`
+	// NextOffset is the offset of the following page, or nil on the last one.
+	NextOffset *int `json:"nextOffset"`
+}
+
+// listEvents returns stored events ordered by name, filtered by the optional
+// language and keyword query parameters and paginated with limit and offset.
+// limit defaults to defaultPageLimit and is clamped to maxPageLimit.
+func listEvents(c *gin.Context) {
+	language := canonicalLanguage(strings.TrimSpace(c.Query("language")))
+	keyword := c.Query("keyword")
+
+	limit, err := queryInt(c, "limit", defaultPageLimit)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if limit < 1 {
+		limit = 1
+	} else if limit > maxPageLimit {
+		limit = maxPageLimit
+	}
+	offset, err := queryInt(c, "offset", 0)
+	if err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	byName, ok := translationOrder(c)
+	if !ok {
+		return
+	}
+
+	list := make([]EventInfo, 0)
+	for _, event := range events.list() {
+		if language != "" {
+			if _, ok := event.Translations[language]; !ok {
+				continue
+			}
+		}
+		if keyword != "" && !hasKeyword(event, keyword) {
+			continue
+		}
+		list = append(list, event)
+	}
+
+	page := eventPage{Events: []eventView{}, Total: len(list)}
+	if offset < len(list) {
+		end := offset + limit
+		if end < len(list) {
+			page.NextOffset = &end
+		} else {
+			end = len(list)
+		}
+		for _, event := range list[offset:end] {
+			page.Events = append(page.Events, newEventView(event, byName))
+		}
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func hasKeyword(event EventInfo, keyword string) bool {
+	for _, k := range event.Keywords {
+		if strings.EqualFold(k, keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// queryInt parses a non-negative integer query parameter.
+func queryInt(c *gin.Context, name string, fallback int) (int, error) {
+	value := c.Query(name)
+	if value == "" {
+		return fallback, nil
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 0 {
+		return 0, fmt.Errorf("%s must be a non-negative integer", name)
+	}
+	return n, nil
+}
+
+func deleteEvent(c *gin.Context) {
+	event, ok := queriedEvent(c)
+	if !ok {
+		respondError(c, http.StatusNotFound, codeEventNotFound, "Event not found")
+		return
 	}
+
+	switch err := events.delete(event.ID); {
+	case err == nil:
+		c.Status(http.StatusNoContent)
+	case errors.Is(err, errEventNotFound):
+		respondError(c, http.StatusNotFound, codeEventNotFound, "Event not found")
+	default:
+		respondError(c, http.StatusInternalServerError, codeInternal, err.Error())
+	}
+}
+
+func resetEvents(c *gin.Context) {
+	if err := events.reset(); err != nil {
+		respondError(c, http.StatusInternalServerError, codeInternal, err.Error())
+		return
+	}
+	c.Status(http.StatusNoContent)
 }
 
 func main() {
-	r := gin.Default()
-	r.POST("/event", postEvent)
-	r.GET("/event", getEvent)
-	r.Run()
+	var err error
+	config, err = loadConfig()
+	if err != nil {
+		log.Fatalf("error loading config: %v", err)
+	}
+	switch {
+	case config.StoreBackend == "redis":
+		if events, err = newRedisEventStore(config.RedisURL, config.RedisKeyPrefix); err != nil {
+			log.Fatalf("error opening event store: %v", err)
+		}
+	case config.EventsFile != "":
+		if events, err = newPersistentEventStore(newFilePersister(config.EventsFile)); err != nil {
+			log.Fatalf("error loading events: %v", err)
+		}
+	}
+	if batchJobs, err = loadBatchStore(config.BatchesFile, config.BatchRetention); err != nil {
+		log.Fatalf("error loading batches: %v", err)
+	}
+	if config.MetricsEnabled {
+		metrics = newMetrics(prometheus.NewRegistry())
+		metrics.setEventsStored(len(events.list()))
+	}
+	if detailsTemplate, err = parseDetailsTemplate(config.DetailsTemplate); err != nil {
+		log.Fatalf("error loading DETAILS_TEMPLATE: %v", err)
+	}
+	if err := setPlaceholderFormat(config.PlaceholderFormat); err != nil {
+		log.Fatalf("error loading KEYWORD_PLACEHOLDER_FORMAT: %v", err)
+	}
+	if err := loadRewrites(config.RewritesFile); err != nil {
+		log.Fatalf("error loading TRANSLATION_REWRITES_FILE: %v", err)
+	}
+	cache = newTranslationCache(config.CacheMaxEntries)
+	catalog.ttl = config.LanguagesCacheTTL
+	if config.CircuitBreakerThreshold > 0 {
+		breaker = newCircuitBreaker(config.CircuitBreakerThreshold, config.CircuitBreakerCooldown)
+	}
+	if config.CallRate > 0 {
+		pacer = newCallPacer(config.CallRate, config.QueueTimeout)
+	}
+	if config.MaxInflightCalls > 0 {
+		callSlots = newCallLimiter(config.MaxInflightCalls)
+	}
+	httpClient = newHTTPClient(config)
+	callbackClient = newCallbackClient(config)
+	provider, err = newProvider(config, httpClient)
+	if err != nil {
+		log.Fatalf("error creating translation provider: %v", err)
+	}
+
+	logger = newLogger(config.LogLevel, config.LogRedactFields, config.LogRedaction)
+	supportedLanguages = supportedLanguagesFor(config)
+
+	ln, err := net.Listen("tcp", config.ListenAddress())
+	if err != nil {
+		log.Fatalf("error listening: %v", err)
+	}
+	srv := &http.Server{Handler: newRouter()}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	shutdownTracing, err := setupTracing(ctx, config)
+	if err != nil {
+		log.Fatalf("error setting up tracing: %v", err)
+	}
+	resumeBatches()
+	if config.RefreshInterval > 0 {
+		ticker := time.NewTicker(config.RefreshInterval)
+		defer ticker.Stop()
+		go refreshStaleTranslations(ctx, ticker.C)
+	}
+	if err := serve(ctx, srv, ln, config.ShutdownTimeout); err != nil {
+		log.Fatalf("server error: %v", err)
+	}
+	if err := shutdownTracing(context.Background()); err != nil {
+		logger.Error("error flushing traces", "error", err)
+	}
 }
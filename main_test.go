@@ -0,0 +1,486 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"text/template"
+	"time"
+)
+
+func init() {
+	gin.SetMode(gin.TestMode)
+}
+
+// setupTest configures the server the way main does, from the environment
+// plus the given key/value pairs, with the mock provider, an empty event
+// store and nothing logged.
+func setupTest(t *testing.T, env ...string) {
+	t.Helper()
+	t.Setenv("TRANSLATION_PROVIDER", "mock")
+	for i := 0; i+1 < len(env); i += 2 {
+		t.Setenv(env[i], env[i+1])
+	}
+
+	var err error
+	if config, err = loadConfig(); err != nil {
+		t.Fatalf("loadConfig: %v", err)
+	}
+	events = newMemoryEventStore()
+	batchJobs = &batchStore{jobs: make(map[string]*batchJob), retention: config.BatchRetention}
+	metrics = nil
+	detailsTemplate = template.Must(parseDetailsTemplate(config.DetailsTemplate))
+	if err := setPlaceholderFormat(config.PlaceholderFormat); err != nil {
+		t.Fatalf("setPlaceholderFormat: %v", err)
+	}
+	transforms = make(map[string][]translationTransform)
+	if err := loadRewrites(config.RewritesFile); err != nil {
+		t.Fatalf("loadRewrites: %v", err)
+	}
+	cache = newTranslationCache(config.CacheMaxEntries)
+	catalog = &languageCatalog{ttl: config.LanguagesCacheTTL}
+	breaker, pacer, callSlots = nil, nil, nil
+	if config.CircuitBreakerThreshold > 0 {
+		breaker = newCircuitBreaker(config.CircuitBreakerThreshold, config.CircuitBreakerCooldown)
+	}
+	if config.CallRate > 0 {
+		pacer = newCallPacer(config.CallRate, config.QueueTimeout)
+	}
+	if config.MaxInflightCalls > 0 {
+		callSlots = newCallLimiter(config.MaxInflightCalls)
+	}
+	httpClient = newHTTPClient(config)
+	callbackClient = newCallbackClient(config)
+	provider = mockProvider{}
+	logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	supportedLanguages = supportedLanguagesFor(config)
+}
+
+// serveRequest sends a request with an optional JSON body and header
+// key/value pairs through the router.
+func serveRequest(r http.Handler, method, target, body string, header ...string) *httptest.ResponseRecorder {
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, target, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+	return w
+}
+
+// eventBody is the JSON of a valid event with the given name, translated into
+// languages.
+func eventBody(name string, languages ...string) string {
+	return mustJSON(EventInfo{
+		Name:      name,
+		Location:  "Town Hall",
+		Details:   "An evening of music",
+		Languages: languages,
+	})
+}
+
+func mustJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	return string(data)
+}
+
+func decodeJSON(t *testing.T, w *httptest.ResponseRecorder, v interface{}) {
+	t.Helper()
+	if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+}
+
+func TestPostEventConcurrent(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			w := serveRequest(r, "POST", "/event", eventBody(fmt.Sprintf("Concert %d", i), "fr"))
+			if w.Code != http.StatusCreated {
+				t.Errorf("POST /event: status %d, body %s", w.Code, w.Body)
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	if n := len(events.list()); n != 50 {
+		t.Fatalf("stored %d events, want 50", n)
+	}
+}
+
+// createEvent posts body to /event and returns the stored event.
+func createEvent(t *testing.T, r http.Handler, body string) EventInfo {
+	t.Helper()
+	w := serveRequest(r, "POST", "/event", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /event: status %d, body %s", w.Code, w.Body)
+	}
+	var event EventInfo
+	decodeJSON(t, w, &event)
+	return event
+}
+
+func TestDeleteEvent(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	event := createEvent(t, r, eventBody("Concert", "fr"))
+
+	if w := serveRequest(r, "DELETE", eventLocation(event.ID), ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: status %d, want 204", w.Code)
+	}
+	if w := serveRequest(r, "GET", eventLocation(event.ID), ""); w.Code != http.StatusNotFound {
+		t.Fatalf("GET after DELETE: status %d, want 404", w.Code)
+	}
+}
+
+func TestDeleteMissingEvent(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	if w := serveRequest(r, "DELETE", "/event?id=missing", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("DELETE: status %d, want 404", w.Code)
+	}
+	if w := serveRequest(r, "DELETE", "/event?type=Missing", ""); w.Code != http.StatusNotFound {
+		t.Fatalf("DELETE by name: status %d, want 404", w.Code)
+	}
+}
+
+func TestListEventsOrderedByName(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	for _, name := range []string{"Opera", "Ballet", "Concert"} {
+		createEvent(t, r, eventBody(name, "fr"))
+	}
+
+	w := serveRequest(r, "GET", "/events", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /events: status %d", w.Code)
+	}
+	var page eventPage
+	decodeJSON(t, w, &page)
+	var names []string
+	for _, event := range page.Events {
+		names = append(names, event.Name)
+	}
+	if got, want := strings.Join(names, ","), "Ballet,Concert,Opera"; got != want {
+		t.Fatalf("listed %s, want %s", got, want)
+	}
+}
+
+func TestTranslateEventConcurrently(t *testing.T) {
+	setupTest(t, "TRANSLATION_CONCURRENCY", "4")
+	const delay = 200 * time.Millisecond
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		w.Write([]byte(azureTranslation))
+	})
+
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr", "de", "es", "it"}}
+	start := time.Now()
+	if failures := translateEvent(context.Background(), &event); len(failures) > 0 {
+		t.Fatalf("failures: %v", failures)
+	}
+	if elapsed := time.Since(start); elapsed >= 2*delay {
+		t.Fatalf("took %v for 4 languages; want about one %v call, not their sum", elapsed, delay)
+	}
+	if len(event.Translations) != 4 {
+		t.Fatalf("got %d translations, want 4", len(event.Translations))
+	}
+}
+
+func TestPostEventWithFakeProvider(t *testing.T) {
+	setupTest(t)
+	fake := useFakeProvider(nil)
+
+	event := createEvent(t, newRouter(), mustJSON(EventInfo{
+		Name:      "Concert",
+		Location:  "Hall",
+		Details:   "Music",
+		Languages: []string{"fr", "de"},
+	}))
+	for _, lang := range []string{"fr", "de"} {
+		if want := lang + ":" + assembleDetails(event); event.Translations[lang] != want {
+			t.Errorf("translation into %s: got %q, want %q", lang, event.Translations[lang], want)
+		}
+		if fake.callCount(lang) != 1 {
+			t.Errorf("%d calls for %s, want 1", fake.callCount(lang), lang)
+		}
+	}
+}
+
+func TestPostEventPartialFailure(t *testing.T) {
+	setupTest(t, "TRANSLATOR_MAX_RETRIES", "0")
+	useFakeProvider(func(text, from, to string) (string, error) {
+		if to == "de" {
+			return "", errors.New("German is down")
+		}
+		return to + ":" + text, nil
+	})
+
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "fr", "de", "es"))
+	if w.Code != http.StatusMultiStatus {
+		t.Fatalf("status %d, want 207: %s", w.Code, w.Body)
+	}
+	var event EventInfo
+	decodeJSON(t, w, &event)
+	if len(event.Translations) != 2 || event.Translations["fr"] == "" || event.Translations["es"] == "" {
+		t.Errorf("translations %v, want fr and es", event.Translations)
+	}
+	if len(event.TranslationErrors) != 1 || !strings.Contains(event.TranslationErrors["de"], "German is down") {
+		t.Errorf("translation errors %v, want only de", event.TranslationErrors)
+	}
+	if stored, ok := events.get(event.ID); !ok || len(stored.Translations) != 2 {
+		t.Errorf("stored %+v, want the event with its two translations", stored)
+	}
+}
+
+func TestPostEventAllLanguagesFail(t *testing.T) {
+	setupTest(t, "TRANSLATOR_MAX_RETRIES", "0")
+	useFakeProvider(func(text, from, to string) (string, error) {
+		return "", errors.New("down")
+	})
+
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "fr", "de"))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("status %d, want 500: %s", w.Code, w.Body)
+	}
+	if n := len(events.list()); n != 0 {
+		t.Fatalf("stored %d events, want none", n)
+	}
+}
+
+func TestResetEvents(t *testing.T) {
+	setupTest(t, "ADMIN_TOKEN", "secret")
+	r := newRouter()
+	createEvent(t, r, eventBody("Concert", "fr"))
+	createEvent(t, r, eventBody("Opera", "fr"))
+
+	for _, token := range []string{"", "wrong"} {
+		if w := serveRequest(r, "DELETE", "/events", "", "X-Admin-Token", token); w.Code != http.StatusUnauthorized {
+			t.Fatalf("token %q: status %d, want 401", token, w.Code)
+		}
+	}
+	if n := len(events.list()); n != 2 {
+		t.Fatalf("unauthorized resets left %d events, want 2", n)
+	}
+
+	if w := serveRequest(r, "DELETE", "/events", "", "X-Admin-Token", "secret"); w.Code != http.StatusNoContent {
+		t.Fatalf("authorized reset: status %d, want 204", w.Code)
+	}
+	if n := len(events.list()); n != 0 {
+		t.Fatalf("%d events left after reset, want none", n)
+	}
+}
+
+func TestResetEventsWithoutAdminToken(t *testing.T) {
+	setupTest(t)
+	if w := serveRequest(newRouter(), "DELETE", "/events", "", "X-Admin-Token", "anything"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("status %d, want 401 when ADMIN_TOKEN is unset", w.Code)
+	}
+}
+
+func listPage(t *testing.T, r http.Handler, query string) eventPage {
+	t.Helper()
+	w := serveRequest(r, "GET", "/events"+query, "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /events%s: status %d, body %s", query, w.Code, w.Body)
+	}
+	var page eventPage
+	decodeJSON(t, w, &page)
+	return page
+}
+
+func TestListEventsPagination(t *testing.T) {
+	setupTest(t)
+	for i := 0; i < maxPageLimit+5; i++ {
+		events.add(EventInfo{ID: fmt.Sprintf("%03d", i), Name: fmt.Sprintf("Event %03d", i)})
+	}
+	r := newRouter()
+
+	if page := listPage(t, r, "?limit=1000"); len(page.Events) != maxPageLimit || page.NextOffset == nil || *page.NextOffset != maxPageLimit {
+		t.Errorf("limit=1000: %d events, next %v; want %d and %d", len(page.Events), page.NextOffset, maxPageLimit, maxPageLimit)
+	}
+	if page := listPage(t, r, "?limit=0&offset=3"); len(page.Events) != 1 || page.Events[0].ID != "003" {
+		t.Errorf("limit=0: got %d events, want exactly the one at the offset", len(page.Events))
+	}
+	page := listPage(t, r, "?offset=1000")
+	if len(page.Events) != 0 || page.NextOffset != nil || page.Total != maxPageLimit+5 {
+		t.Errorf("offset beyond the end: %d events, next %v, total %d", len(page.Events), page.NextOffset, page.Total)
+	}
+	if w := serveRequest(r, "GET", "/events?limit=-1", ""); w.Code != http.StatusBadRequest {
+		t.Errorf("limit=-1: status %d, want 400", w.Code)
+	}
+}
+
+func TestListEventsFilters(t *testing.T) {
+	setupTest(t)
+	events.add(EventInfo{ID: "1", Name: "Art Walk", Keywords: []string{"Art"}, Translations: map[string]string{"fr": "x"}})
+	events.add(EventInfo{ID: "2", Name: "Jazz Night", Keywords: []string{"Jazz"}, Translations: map[string]string{"de": "x"}})
+	events.add(EventInfo{ID: "3", Name: "Jazz Brunch", Keywords: []string{"jazz"}, Translations: map[string]string{"fr": "x"}})
+	r := newRouter()
+
+	if page := listPage(t, r, "?keyword=JAZZ"); page.Total != 2 || page.Events[0].ID != "3" || page.Events[1].ID != "2" {
+		t.Errorf("keyword=JAZZ: got %+v", page)
+	}
+	if page := listPage(t, r, "?keyword=jazz&language=fr"); page.Total != 1 || page.Events[0].ID != "3" {
+		t.Errorf("keyword=jazz&language=fr: got %+v", page)
+	}
+}
+
+func TestTranslationResultsMetadata(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	fresh := createEvent(t, r, eventBody("Concert", "fr"))
+	cached := createEvent(t, r, eventBody("Concert", "fr"))
+	for _, tt := range []struct {
+		event     EventInfo
+		fromCache bool
+	}{{fresh, false}, {cached, true}} {
+		result, ok := tt.event.Results["fr"]
+		if !ok {
+			t.Fatalf("no fr result in %+v", tt.event.Results)
+		}
+		if result.Text != tt.event.Translations["fr"] || result.Provider != "mock" || result.FromCache != tt.fromCache || result.Direction != "ltr" {
+			t.Errorf("result %+v, want the fr text from mock with fromCache %v", result, tt.fromCache)
+		}
+	}
+}
+
+func TestPostEventLocationHeader(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	body := mustJSON(EventInfo{ID: "spring gala & more", Name: "Spring Gala", Location: "Hall", Details: "Music", Languages: []string{"fr"}})
+
+	w := serveRequest(r, "POST", "/event", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d, want 201: %s", w.Code, w.Body)
+	}
+	location := w.Header().Get("Location")
+	if want := "/event?id=spring+gala+%26+more"; location != want {
+		t.Fatalf("Location %q, want %q", location, want)
+	}
+	var created EventInfo
+	decodeJSON(t, w, &created)
+
+	got := serveRequest(r, "GET", location, "")
+	var fetched EventInfo
+	decodeJSON(t, got, &fetched)
+	if got.Code != http.StatusOK || fetched.ID != created.ID || fetched.Translations["fr"] != created.Translations["fr"] {
+		t.Fatalf("following Location: status %d, event %+v", got.Code, fetched)
+	}
+}
+
+func TestGetEventConditional(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "fr"))
+
+	first := serveRequest(r, "GET", eventLocation(created.ID), "")
+	tag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || tag == "" {
+		t.Fatalf("first fetch: status %d, ETag %q", first.Code, tag)
+	}
+
+	for _, header := range []string{tag, `"other", ` + tag, "W/" + tag, "*"} {
+		w := serveRequest(r, "GET", eventLocation(created.ID), "", "If-None-Match", header)
+		if w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+			t.Errorf("If-None-Match %s: status %d with %d bytes, want an empty 304", header, w.Code, w.Body.Len())
+		}
+	}
+	if w := serveRequest(r, "GET", eventLocation(created.ID), "", "If-None-Match", `"stale"`); w.Code != http.StatusOK {
+		t.Errorf("stale If-None-Match: status %d, want 200", w.Code)
+	}
+}
+
+func TestEventsWithSameName(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	first := createEvent(t, r, mustJSON(EventInfo{ID: "gala-2023", Name: "Gala", Location: "Hall", Details: "Last year", Languages: []string{"fr"}}))
+	second := createEvent(t, r, mustJSON(EventInfo{ID: "gala-2024", Name: "Gala", Location: "Hall", Details: "This year", Languages: []string{"fr"}}))
+
+	for _, want := range []EventInfo{first, second} {
+		w := serveRequest(r, "GET", eventLocation(want.ID), "")
+		var got EventInfo
+		decodeJSON(t, w, &got)
+		if got.ID != want.ID || got.Details != want.Details {
+			t.Errorf("fetching %s got %s with details %q", want.ID, got.ID, got.Details)
+		}
+	}
+	if w := serveRequest(r, "POST", "/event", mustJSON(EventInfo{ID: "gala-2024", Name: "Other", Location: "Hall", Details: "x", Languages: []string{"fr"}})); w.Code != http.StatusConflict {
+		t.Errorf("reusing an ID: status %d, want 409", w.Code)
+	}
+}
+
+func TestEventTimestamps(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	before := time.Now().UTC()
+	created := createEvent(t, r, eventBody("Concert", "fr"))
+	if created.CreatedAt.Before(before) || created.CreatedAt.After(time.Now()) || !created.UpdatedAt.Equal(created.CreatedAt) {
+		t.Fatalf("createdAt %v, updatedAt %v, want both set to the creation time", created.CreatedAt, created.UpdatedAt)
+	}
+
+	time.Sleep(2 * time.Millisecond)
+	created.Details = "A new programme"
+	updated := putEvent(t, r, created)
+	if !updated.CreatedAt.Equal(created.CreatedAt) || !updated.UpdatedAt.After(created.UpdatedAt) {
+		t.Errorf("after update createdAt %v, updatedAt %v, want createdAt kept and updatedAt advanced", updated.CreatedAt, updated.UpdatedAt)
+	}
+
+	var fetched EventInfo
+	decodeJSON(t, serveRequest(r, "GET", "/event?id="+created.ID, ""), &fetched)
+	var page eventPage
+	decodeJSON(t, serveRequest(r, "GET", "/events", ""), &page)
+	if len(page.Events) != 1 {
+		t.Fatalf("listed %d events, want 1", len(page.Events))
+	}
+	for _, got := range []EventInfo{fetched, page.Events[0].EventInfo} {
+		if !got.CreatedAt.Equal(updated.CreatedAt) || !got.UpdatedAt.Equal(updated.UpdatedAt) {
+			t.Errorf("returned createdAt %v, updatedAt %v, want %v, %v", got.CreatedAt, got.UpdatedAt, updated.CreatedAt, updated.UpdatedAt)
+		}
+	}
+}
+
+func TestClientTimestampsIgnored(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	past := time.Date(2000, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr"}, CreatedAt: past, UpdatedAt: past}
+	created := createEvent(t, r, mustJSON(event))
+	if created.CreatedAt.Equal(past) || created.UpdatedAt.Equal(past) {
+		t.Fatalf("created with client timestamps %v, %v", created.CreatedAt, created.UpdatedAt)
+	}
+
+	created.CreatedAt, created.UpdatedAt = past, past
+	updated := putEvent(t, r, created)
+	if updated.CreatedAt.Equal(past) || updated.UpdatedAt.Equal(past) {
+		t.Errorf("updated with client timestamps %v, %v", updated.CreatedAt, updated.UpdatedAt)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"strconv"
+	"time"
+)
+
+// translatorMetrics holds the Prometheus collectors for the service. A nil
+// *translatorMetrics is valid and records nothing, which is what the service
+// uses when metrics are disabled.
+type translatorMetrics struct {
+	gatherer prometheus.Gatherer
+
+	translationCalls    *prometheus.CounterVec
+	translationFailures *prometheus.CounterVec
+	translationDuration *prometheus.HistogramVec
+	cacheHits           prometheus.Counter
+	cacheMisses         prometheus.Counter
+	eventsStored        prometheus.Gauge
+}
+
+func newMetrics(reg *prometheus.Registry) *translatorMetrics {
+	m := &translatorMetrics{
+		gatherer: reg,
+		translationCalls: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "translator_translation_calls_total",
+			Help: "Translation API calls made, by target language.",
+		}, []string{"language"}),
+		translationFailures: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "translator_translation_failures_total",
+			Help: "Failed translation API calls, by provider error code.",
+		}, []string{"code"}),
+		translationDuration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "translator_translation_duration_seconds",
+			Help:    "Duration of translation API calls, by target language.",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"language"}),
+		cacheHits: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "translator_cache_hits_total",
+			Help: "Translations served from the cache.",
+		}),
+		cacheMisses: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "translator_cache_misses_total",
+			Help: "Translations not found in the cache.",
+		}),
+		eventsStored: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "translator_events_stored",
+			Help: "Number of events currently stored.",
+		}),
+	}
+
+	reg.MustRegister(
+		m.translationCalls,
+		m.translationFailures,
+		m.translationDuration,
+		m.cacheHits,
+		m.cacheMisses,
+		m.eventsStored,
+	)
+	return m
+}
+
+func (m *translatorMetrics) observeCall(language string, d time.Duration, err error) {
+	if m == nil {
+		return
+	}
+	m.translationCalls.WithLabelValues(language).Inc()
+	m.translationDuration.WithLabelValues(language).Observe(d.Seconds())
+	if err != nil {
+		m.translationFailures.WithLabelValues(failureCode(err)).Inc()
+	}
+}
+
+func (m *translatorMetrics) observeCache(hit bool) {
+	if m == nil {
+		return
+	}
+	if hit {
+		m.cacheHits.Inc()
+	} else {
+		m.cacheMisses.Inc()
+	}
+}
+
+func (m *translatorMetrics) setEventsStored(n int) {
+	if m == nil {
+		return
+	}
+	m.eventsStored.Set(float64(n))
+}
+
+func (m *translatorMetrics) handler() gin.HandlerFunc {
+	return gin.WrapH(promhttp.HandlerFor(m.gatherer, promhttp.HandlerOpts{}))
+}
+
+// failureCode labels a failed call with the provider's error code when there
+// is one, falling back to the HTTP status or a generic "error".
+func failureCode(err error) string {
+	var upstreamErr providerError
+	if errors.As(err, &upstreamErr) {
+		if code := upstreamErr.ErrorCode(); code != 0 {
+			return strconv.Itoa(code)
+		}
+		return strconv.Itoa(upstreamErr.HTTPStatus())
+	}
+	return "error"
+}
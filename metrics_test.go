@@ -0,0 +1,39 @@
+package main
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestMetricsCountTranslations(t *testing.T) {
+	setupTest(t, "METRICS_ENABLED", "true")
+	metrics = newMetrics(prometheus.NewRegistry())
+	r := newRouter()
+
+	createEvent(t, r, eventBody("Concert", "fr", "de"))
+	createEvent(t, r, eventBody("Concert", "fr"))
+
+	if n := testutil.ToFloat64(metrics.translationCalls.WithLabelValues("fr")); n != 1 {
+		t.Errorf("fr calls = %v, want 1: the second event is served from the cache", n)
+	}
+	if n := testutil.ToFloat64(metrics.translationCalls.WithLabelValues("de")); n != 1 {
+		t.Errorf("de calls = %v, want 1", n)
+	}
+	if n := testutil.ToFloat64(metrics.cacheHits); n != 1 {
+		t.Errorf("cache hits = %v, want 1", n)
+	}
+	if n := testutil.ToFloat64(metrics.cacheMisses); n != 2 {
+		t.Errorf("cache misses = %v, want 2", n)
+	}
+	if n := testutil.ToFloat64(metrics.eventsStored); n != 2 {
+		t.Errorf("events stored = %v, want 2", n)
+	}
+
+	w := serveRequest(r, "GET", "/metrics", "")
+	if w.Code != http.StatusOK || !strings.Contains(w.Body.String(), "translator_translation_calls_total") {
+		t.Fatalf("GET /metrics: status %d, body %s", w.Code, w.Body)
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+)
+
+// mockProvider is an offline provider for local development. It "translates"
+// by prefixing the text with the target language, e.g. "[fr] text".
+type mockProvider struct{}
+
+// Translate leaves HTML markup intact since it never alters the text.
+func (mockProvider) Translate(ctx context.Context, text, from, to string, opts TranslateOptions) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return "[" + to + "] " + text, nil
+}
+
+func (mockProvider) Transliterate(ctx context.Context, text, language, fromScript, toScript string) (string, error) {
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	return "[" + toScript + "] " + text, nil
+}
+
+// Detect always reports English with full confidence.
+func (mockProvider) Detect(ctx context.Context, text string) (string, float64, error) {
+	if err := ctx.Err(); err != nil {
+		return "", 0, err
+	}
+	return "en", 1, nil
+}
+
+// Languages lists a few languages so wildcard requests can be tried
+// offline.
+func (mockProvider) Languages(ctx context.Context) ([]languageInfo, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return []languageInfo{
+		{Code: "ar", Name: "Arabic", Dir: "rtl"},
+		{Code: "de", Name: "German", Dir: "ltr"},
+		{Code: "en", Name: "English", Dir: "ltr"},
+		{Code: "es", Name: "Spanish", Dir: "ltr"},
+		{Code: "fr", Name: "French", Dir: "ltr"},
+		{Code: "ja", Name: "Japanese", Dir: "ltr"},
+	}, nil
+}
@@ -0,0 +1,20 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestPostEventWithMockProvider(t *testing.T) {
+	setupTest(t)
+
+	event := createEvent(t, newRouter(), eventBody("Concert", "fr", "ja"))
+	if len(event.Translations) != 2 {
+		t.Fatalf("got translations %v, want fr and ja", event.Translations)
+	}
+	for _, lang := range []string{"fr", "ja"} {
+		if text := event.Translations[lang]; !strings.HasPrefix(text, "["+lang+"] ") {
+			t.Errorf("translation into %s is %q, want the mock prefix", lang, text)
+		}
+	}
+}
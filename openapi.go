@@ -0,0 +1,389 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// conflictBody and deadlineBody are the shapes of error responses that carry
+// more than the error, only used to describe them in the OpenAPI document.
+type conflictBody struct {
+	Error apiError `json:"error"`
+	// Event is the event already stored under the ID.
+	Event EventInfo `json:"event"`
+}
+
+type deadlineBody struct {
+	Error apiError `json:"error"`
+	// Partial is the event or translate response with what was translated
+	// before the deadline.
+	Partial interface{} `json:"partial"`
+}
+
+type bulkResponse struct {
+	Results []bulkResult `json:"results"`
+}
+
+type languagesBody struct {
+	Languages []languageInfo `json:"languages"`
+}
+
+type healthBody struct {
+	Status string `json:"status"`
+	// Circuit is the circuit breaker's state: "closed", "open" or
+	// "half-open".
+	Circuit string `json:"circuit"`
+}
+
+// apiParameter is a query parameter or header of an operation.
+type apiParameter struct {
+	name, in, description string
+}
+
+// apiResponse is one documented response of an operation. A nil body
+// describes a response without content.
+type apiResponse struct {
+	status      int
+	description string
+	body        interface{}
+}
+
+// apiOperation describes one route. The schemas of its request and response
+// bodies are derived from the Go types the handlers bind and write, so they
+// follow the structs as fields are added.
+type apiOperation struct {
+	method, path, summary string
+	parameters            []apiParameter
+	body                  interface{}
+	responses             []apiResponse
+}
+
+var (
+	idParameters = []apiParameter{
+		{"id", "query", "ID of the event."},
+		{"type", "query", "Name or slug of the event, for clients that predate IDs."},
+	}
+	badRequest    = apiResponse{http.StatusBadRequest, "Invalid request body", errorResponse{}}
+	notFound      = apiResponse{http.StatusNotFound, "Event not found", errorResponse{}}
+	tooLong       = apiResponse{http.StatusRequestEntityTooLarge, "Text to translate is too long", errorResponse{}}
+	translateFail = apiResponse{http.StatusBadGateway, "Translation failed", errorResponse{}}
+	deadline      = apiResponse{http.StatusGatewayTimeout, "Translation deadline exceeded", deadlineBody{}}
+)
+
+var apiOperations = []apiOperation{
+	{
+		method: "post", path: "/event", summary: "Create and translate an event",
+		parameters: []apiParameter{
+			{"dryRun", "query", "With true, return what would be sent to the provider without translating or storing."},
+			{"stats", "query", "With true, include the translation statistics of the request."},
+			{"debug", "query", "With true, include the prepared text and placeholders sent to the provider; never stored."},
+			{"Idempotency-Key", "header", "Replays the first response to requests with the same key."},
+		},
+		body: EventInfo{},
+		responses: []apiResponse{
+			{http.StatusCreated, "Event created", EventInfo{}},
+			{http.StatusAccepted, "Event accepted; it is posted to callbackUrl once translated", EventInfo{}},
+			{http.StatusMultiStatus, "Event created, but some languages failed", EventInfo{}},
+			{http.StatusOK, "Preview of a dry run", eventPreview{}},
+			badRequest,
+			{http.StatusConflict, "Event already exists", conflictBody{}},
+			tooLong,
+			translateFail,
+			deadline,
+		},
+	},
+	{
+		method: "get", path: "/event", summary: "Fetch an event",
+		parameters: append(idParameters,
+			apiParameter{"If-None-Match", "header", "ETag of a previously fetched copy."},
+			apiParameter{"translationOrder", "query", "With name, also list the translations sorted by language name."},
+			apiParameter{"lang", "query", "Only return the translation best matching this language."},
+			apiParameter{"Accept-Language", "header", "Only return the translation best matching these languages, without lang."}),
+		responses: []apiResponse{
+			{http.StatusOK, "The event", eventView{}},
+			{http.StatusNotModified, "The event is unchanged", nil},
+			notFound,
+		},
+	},
+	{
+		method: "put", path: "/event", summary: "Replace and retranslate an event",
+		parameters: idParameters,
+		body:       EventInfo{},
+		responses: []apiResponse{
+			{http.StatusOK, "Event updated", EventInfo{}},
+			badRequest,
+			notFound,
+			tooLong,
+			translateFail,
+			deadline,
+		},
+	},
+	{
+		method: "patch", path: "/event", summary: "Update some fields of an event",
+		body: eventPatch{},
+		responses: []apiResponse{
+			{http.StatusOK, "Event updated", EventInfo{}},
+			badRequest,
+			notFound,
+			tooLong,
+			translateFail,
+			deadline,
+		},
+	},
+	{
+		method: "delete", path: "/event", summary: "Delete an event",
+		parameters: idParameters,
+		responses: []apiResponse{
+			{http.StatusNoContent, "Event deleted", nil},
+			notFound,
+		},
+	},
+	{
+		method: "get", path: "/events", summary: "List events",
+		parameters: []apiParameter{
+			{"language", "query", "Only events translated into this language."},
+			{"keyword", "query", "Only events with this keyword."},
+			{"limit", "query", "Page size."},
+			{"translationOrder", "query", "With name, also list each event's translations sorted by language name."},
+			{"offset", "query", "Index of the first event of the page."},
+		},
+		responses: []apiResponse{
+			{http.StatusOK, "A page of events", eventPage{}},
+			{http.StatusBadRequest, "Invalid query parameter", errorResponse{}},
+		},
+	},
+	{
+		method: "post", path: "/events", summary: "Create several events",
+		body: []EventInfo{},
+		responses: []apiResponse{
+			{http.StatusCreated, "Every event was created", bulkResponse{}},
+			{http.StatusMultiStatus, "The result of each event", bulkResponse{}},
+			{http.StatusBadRequest, "Invalid request body", errorResponse{}},
+		},
+	},
+	{
+		method: "post", path: "/batch", summary: "Create many events in the background",
+		body: []EventInfo{},
+		responses: []apiResponse{
+			{http.StatusAccepted, "Batch accepted; poll its Location for progress", batchProgress{}},
+			{http.StatusBadRequest, "Invalid request body", errorResponse{}},
+		},
+	},
+	{
+		method: "get", path: "/batch/{id}", summary: "Poll the progress of a batch",
+		parameters: []apiParameter{{"id", "path", "ID of the batch."}},
+		responses: []apiResponse{
+			{http.StatusOK, "The batch's progress and the results known so far", batchProgress{}},
+			{http.StatusNotFound, "Batch not found", errorResponse{}},
+		},
+	},
+	{
+		method: "delete", path: "/events", summary: "Delete every event",
+		parameters: []apiParameter{{"X-Admin-Token", "header", "The configured admin token."}},
+		responses: []apiResponse{
+			{http.StatusNoContent, "Events deleted", nil},
+			{http.StatusUnauthorized, "Invalid admin token", errorResponse{}},
+		},
+	},
+	{
+		method: "post", path: "/translate", summary: "Translate text without storing it",
+		parameters: []apiParameter{
+			{"debug", "query", "With true, include the prepared text and placeholders sent to the provider."},
+		},
+		body: translateRequest{},
+		responses: []apiResponse{
+			{http.StatusOK, "The translations", translateResponse{}},
+			{http.StatusMultiStatus, "Some languages failed", translateResponse{}},
+			badRequest,
+			tooLong,
+			deadline,
+		},
+	},
+	{
+		method: "post", path: "/translate/stream", summary: "Translate text, streaming each language as it finishes",
+		body: translateRequest{},
+		responses: []apiResponse{
+			{http.StatusOK, "A text/event-stream of a progress event per language, then a done event", nil},
+			badRequest,
+			tooLong,
+		},
+	},
+	{
+		method: "get", path: "/languages", summary: "List the languages events may be translated into",
+		responses: []apiResponse{
+			{http.StatusOK, "The provider's languages", languagesBody{}},
+			{http.StatusBadGateway, "The provider could not list its languages", errorResponse{}},
+		},
+	},
+	{
+		method: "get", path: "/healthz", summary: "Check the service is up",
+		parameters: []apiParameter{{"deep", "query", "With true, also make a small translation."}},
+		responses: []apiResponse{
+			{http.StatusOK, "The service is up", healthBody{}},
+			{http.StatusServiceUnavailable, "The provider is unusable", errorResponse{}},
+		},
+	},
+}
+
+// openAPIDocument builds the OpenAPI 3 description of apiOperations, with a
+// component schema for every struct type they use.
+func openAPIDocument() map[string]interface{} {
+	schemas := make(map[string]interface{})
+	paths := make(map[string]map[string]interface{})
+	for _, op := range apiOperations {
+		operation := map[string]interface{}{"summary": op.summary}
+
+		var parameters []interface{}
+		for _, p := range op.parameters {
+			parameters = append(parameters, map[string]interface{}{
+				"name":        p.name,
+				"in":          p.in,
+				"description": p.description,
+				"schema":      map[string]interface{}{"type": "string"},
+			})
+		}
+		if parameters != nil {
+			operation["parameters"] = parameters
+		}
+		if op.body != nil {
+			operation["requestBody"] = map[string]interface{}{
+				"required": true,
+				"content":  jsonContent(reflect.TypeOf(op.body), schemas),
+			}
+		}
+
+		responses := make(map[string]interface{}, len(op.responses))
+		for _, r := range op.responses {
+			response := map[string]interface{}{"description": r.description}
+			if r.body != nil {
+				response["content"] = jsonContent(reflect.TypeOf(r.body), schemas)
+			}
+			responses[strconv.Itoa(r.status)] = response
+		}
+		operation["responses"] = responses
+
+		if paths[op.path] == nil {
+			paths[op.path] = make(map[string]interface{})
+		}
+		paths[op.path][op.method] = operation
+	}
+
+	return map[string]interface{}{
+		"openapi": "3.0.3",
+		"info": map[string]interface{}{
+			"title":   "CustomTranslator",
+			"version": "1.0.0",
+		},
+		"paths":      paths,
+		"components": map[string]interface{}{"schemas": schemas},
+	}
+}
+
+func jsonContent(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"application/json": map[string]interface{}{"schema": schemaFor(t, schemas)},
+	}
+}
+
+// schemaFor describes the JSON encoding of t. Named structs are added to
+// schemas once and referred to by name.
+func schemaFor(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaFor(t.Elem(), schemas)
+	case reflect.String:
+		return map[string]interface{}{"type": "string"}
+	case reflect.Bool:
+		return map[string]interface{}{"type": "boolean"}
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return map[string]interface{}{"type": "integer"}
+	case reflect.Float32, reflect.Float64:
+		return map[string]interface{}{"type": "number"}
+	case reflect.Slice, reflect.Array:
+		return map[string]interface{}{"type": "array", "items": schemaFor(t.Elem(), schemas)}
+	case reflect.Map:
+		return map[string]interface{}{"type": "object", "additionalProperties": schemaFor(t.Elem(), schemas)}
+	case reflect.Struct:
+		if t == reflect.TypeOf(time.Time{}) {
+			return map[string]interface{}{"type": "string", "format": "date-time"}
+		}
+		ref := map[string]interface{}{"$ref": "#/components/schemas/" + t.Name()}
+		if _, ok := schemas[t.Name()]; ok {
+			return ref
+		}
+		// Registered before the fields are walked so recursive types end.
+		schemas[t.Name()] = nil
+		schemas[t.Name()] = structSchema(t, schemas)
+		return ref
+	default:
+		return map[string]interface{}{}
+	}
+}
+
+func structSchema(t reflect.Type, schemas map[string]interface{}) map[string]interface{} {
+	properties := make(map[string]interface{})
+	var required []string
+	addStructFields(t, properties, &required, schemas)
+
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		sort.Strings(required)
+		schema["required"] = required
+	}
+	return schema
+}
+
+// addStructFields adds the fields of t to properties, inlining embedded
+// structs the way encoding/json does.
+func addStructFields(t reflect.Type, properties map[string]interface{}, required *[]string, schemas map[string]interface{}) {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.Anonymous && field.Type.Kind() == reflect.Struct {
+			addStructFields(field.Type, properties, required, schemas)
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		name := jsonFieldName(field)
+		if name == "" {
+			continue
+		}
+
+		property := schemaFor(field.Type, schemas)
+		for _, rule := range strings.Split(field.Tag.Get("validate"), ",") {
+			if rule == "dive" {
+				// Later rules apply to elements, not the field itself.
+				break
+			}
+			if rule == "required" {
+				*required = append(*required, name)
+			}
+			if values, ok := strings.CutPrefix(rule, "oneof="); ok {
+				property = map[string]interface{}{"type": "string", "enum": strings.Fields(values)}
+			}
+		}
+		properties[name] = property
+	}
+}
+
+var (
+	openAPIOnce sync.Once
+	openAPIJSON []byte
+)
+
+// serveOpenAPI serves the OpenAPI document, built on first use.
+func serveOpenAPI(c *gin.Context) {
+	openAPIOnce.Do(func() {
+		openAPIJSON, _ = json.Marshal(openAPIDocument())
+	})
+	c.Data(http.StatusOK, "application/json; charset=utf-8", openAPIJSON)
+}
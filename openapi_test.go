@@ -0,0 +1,90 @@
+package main
+
+import (
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func fetchOpenAPI(t *testing.T) map[string]interface{} {
+	t.Helper()
+	w := serveRequest(newRouter(), "GET", "/openapi.json", "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json: status %d", w.Code)
+	}
+	var doc map[string]interface{}
+	decodeJSON(t, w, &doc)
+	return doc
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	setupTest(t)
+	doc := fetchOpenAPI(t)
+
+	if version, _ := doc["openapi"].(string); !strings.HasPrefix(version, "3.") {
+		t.Fatalf("openapi = %v, want 3.x", doc["openapi"])
+	}
+	paths, _ := doc["paths"].(map[string]interface{})
+	event, _ := paths["/event"].(map[string]interface{})
+	for _, method := range []string{"get", "post", "put", "patch", "delete"} {
+		if _, ok := event[method]; !ok {
+			t.Errorf("/event has no %s operation", method)
+		}
+	}
+
+	components, _ := doc["components"].(map[string]interface{})
+	schemas, _ := components["schemas"].(map[string]interface{})
+	eventInfo, _ := schemas["EventInfo"].(map[string]interface{})
+	properties, _ := eventInfo["properties"].(map[string]interface{})
+	for _, name := range []string{"id", "name", "location", "details", "languages", "translations"} {
+		if _, ok := properties[name]; !ok {
+			t.Errorf("EventInfo schema has no %q property", name)
+		}
+	}
+	required, _ := eventInfo["required"].([]interface{})
+	if len(required) == 0 {
+		t.Errorf("EventInfo schema has no required properties")
+	}
+	if _, ok := schemas["errorResponse"]; !ok {
+		t.Errorf("no errorResponse schema")
+	}
+
+	// Every reference resolves to a schema.
+	var check func(v interface{})
+	check = func(v interface{}) {
+		switch v := v.(type) {
+		case map[string]interface{}:
+			if ref, ok := v["$ref"].(string); ok {
+				if _, ok := schemas[strings.TrimPrefix(ref, "#/components/schemas/")]; !ok {
+					t.Errorf("unresolved reference %s", ref)
+				}
+			}
+			for _, child := range v {
+				check(child)
+			}
+		case []interface{}:
+			for _, child := range v {
+				check(child)
+			}
+		}
+	}
+	check(doc)
+}
+
+func TestOpenAPIDescribesEveryRoute(t *testing.T) {
+	setupTest(t)
+	paths, _ := fetchOpenAPI(t)["paths"].(map[string]interface{})
+	param := regexp.MustCompile(`:(\w+)`)
+
+	for _, route := range newRouter().Routes() {
+		if route.Path == "/openapi.json" {
+			continue
+		}
+		path := param.ReplaceAllString(route.Path, "{$1}")
+		operations, _ := paths[path].(map[string]interface{})
+		if _, ok := operations[strings.ToLower(route.Method)]; !ok {
+			t.Errorf("%s %s is not documented", route.Method, path)
+		}
+	}
+}
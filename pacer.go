@@ -0,0 +1,57 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"golang.org/x/time/rate"
+	"time"
+)
+
+// errQueueTimeout is returned instead of calling the provider when the call
+// would have to wait longer than config.QueueTimeout for its turn.
+var errQueueTimeout = errors.New("translation provider busy, too many calls queued")
+
+// callPacer spaces provider calls evenly at a sustained rate, so a burst of
+// requests is smoothed out instead of running into the provider's rate limit.
+// Calls wait their turn up to maxWait. A nil pacer lets every call through at
+// once.
+type callPacer struct {
+	limiter *rate.Limiter
+	maxWait time.Duration
+}
+
+func newCallPacer(callsPerSecond float64, maxWait time.Duration) *callPacer {
+	// A burst of one makes the bucket leak at a steady rate.
+	return &callPacer{limiter: rate.NewLimiter(rate.Limit(callsPerSecond), 1), maxWait: maxWait}
+}
+
+// pacer is nil when TRANSLATOR_CALL_RATE is 0.
+var pacer *callPacer
+
+// wait blocks until the call's turn comes. It fails at once with
+// errQueueTimeout when that is more than maxWait away, and gives up its turn
+// when ctx is done first.
+func (p *callPacer) wait(ctx context.Context) error {
+	if p == nil {
+		return nil
+	}
+	reservation := p.limiter.Reserve()
+	delay := reservation.Delay()
+	if delay > p.maxWait {
+		reservation.Cancel()
+		return errQueueTimeout
+	}
+	if delay == 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		reservation.Cancel()
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestCallPacerSpacesCalls(t *testing.T) {
+	p := newCallPacer(50, time.Second)
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		if err := p.wait(context.Background()); err != nil {
+			t.Fatal(err)
+		}
+	}
+	// The first call goes at once and each later one 20ms after the last.
+	if elapsed := time.Since(start); elapsed < 70*time.Millisecond {
+		t.Fatalf("5 calls at 50/s took %v, want at least 80ms", elapsed)
+	}
+}
+
+func TestCallPacerQueueTimeout(t *testing.T) {
+	p := newCallPacer(1, 100*time.Millisecond)
+	if err := p.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	start := time.Now()
+	if err := p.wait(context.Background()); err != errQueueTimeout {
+		t.Fatalf("second call: got %v, want %v", err, errQueueTimeout)
+	}
+	if elapsed := time.Since(start); elapsed > 50*time.Millisecond {
+		t.Errorf("refused call waited %v, want it refused at once", elapsed)
+	}
+}
+
+func TestCallPacerCanceled(t *testing.T) {
+	p := newCallPacer(1, time.Minute)
+	if err := p.wait(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := p.wait(ctx); err != context.DeadlineExceeded {
+		t.Fatalf("canceled wait: got %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestBurstQueuedBehindCallRate(t *testing.T) {
+	setupTest(t, "TRANSLATOR_CALL_RATE", "100", "TRANSLATOR_QUEUE_TIMEOUT", "5s")
+	var mu sync.Mutex
+	var calls []time.Time
+	useFakeProvider(func(text, from, to string) (string, error) {
+		mu.Lock()
+		calls = append(calls, time.Now())
+		mu.Unlock()
+		return to + ":" + text, nil
+	})
+	r := newRouter()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			body := fmt.Sprintf(`{"text":"Hello %d","to":["fr","de"]}`, i)
+			if w := serveRequest(r, "POST", "/translate", body); w.Code != http.StatusOK {
+				t.Errorf("POST /translate: status %d, body %s", w.Code, w.Body)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if len(calls) != 10 {
+		t.Fatalf("made %d calls, want 10", len(calls))
+	}
+	if span := calls[9].Sub(calls[0]); span < 80*time.Millisecond {
+		t.Errorf("10 calls made within %v, want them spaced 10ms apart", span)
+	}
+}
+
+func TestQueueTimeoutResponse(t *testing.T) {
+	setupTest(t, "TRANSLATOR_CALL_RATE", "0.1", "TRANSLATOR_QUEUE_TIMEOUT", "10ms", "TRANSLATOR_MAX_RETRIES", "0")
+	useFakeProvider(nil)
+	r := newRouter()
+
+	serveRequest(r, "POST", "/event", eventBody("Concert", "fr"))
+	w := serveRequest(r, "POST", "/event", eventBody("Gala", "fr"))
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if w.Code != http.StatusServiceUnavailable || res.Error.Code != codeQueueTimeout {
+		t.Fatalf("status %d, code %q, want %d %q", w.Code, res.Error.Code, http.StatusServiceUnavailable, codeQueueTimeout)
+	}
+}
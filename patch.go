@@ -0,0 +1,191 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"net/http"
+	"reflect"
+)
+
+// eventPatch is a partial EventInfo: nil fields are left unchanged. ID
+// selects the event to patch, and a Name given with it renames the event.
+// Without an ID, Name selects the event instead.
+type eventPatch struct {
+	ID               string             `json:"id"`
+	Name             string             `json:"name" validate:"required_without=ID"`
+	Location         *string            `json:"location"`
+	Details          *string            `json:"details"`
+	LinkNames        *map[string]string `json:"linkNames"`
+	SponsoredMessage *string            `json:"sponsoredMessage"`
+	Languages        *[]string          `json:"languages"`
+	Keywords         *[]string          `json:"keywords"`
+	SourceLanguage   *string            `json:"sourceLanguage"`
+	Transliterate    *bool              `json:"transliterate"`
+	DetectLanguage   *bool              `json:"detectLanguage"`
+
+	Glossary *map[string]map[string]string `json:"glossary"`
+
+	CaseInsensitiveKeywords *bool `json:"caseInsensitiveKeywords"`
+	WholeWordKeywords       *bool `json:"wholeWordKeywords"`
+	PreserveLiterals        *bool `json:"preserveLiterals"`
+
+	TextType        *string `json:"textType"`
+	ProfanityAction *string `json:"profanityAction"`
+	Category        *string `json:"category" validate:"omitempty,azure_category"`
+
+	IncludeAlternatives *bool `json:"includeAlternatives"`
+	VerifyRoundTrip     *bool `json:"verifyRoundTrip"`
+	TranslateKeywords   *bool `json:"translateKeywords"`
+}
+
+func (p eventPatch) apply(event EventInfo) EventInfo {
+	if p.ID != "" && p.Name != "" {
+		event.Name = p.Name
+	}
+	if p.Location != nil {
+		event.Location = *p.Location
+	}
+	if p.Details != nil {
+		event.Details = *p.Details
+	}
+	if p.LinkNames != nil {
+		event.LinkNames = *p.LinkNames
+	}
+	if p.SponsoredMessage != nil {
+		event.SponsoredMessage = *p.SponsoredMessage
+	}
+	if p.Languages != nil {
+		event.Languages = requestedLanguages(*p.Languages)
+	}
+	if p.Keywords != nil {
+		event.Keywords = *p.Keywords
+	}
+	if p.SourceLanguage != nil {
+		event.SourceLanguage = *p.SourceLanguage
+	}
+	if p.Transliterate != nil {
+		event.Transliterate = *p.Transliterate
+	}
+	if p.DetectLanguage != nil {
+		event.DetectLanguage = *p.DetectLanguage
+	}
+	if p.Glossary != nil {
+		event.Glossary = *p.Glossary
+	}
+	if p.CaseInsensitiveKeywords != nil {
+		event.CaseInsensitiveKeywords = *p.CaseInsensitiveKeywords
+	}
+	if p.WholeWordKeywords != nil {
+		event.WholeWordKeywords = *p.WholeWordKeywords
+	}
+	if p.PreserveLiterals != nil {
+		event.PreserveLiterals = *p.PreserveLiterals
+	}
+	if p.TextType != nil {
+		event.TextType = *p.TextType
+	}
+	if p.ProfanityAction != nil {
+		event.ProfanityAction = *p.ProfanityAction
+	}
+	if p.Category != nil {
+		event.Category = *p.Category
+	}
+	if p.IncludeAlternatives != nil {
+		event.IncludeAlternatives = *p.IncludeAlternatives
+	}
+	if p.VerifyRoundTrip != nil {
+		event.VerifyRoundTrip = *p.VerifyRoundTrip
+	}
+	if p.TranslateKeywords != nil {
+		event.TranslateKeywords = *p.TranslateKeywords
+	}
+	return event
+}
+
+// translationInput is the part of an event that determines its translations.
+type translationInput struct {
+	segments       []eventSegment
+	languages      []string
+	keywords       []string
+	glossary       map[string]map[string]string
+	keywordOptions keywordOptions
+	sourceLanguage string
+	detectLanguage bool
+	transliterate  bool
+	options        TranslateOptions
+	alternatives   bool
+	roundTrip      bool
+	translateKeys  bool
+}
+
+func translationInputOf(event EventInfo) translationInput {
+	return translationInput{
+		segments:       eventSegments(event),
+		languages:      event.Languages,
+		keywords:       event.Keywords,
+		glossary:       event.Glossary,
+		keywordOptions: keywordOptionsFor(event),
+		sourceLanguage: event.SourceLanguage,
+		detectLanguage: event.DetectLanguage,
+		transliterate:  event.Transliterate,
+		options:        translateOptionsFor(event),
+		alternatives:   event.IncludeAlternatives,
+		roundTrip:      event.VerifyRoundTrip,
+		translateKeys:  event.TranslateKeywords,
+	}
+}
+
+// needsRetranslation reports whether patching before into after changed
+// anything that affects the translations.
+func needsRetranslation(before, after EventInfo) bool {
+	return !reflect.DeepEqual(translationInputOf(before), translationInputOf(after))
+}
+
+// sameTranslationInput reports whether before and after translate the same
+// text in the same way, whatever languages they translate it into.
+func sameTranslationInput(before, after EventInfo) bool {
+	beforeInput, afterInput := translationInputOf(before), translationInputOf(after)
+	beforeInput.languages, afterInput.languages = nil, nil
+	return reflect.DeepEqual(beforeInput, afterInput)
+}
+
+// patchEvent updates only the fields present in the body, re-translating
+// only when a field that affects the translations changed.
+func patchEvent(c *gin.Context) {
+	var patch eventPatch
+	if err := c.ShouldBindJSON(&patch); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	if err := validate.Struct(patch); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	existing, ok := findEvent(patch.ID, patch.Name)
+	if !ok {
+		respondError(c, http.StatusNotFound, codeEventNotFound, "Event not found")
+		return
+	}
+
+	event := patch.apply(existing)
+	languages, err := expandLanguages(c.Request.Context(), event.Languages, event.SourceLanguage)
+	if err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	event.Languages = languages
+	if event.LinkNames, err = normalizeLinkNames(event.LinkNames); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+	if err := validate.Struct(event); err != nil {
+		respondValidationError(c, err)
+		return
+	}
+
+	if needsRetranslation(existing, event) {
+		retranslateAndUpdate(c, existing, event)
+		return
+	}
+	saveUpdatedEvent(c, event)
+}
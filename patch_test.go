@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestPatchLocationKeepsTranslations(t *testing.T) {
+	// Without the location in the translated text, it does not affect the
+	// translations.
+	setupTest(t, "DETAILS_TEMPLATE", "{{.Name}}: {{.Details}}")
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "fr", "de"))
+	fake := useFakeProvider(nil)
+
+	w := serveRequest(r, "PATCH", "/event", `{"id":"`+created.ID+`","location":"Opera House"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH: status %d, want 200: %s", w.Code, w.Body)
+	}
+	var patched EventInfo
+	decodeJSON(t, w, &patched)
+	if patched.Location != "Opera House" {
+		t.Errorf("location %q, want Opera House", patched.Location)
+	}
+	if n := fake.totalCalls(); n != 0 {
+		t.Errorf("made %d translation calls, want none", n)
+	}
+	for lang, text := range created.Translations {
+		if patched.Translations[lang] != text {
+			t.Errorf("%s translation changed from %q to %q", lang, text, patched.Translations[lang])
+		}
+	}
+	if stored, _ := events.get(created.ID); stored.Location != "Opera House" || stored.Translations["fr"] != created.Translations["fr"] {
+		t.Errorf("stored %+v, want the new location with the old translations", stored)
+	}
+}
+
+func TestPatchDetailsRetranslates(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "fr", "de"))
+	fake := useFakeProvider(nil)
+
+	w := serveRequest(r, "PATCH", "/event", `{"id":"`+created.ID+`","details":"A night of jazz"}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("PATCH: status %d, want 200: %s", w.Code, w.Body)
+	}
+	var patched EventInfo
+	decodeJSON(t, w, &patched)
+	if fake.callCount("fr") != 1 || fake.callCount("de") != 1 {
+		t.Errorf("calls %v, want one per language", fake.calls)
+	}
+	if want := "fr:" + assembleDetails(patched); patched.Translations["fr"] != want {
+		t.Errorf("fr translation %q, want %q", patched.Translations["fr"], want)
+	}
+}
+
+func TestPatchMissingEvent(t *testing.T) {
+	setupTest(t)
+	if w := serveRequest(newRouter(), "PATCH", "/event", `{"id":"missing","location":"Hall"}`); w.Code != http.StatusNotFound {
+		t.Fatalf("status %d, want 404", w.Code)
+	}
+}
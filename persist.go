@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+)
+
+// filePersister keeps the events in a single JSON file.
+type filePersister struct {
+	path string
+}
+
+func newFilePersister(path string) *filePersister {
+	return &filePersister{path: path}
+}
+
+func (p *filePersister) load() (map[string]EventInfo, error) {
+	data, err := ioutil.ReadFile(p.path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading events file: %v", err)
+	}
+
+	var events map[string]EventInfo
+	if err := json.Unmarshal(data, &events); err != nil {
+		return nil, fmt.Errorf("error decoding events file: %v", err)
+	}
+	return events, nil
+}
+
+// save writes to a temporary file in the same directory and renames it over
+// the previous file, so a crash never leaves a half-written events file.
+func (p *filePersister) save(events map[string]EventInfo) error {
+	data, err := json.Marshal(events)
+	if err != nil {
+		return fmt.Errorf("error marshaling events: %v", err)
+	}
+	return replaceFile(p.path, "events", data)
+}
+
+// replaceFile writes data to a temporary file in the same directory as path
+// and renames it over path. what names the file's contents in errors.
+func replaceFile(path, what string, data []byte) error {
+	tmp, err := ioutil.TempFile(filepath.Dir(path), filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("error creating temporary %s file: %v", what, err)
+	}
+	defer os.Remove(tmp.Name())
+
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error writing %s file: %v", what, err)
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return fmt.Errorf("error syncing %s file: %v", what, err)
+	}
+	if err := tmp.Close(); err != nil {
+		return fmt.Errorf("error closing %s file: %v", what, err)
+	}
+
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("error replacing %s file: %v", what, err)
+	}
+	return nil
+}
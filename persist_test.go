@@ -0,0 +1,44 @@
+package main
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+func TestEventsSurviveRestart(t *testing.T) {
+	setupTest(t)
+	path := filepath.Join(t.TempDir(), "events.json")
+	store, err := newPersistentEventStore(newFilePersister(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	events = store
+	r := newRouter()
+	concert := createEvent(t, r, eventBody("Concert", "fr"))
+	opera := createEvent(t, r, eventBody("Opera", "de"))
+
+	// A new store reading the same file stands in for a restarted server.
+	reloaded, err := newPersistentEventStore(newFilePersister(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []EventInfo{concert, opera} {
+		got, ok := reloaded.get(want.ID)
+		if !ok {
+			t.Fatalf("event %q lost on restart", want.Name)
+		}
+		if got.Name != want.Name || len(got.Translations) != 1 || got.Translations[want.Languages[0]] != want.Translations[want.Languages[0]] {
+			t.Fatalf("reloaded %+v, want %+v", got, want)
+		}
+	}
+}
+
+func TestFilePersisterMissingFile(t *testing.T) {
+	store, err := newPersistentEventStore(newFilePersister(filepath.Join(t.TempDir(), "missing.json")))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(store.list()); n != 0 {
+		t.Fatalf("got %d events from a missing file, want none", n)
+	}
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/text/language"
+	"sort"
+)
+
+// preferredTranslation picks the event's translation that best matches the
+// ?lang= parameter or, without one, the Accept-Language header. A preference
+// for a regional variant such as "fr-CA" falls back to the base language's
+// translation, "fr"; weaker matches, such as Traditional for Simplified
+// Chinese, are not taken. It reports false when no preference was given or none
+// of the translations matches.
+func preferredTranslation(c *gin.Context, event EventInfo) (string, bool) {
+	preference := c.Query("lang")
+	if preference == "" {
+		preference = c.GetHeader("Accept-Language")
+	}
+	if preference == "" || len(event.Translations) == 0 {
+		return "", false
+	}
+	if _, ok := event.Translations[canonicalLanguage(preference)]; ok {
+		return canonicalLanguage(preference), true
+	}
+
+	preferred, _, err := language.ParseAcceptLanguage(preference)
+	if err != nil || len(preferred) == 0 {
+		return "", false
+	}
+	codes := make([]string, 0, len(event.Translations))
+	for code := range event.Translations {
+		codes = append(codes, code)
+	}
+	sort.Strings(codes)
+	tags := make([]language.Tag, 0, len(codes))
+	for _, code := range codes {
+		tags = append(tags, language.Make(code))
+	}
+
+	_, index, confidence := language.NewMatcher(tags).Match(preferred...)
+	if confidence < language.High {
+		return "", false
+	}
+	return codes[index], true
+}
+
+// onlyTranslation keeps the translations of event into lang alone, leaving
+// the rest of the event as stored.
+func onlyTranslation(event EventInfo, lang string) EventInfo {
+	event.Translations = map[string]string{lang: event.Translations[lang]}
+	if result, ok := event.Results[lang]; ok {
+		event.Results = map[string]TranslationResult{lang: result}
+	} else {
+		event.Results = nil
+	}
+	event.TranslationErrors = nil
+	event.TranslatedSponsoredMessage = onlyLanguage(event.TranslatedSponsoredMessage, lang)
+	event.Transliterations = onlyLanguage(event.Transliterations, lang)
+	if event.TranslatedLinkNames != nil {
+		names := make(map[string]map[string]string, len(event.TranslatedLinkNames))
+		for link, translated := range event.TranslatedLinkNames {
+			names[link] = onlyLanguage(translated, lang)
+		}
+		event.TranslatedLinkNames = names
+	}
+	if keywords, ok := event.TranslatedKeywords[lang]; ok {
+		event.TranslatedKeywords = map[string][]string{lang: keywords}
+	} else {
+		event.TranslatedKeywords = nil
+	}
+	if alternatives, ok := event.Alternatives[lang]; ok {
+		event.Alternatives = map[string][]string{lang: alternatives}
+	} else {
+		event.Alternatives = nil
+	}
+	return event
+}
+
+func onlyLanguage(texts map[string]string, lang string) map[string]string {
+	text, ok := texts[lang]
+	if !ok {
+		return nil
+	}
+	return map[string]string{lang: text}
+}
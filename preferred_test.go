@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestGetEventPreferredTranslation(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "fr", "de", "zh-Hans"))
+
+	tests := []struct {
+		name   string
+		query  string
+		header string
+		// want is the only translation returned, or "" for all of them.
+		want string
+	}{
+		{name: "exact header", header: "de", want: "de"},
+		{name: "exact param", query: "fr", want: "fr"},
+		{name: "param over header", query: "fr", header: "de", want: "fr"},
+		{name: "weighted header", header: "ja;q=0.9, de;q=0.8, fr;q=0.5", want: "de"},
+		{name: "base language fallback", header: "fr-CA", want: "fr"},
+		{name: "script is not a fallback", header: "zh-Hant"},
+		{name: "no match", header: "ja, ko;q=0.5"},
+		{name: "no preference"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			target := "/event?id=" + created.ID
+			if tt.query != "" {
+				target += "&lang=" + tt.query
+			}
+			var header []string
+			if tt.header != "" {
+				header = []string{"Accept-Language", tt.header}
+			}
+			w := serveRequest(r, "GET", target, "", header...)
+			if w.Code != http.StatusOK {
+				t.Fatalf("status %d, body %s", w.Code, w.Body)
+			}
+			var event EventInfo
+			decodeJSON(t, w, &event)
+
+			if tt.want == "" {
+				if len(event.Translations) != 3 || w.Header().Get("Content-Language") != "" {
+					t.Errorf("translations %v, Content-Language %q, want the full event", event.Translations, w.Header().Get("Content-Language"))
+				}
+				return
+			}
+			if text, ok := event.Translations[tt.want]; len(event.Translations) != 1 || !ok || text != created.Translations[tt.want] {
+				t.Errorf("translations %v, want only %s", event.Translations, tt.want)
+			}
+			if len(event.Results) != 1 {
+				t.Errorf("results %v, want only %s", event.Results, tt.want)
+			}
+			if got := w.Header().Get("Content-Language"); got != tt.want {
+				t.Errorf("Content-Language %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetEventVaryAcceptLanguage(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "fr"))
+
+	w := serveRequest(r, "GET", "/event?id="+created.ID, "", "Accept-Language", "fr")
+	if vary := strings.Join(w.Header().Values("Vary"), ", "); !strings.Contains(vary, "Accept-Language") {
+		t.Errorf("Vary %q, want Accept-Language", vary)
+	}
+	w = serveRequest(r, "GET", "/event?lang=fr&id="+created.ID, "")
+	if vary := strings.Join(w.Header().Values("Vary"), ", "); strings.Contains(vary, "Accept-Language") {
+		t.Errorf("Vary %q with ?lang=, want no Accept-Language", vary)
+	}
+}
@@ -0,0 +1,38 @@
+package main
+
+// previewSegment is a segment as it would be sent to the provider.
+type previewSegment struct {
+	Role string `json:"role"`
+	Text string `json:"text"`
+}
+
+// languagePreview is what would be sent to translate into one language.
+type languagePreview struct {
+	Segments []previewSegment `json:"segments"`
+	// Placeholders maps each placeholder to the text it is restored to.
+	Placeholders map[string]string `json:"placeholders"`
+}
+
+// eventPreview is the response to POST /event?dryRun=true.
+type eventPreview struct {
+	Event     EventInfo                  `json:"event"`
+	Languages map[string]languagePreview `json:"languages"`
+}
+
+// previewEvent prepares every language of event for translation without
+// calling the provider, so clients can check the assembled text and keyword
+// placeholders before spending quota. Placeholders differ per language only
+// when the glossary does.
+func previewEvent(event EventInfo) eventPreview {
+	segments := eventSegments(event)
+	preview := eventPreview{Event: event, Languages: make(map[string]languagePreview, len(event.Languages))}
+	for _, lang := range event.Languages {
+		prepared, placeholderMap := prepareSegments(event, segments, lang)
+		language := languagePreview{Segments: make([]previewSegment, len(segments)), Placeholders: placeholderMap}
+		for i, segment := range segments {
+			language.Segments[i] = previewSegment{Role: segment.role, Text: prepared[i]}
+		}
+		preview.Languages[lang] = language
+	}
+	return preview
+}
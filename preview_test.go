@@ -0,0 +1,42 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestDryRunMakesNoCalls(t *testing.T) {
+	setupTest(t)
+	fake := useFakeProvider(nil)
+	event := EventInfo{Name: "Jazz Night", Location: "Hall", Details: "Jazz for all", Languages: []string{"fr", "de"}, Keywords: []string{"Jazz"}}
+
+	w := serveRequest(newRouter(), "POST", "/event?dryRun=true", mustJSON(event))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, want 200: %s", w.Code, w.Body)
+	}
+	if n := fake.totalCalls(); n != 0 {
+		t.Fatalf("made %d translation calls, want none", n)
+	}
+	if n := len(events.list()); n != 0 {
+		t.Fatalf("stored %d events, want none", n)
+	}
+
+	var preview eventPreview
+	decodeJSON(t, w, &preview)
+	for _, lang := range event.Languages {
+		language, ok := preview.Languages[lang]
+		if !ok || len(language.Segments) != 1 || language.Segments[0].Role != "details" {
+			t.Fatalf("%s preview %+v, want the details segment", lang, language)
+		}
+		text := language.Segments[0].Text
+		if strings.Contains(text, "Jazz") || len(language.Placeholders) != 1 {
+			t.Fatalf("%s prepared %q with placeholders %v, want Jazz replaced", lang, text, language.Placeholders)
+		}
+		for placeholder, keyword := range language.Placeholders {
+			if keyword != "Jazz" || strings.Count(text, placeholder) != 2 {
+				t.Errorf("%s: placeholder %s for %q appears %d times in %q", lang, placeholder, keyword, strings.Count(text, placeholder), text)
+			}
+		}
+	}
+}
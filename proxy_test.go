@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+// forwardProxy starts a proxy that answers every request as the Azure API
+// would, passing the host each was meant for to the returned channel.
+func forwardProxy(t *testing.T) (*httptest.Server, <-chan string) {
+	t.Helper()
+	hosts := make(chan string, 10)
+	azure := echoAzure(nil)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hosts <- r.URL.Host
+		azure(w, r)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, hosts
+}
+
+func TestProviderCallsGoThroughProxy(t *testing.T) {
+	proxy, hosts := forwardProxy(t)
+	setupTest(t, "TRANSLATOR_PROXY_URL", proxy.URL)
+	config.Endpoint = "http://translator.example"
+	config.SubscriptionKey = "test-key"
+	provider = newAzureProvider(config, httpClient)
+
+	w := serveRequest(newRouter(), "POST", "/translate", `{"text":"Hello","to":["fr"]}`)
+	var res translateResponse
+	decodeJSON(t, w, &res)
+	if got := res.Translations["fr"]; w.Code != http.StatusOK || got != "fr:Hello" {
+		t.Errorf("status %d, translation %q, want 200 %q", w.Code, got, "fr:Hello")
+	}
+	select {
+	case host := <-hosts:
+		if host != "translator.example" {
+			t.Errorf("proxy got a request for %q, want translator.example", host)
+		}
+	default:
+		t.Fatal("request did not go through the proxy")
+	}
+}
+
+func TestInvalidProxyURLRejected(t *testing.T) {
+	for _, proxy := range []string{"proxy.example:3128", "ftp://proxy.example", "http://"} {
+		t.Setenv("TRANSLATOR_PROXY_URL", proxy)
+		if _, err := loadConfig(); err == nil {
+			t.Errorf("TRANSLATOR_PROXY_URL=%q accepted, want an error", proxy)
+		}
+	}
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+	"golang.org/x/time/rate"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// limiterIdleTTL is how long a client's bucket is kept after its last request.
+const limiterIdleTTL = 10 * time.Minute
+
+type clientLimiter struct {
+	limiter  *rate.Limiter
+	lastSeen time.Time
+}
+
+// rateLimiter keeps one token bucket per client, as identified by clientKey.
+type rateLimiter struct {
+	sync.Mutex
+	limit     rate.Limit
+	burst     int
+	clients   map[string]*clientLimiter
+	lastSweep time.Time
+}
+
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if burst < 1 {
+		burst = 1
+	}
+	return &rateLimiter{
+		limit:     rate.Limit(rps),
+		burst:     burst,
+		clients:   make(map[string]*clientLimiter),
+		lastSweep: time.Now(),
+	}
+}
+
+func (l *rateLimiter) get(key string, now time.Time) *rate.Limiter {
+	l.Lock()
+	defer l.Unlock()
+
+	if now.Sub(l.lastSweep) > limiterIdleTTL {
+		for k, client := range l.clients {
+			if now.Sub(client.lastSeen) > limiterIdleTTL {
+				delete(l.clients, k)
+			}
+		}
+		l.lastSweep = now
+	}
+
+	client, ok := l.clients[key]
+	if !ok {
+		client = &clientLimiter{limiter: rate.NewLimiter(l.limit, l.burst)}
+		l.clients[key] = client
+	}
+	client.lastSeen = now
+	return client.limiter
+}
+
+// clientKey identifies the client by its API key once requireAPIKey has
+// accepted it and otherwise by its IP address. An unchecked X-API-Key header
+// is ignored, or a client could send a new one with every request to get a
+// fresh bucket.
+func clientKey(c *gin.Context) string {
+	if key := c.GetString(apiKeyContextKey); key != "" {
+		return "key:" + key
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// middleware rejects requests over the client's rate with 429 and a
+// Retry-After header saying when a token will be available.
+func (l *rateLimiter) middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		now := time.Now()
+		reservation := l.get(clientKey(c), now).ReserveN(now, 1)
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			c.Header("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			abortWithError(c, http.StatusTooManyRequests, codeRateLimited, "Rate limit exceeded")
+			return
+		}
+		c.Next()
+	}
+}
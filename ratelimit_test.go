@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestRateLimitRejectsExcess(t *testing.T) {
+	setupTest(t, "RATE_LIMIT_RPS", "0.01", "RATE_LIMIT_BURST", "2")
+	r := newRouter()
+
+	for i := 0; i < 4; i++ {
+		w := serveRequest(r, "POST", "/event", eventBody(fmt.Sprintf("Concert %d", i), "fr"))
+		if i < 2 && w.Code != http.StatusCreated {
+			t.Fatalf("request %d: status %d, want 201", i, w.Code)
+		}
+		if i >= 2 {
+			if w.Code != http.StatusTooManyRequests {
+				t.Fatalf("request %d: status %d, want 429", i, w.Code)
+			}
+			if w.Header().Get("Retry-After") == "" {
+				t.Errorf("request %d: no Retry-After header", i)
+			}
+		}
+	}
+}
+
+func TestRateLimitIgnoresUncheckedAPIKeys(t *testing.T) {
+	setupTest(t, "RATE_LIMIT_RPS", "0.01", "RATE_LIMIT_BURST", "1")
+	r := newRouter()
+
+	// Without API_KEYS the header is not checked, so a new key per request
+	// must not buy a new bucket.
+	serveRequest(r, "POST", "/event", eventBody("Concert", "fr"), "X-API-Key", "first")
+	w := serveRequest(r, "POST", "/event", eventBody("Opera", "fr"), "X-API-Key", "second")
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("status %d, want 429 for the same IP with another key", w.Code)
+	}
+}
+
+func TestRateLimitPerAcceptedAPIKey(t *testing.T) {
+	setupTest(t, "RATE_LIMIT_RPS", "0.01", "RATE_LIMIT_BURST", "1", "API_KEYS", "key-a,key-b")
+	r := newRouter()
+
+	if w := serveRequest(r, "POST", "/event", eventBody("Concert", "fr"), "X-API-Key", "key-a"); w.Code != http.StatusCreated {
+		t.Fatalf("key-a: status %d, want 201", w.Code)
+	}
+	if w := serveRequest(r, "POST", "/event", eventBody("Opera", "fr"), "X-API-Key", "key-a"); w.Code != http.StatusTooManyRequests {
+		t.Fatalf("key-a again: status %d, want 429", w.Code)
+	}
+	if w := serveRequest(r, "POST", "/event", eventBody("Ballet", "fr"), "X-API-Key", "key-b"); w.Code != http.StatusCreated {
+		t.Fatalf("key-b: status %d, want 201 from its own bucket", w.Code)
+	}
+}
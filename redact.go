@@ -0,0 +1,41 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"log/slog"
+	"strings"
+)
+
+// Ways config.LogRedaction hides the values of config.LogRedactFields.
+const (
+	redactMask = "mask"
+	redactHash = "hash"
+)
+
+const redactedValue = "[REDACTED]"
+
+// redactAttrs returns a slog ReplaceAttr function that hides the values of
+// attributes named one of fields, compared case-insensitively and at any
+// group depth. With redactHash the value is replaced by a short hash of it,
+// so log lines about the same value can still be correlated. No fields
+// returns nil, which leaves every attribute as is.
+func redactAttrs(fields []string, mode string) func(groups []string, a slog.Attr) slog.Attr {
+	if len(fields) == 0 {
+		return nil
+	}
+	sensitive := make(map[string]bool, len(fields))
+	for _, field := range fields {
+		sensitive[strings.ToLower(field)] = true
+	}
+	return func(groups []string, a slog.Attr) slog.Attr {
+		if !sensitive[strings.ToLower(a.Key)] {
+			return a
+		}
+		if mode == redactHash {
+			sum := sha256.Sum256([]byte(a.Value.String()))
+			return slog.String(a.Key, "sha256:"+hex.EncodeToString(sum[:6]))
+		}
+		return slog.String(a.Key, redactedValue)
+	}
+}
@@ -0,0 +1,266 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"github.com/redis/go-redis/v9"
+	"time"
+)
+
+// redisTimeout bounds every Redis command of the store.
+const redisTimeout = 5 * time.Second
+
+// redisEventStore keeps each event as JSON under prefix + ID so several
+// instances can share the events. Each slug is reserved by a key of its own,
+// "slug:" + prefix + slug, holding the ID of the event that has it; it lies
+// outside the prefix so scans for events never see it. Lookups that fail
+// because Redis is unreachable are logged and reported as not found.
+type redisEventStore struct {
+	client *redis.Client
+	prefix string
+}
+
+// newRedisEventStore connects to the Redis server at url, e.g.
+// "redis://localhost:6379/0", and reserves the slugs of events stored before
+// slugs were reserved.
+func newRedisEventStore(url, prefix string) (*redisEventStore, error) {
+	opts, err := redis.ParseURL(url)
+	if err != nil {
+		return nil, fmt.Errorf("error parsing REDIS_URL: %v", err)
+	}
+	s := &redisEventStore{client: redis.NewClient(opts), prefix: prefix}
+
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if err := s.client.Ping(ctx).Err(); err != nil {
+		return nil, fmt.Errorf("error connecting to redis: %w", err)
+	}
+	for _, event := range s.list() {
+		if event.Slug == "" {
+			continue
+		}
+		if err := s.client.SetNX(ctx, s.slugKey(event.Slug), event.ID, 0).Err(); err != nil {
+			return nil, fmt.Errorf("error reserving slugs in redis: %w", err)
+		}
+	}
+	return s, nil
+}
+
+func (s *redisEventStore) key(id string) string {
+	return s.prefix + id
+}
+
+func (s *redisEventStore) slugKey(slug string) string {
+	return "slug:" + s.prefix + slug
+}
+
+func (s *redisEventStore) get(id string) (EventInfo, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	data, err := s.client.Get(ctx, s.key(id)).Bytes()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Error("error reading event from redis", "id", id, "error", err)
+		}
+		return EventInfo{}, false
+	}
+	var event EventInfo
+	if err := json.Unmarshal(data, &event); err != nil {
+		logger.Error("error decoding event from redis", "id", id, "error", err)
+		return EventInfo{}, false
+	}
+	return event, true
+}
+
+func (s *redisEventStore) exists(id string) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	n, err := s.client.Exists(ctx, s.key(id)).Result()
+	if err != nil {
+		logger.Error("error reading event from redis", "id", id, "error", err)
+		return false
+	}
+	return n > 0
+}
+
+func (s *redisEventStore) findByName(name string) (EventInfo, bool) {
+	for _, event := range s.list() {
+		if event.Name == name {
+			return event, true
+		}
+	}
+	return EventInfo{}, false
+}
+
+func (s *redisEventStore) findBySlug(slug string) (EventInfo, bool) {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	id, err := s.client.Get(ctx, s.slugKey(slug)).Result()
+	if err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Error("error reading slug from redis", "slug", slug, "error", err)
+		}
+		return EventInfo{}, false
+	}
+	return s.get(id)
+}
+
+// add reserves the event's slug and then stores the event, both with SET NX,
+// so of two instances adding the same ID or slug only one wins.
+func (s *redisEventStore) add(event EventInfo) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling event: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	if event.Slug != "" {
+		reserved, err := s.client.SetNX(ctx, s.slugKey(event.Slug), event.ID, 0).Result()
+		if err != nil {
+			return fmt.Errorf("error reserving slug in redis: %w", err)
+		}
+		if !reserved {
+			return errSlugExists
+		}
+	}
+	added, err := s.client.SetNX(ctx, s.key(event.ID), data, 0).Result()
+	if err != nil || !added {
+		if event.Slug != "" {
+			s.client.Del(ctx, s.slugKey(event.Slug))
+		}
+		if err != nil {
+			return fmt.Errorf("error storing event in redis: %w", err)
+		}
+		return errEventExists
+	}
+	s.updateEventsStored(ctx)
+	return nil
+}
+
+func (s *redisEventStore) update(event EventInfo) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling event: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	updated, err := s.client.SetXX(ctx, s.key(event.ID), data, 0).Result()
+	if err != nil {
+		return fmt.Errorf("error storing event in redis: %w", err)
+	}
+	if !updated {
+		return errEventNotFound
+	}
+	// Events stored before slugs existed get theirs on an update.
+	if event.Slug != "" {
+		if err := s.client.SetNX(ctx, s.slugKey(event.Slug), event.ID, 0).Err(); err != nil {
+			return fmt.Errorf("error reserving slug in redis: %w", err)
+		}
+	}
+	return nil
+}
+
+// delete removes the event along with its slug reservation.
+func (s *redisEventStore) delete(id string) error {
+	event, ok := s.get(id)
+	if !ok {
+		return errEventNotFound
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	keys := []string{s.key(id)}
+	if event.Slug != "" {
+		keys = append(keys, s.slugKey(event.Slug))
+	}
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("error deleting event from redis: %w", err)
+	}
+	s.updateEventsStored(ctx)
+	return nil
+}
+
+func (s *redisEventStore) reset() error {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	keys, err := s.keys(ctx, s.prefix)
+	if err != nil {
+		return err
+	}
+	slugKeys, err := s.keys(ctx, s.slugKey(""))
+	if err != nil {
+		return err
+	}
+	keys = append(keys, slugKeys...)
+	if len(keys) == 0 {
+		return nil
+	}
+	if err := s.client.Del(ctx, keys...).Err(); err != nil {
+		return fmt.Errorf("error deleting events from redis: %w", err)
+	}
+	metrics.setEventsStored(0)
+	return nil
+}
+
+func (s *redisEventStore) list() []EventInfo {
+	ctx, cancel := context.WithTimeout(context.Background(), redisTimeout)
+	defer cancel()
+	list := make([]EventInfo, 0)
+	keys, err := s.keys(ctx, s.prefix)
+	if err == nil && len(keys) > 0 {
+		var values []interface{}
+		values, err = s.client.MGet(ctx, keys...).Result()
+		for _, value := range values {
+			// Events deleted since the scan come back as nil.
+			data, ok := value.(string)
+			if !ok {
+				continue
+			}
+			var event EventInfo
+			if err := json.Unmarshal([]byte(data), &event); err != nil {
+				logger.Error("error decoding event from redis", "error", err)
+				continue
+			}
+			list = append(list, event)
+		}
+	}
+	if err != nil {
+		logger.Error("error listing events from redis", "error", err)
+	}
+	sortEvents(list)
+	return list
+}
+
+// flush has nothing to do: every change is written to Redis at once.
+func (s *redisEventStore) flush() error {
+	return nil
+}
+
+// updateEventsStored sets the stored events gauge, counting the events of
+// every instance. The count takes a scan, so it is skipped without metrics.
+func (s *redisEventStore) updateEventsStored(ctx context.Context) {
+	if metrics == nil {
+		return
+	}
+	keys, err := s.keys(ctx, s.prefix)
+	if err != nil {
+		logger.Error("error counting events in redis", "error", err)
+		return
+	}
+	metrics.setEventsStored(len(keys))
+}
+
+// keys scans for every key starting with prefix, without blocking Redis the
+// way KEYS would.
+func (s *redisEventStore) keys(ctx context.Context, prefix string) ([]string, error) {
+	var keys []string
+	iter := s.client.Scan(ctx, 0, prefix+"*", 100).Iterator()
+	for iter.Next(ctx) {
+		keys = append(keys, iter.Val())
+	}
+	if err := iter.Err(); err != nil {
+		return nil, fmt.Errorf("error scanning events in redis: %w", err)
+	}
+	return keys, nil
+}
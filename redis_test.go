@@ -0,0 +1,124 @@
+package main
+
+import (
+	"encoding/json"
+	"github.com/alicebob/miniredis/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"net/http"
+	"testing"
+)
+
+// useRedisStore replaces the event store with a Redis store backed by an
+// in-process server.
+func useRedisStore(t *testing.T) (*redisEventStore, *miniredis.Miniredis) {
+	t.Helper()
+	srv := miniredis.RunT(t)
+	store, err := newRedisEventStore("redis://"+srv.Addr(), defaultRedisKeyPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	events = store
+	return store, srv
+}
+
+func TestRedisStoreRoundTrip(t *testing.T) {
+	setupTest(t)
+	store, srv := useRedisStore(t)
+	r := newRouter()
+
+	created := createEvent(t, r, eventBody("Concert", "fr"))
+	if !srv.Exists(defaultRedisKeyPrefix + created.ID) {
+		t.Fatalf("no redis key for event %q", created.ID)
+	}
+
+	w := serveRequest(r, "GET", eventLocation(created.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: status %d, body %s", w.Code, w.Body)
+	}
+	var got EventInfo
+	decodeJSON(t, w, &got)
+	if got.Name != "Concert" || got.Translations["fr"] != created.Translations["fr"] {
+		t.Fatalf("got %+v, want the created event", got)
+	}
+	if bySlug, ok := store.findBySlug(created.Slug); !ok || bySlug.ID != created.ID {
+		t.Fatalf("findBySlug(%q) = %+v, %v", created.Slug, bySlug, ok)
+	}
+
+	if w := serveRequest(r, "DELETE", eventLocation(created.ID), ""); w.Code != http.StatusNoContent {
+		t.Fatalf("DELETE: status %d, body %s", w.Code, w.Body)
+	}
+	if _, ok := store.get(created.ID); ok {
+		t.Fatal("event still stored after DELETE")
+	}
+	if _, ok := store.findBySlug(created.Slug); ok || srv.Exists(store.slugKey(created.Slug)) {
+		t.Fatal("slug still reserved after DELETE")
+	}
+	if w := serveRequest(r, "GET", eventLocation(created.ID), ""); w.Code != http.StatusNotFound {
+		t.Fatalf("GET after DELETE: status %d, want %d", w.Code, http.StatusNotFound)
+	}
+}
+
+func TestRedisStoreReservesSlugs(t *testing.T) {
+	setupTest(t)
+	store, _ := useRedisStore(t)
+
+	if err := store.add(EventInfo{ID: "a", Name: "Concert", Slug: "concert"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.add(EventInfo{ID: "b", Name: "Concert", Slug: "concert"}); err != errSlugExists {
+		t.Fatalf("second add with the slug: got %v, want %v", err, errSlugExists)
+	}
+	if err := store.add(EventInfo{ID: "a", Name: "Other", Slug: "other"}); err != errEventExists {
+		t.Fatalf("second add with the ID: got %v, want %v", err, errEventExists)
+	}
+	if _, ok := store.findBySlug("other"); ok {
+		t.Fatal("slug of a rejected event left reserved")
+	}
+	if n := len(store.list()); n != 1 {
+		t.Fatalf("listed %d events, want 1 without the slug keys", n)
+	}
+
+	if err := store.reset(); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.add(EventInfo{ID: "c", Name: "Concert", Slug: "concert"}); err != nil {
+		t.Fatalf("add after reset: %v", err)
+	}
+}
+
+func TestRedisStoreReservesExistingSlugs(t *testing.T) {
+	setupTest(t)
+	srv := miniredis.RunT(t)
+	data, _ := json.Marshal(EventInfo{ID: "a", Name: "Concert", Slug: "concert"})
+	srv.Set(defaultRedisKeyPrefix+"a", string(data))
+
+	store, err := newRedisEventStore("redis://"+srv.Addr(), defaultRedisKeyPrefix)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event, ok := store.findBySlug("concert"); !ok || event.ID != "a" {
+		t.Fatalf("findBySlug = %+v, %v, want the stored event", event, ok)
+	}
+}
+
+func TestRedisStoreEventsStoredMetric(t *testing.T) {
+	setupTest(t)
+	store, _ := useRedisStore(t)
+	metrics = newMetrics(prometheus.NewRegistry())
+
+	for _, id := range []string{"a", "b"} {
+		if err := store.add(EventInfo{ID: id, Name: "Concert " + id, Slug: "concert-" + id}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if got := testutil.ToFloat64(metrics.eventsStored); got != 2 {
+		t.Fatalf("events stored %v after two adds, want 2", got)
+	}
+	if err := store.delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if got := testutil.ToFloat64(metrics.eventsStored); got != 1 {
+		t.Fatalf("events stored %v after a delete, want 1", got)
+	}
+}
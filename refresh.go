@@ -0,0 +1,76 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"reflect"
+	"time"
+)
+
+// translationHash identifies everything about event that its translations
+// depend on.
+func translationHash(event EventInfo) string {
+	// fmt prints map keys sorted, so equal inputs always print the same.
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%#v", translationInputOf(event))))
+	return hex.EncodeToString(sum[:16])
+}
+
+// isStale reports whether event was changed since it was translated, e.g. by
+// editing the events file. Events stored before hashes were recorded are
+// never considered stale.
+func isStale(event EventInfo) bool {
+	return event.TranslationHash != "" && event.TranslationHash != translationHash(event)
+}
+
+// refreshStaleTranslations retranslates every stale event each time ticks
+// fires, until ctx is done.
+func refreshStaleTranslations(ctx context.Context, ticks <-chan time.Time) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticks:
+			refreshStaleEvents(ctx)
+		}
+	}
+}
+
+// refreshStaleEvents retranslates the stale events one after another and
+// returns how many were updated. Like an update through the API, an event is
+// only replaced when every language translated, and not at all when it
+// changed again while it was being translated.
+func refreshStaleEvents(ctx context.Context) int {
+	refreshed := 0
+	for _, event := range events.list() {
+		if ctx.Err() != nil {
+			break
+		}
+		if !isStale(event) {
+			continue
+		}
+
+		original := event
+		failures := translateEvent(ctx, &event)
+		if len(failures) > 0 {
+			lang, err := firstFailure(failures)
+			logger.Warn("error refreshing translations", "id", event.ID, "language", lang, "error", err)
+			continue
+		}
+		event.TranslationErrors = nil
+
+		current, ok := events.get(event.ID)
+		if !ok || !reflect.DeepEqual(translationInputOf(current), translationInputOf(original)) {
+			continue
+		}
+		event.UpdatedAt = time.Now().UTC()
+		if err := events.update(event); err != nil {
+			logger.Error("error storing refreshed event", "id", event.ID, "error", err)
+			continue
+		}
+		logger.Info("refreshed stale translations", "id", event.ID)
+		refreshed++
+	}
+	return refreshed
+}
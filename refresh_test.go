@@ -0,0 +1,95 @@
+package main
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// editStored changes an event's details in the store without retranslating
+// it, as editing the events file would.
+func editStored(t *testing.T, id, details string) {
+	t.Helper()
+	event, _ := events.get(id)
+	event.Details = details
+	if err := events.update(event); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRefreshStaleEvents(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	stale := createEvent(t, r, eventBody("Concert", "fr"))
+	fresh := createEvent(t, r, eventBody("Gala", "fr"))
+	editStored(t, stale.ID, "A new programme")
+	fake := useFakeProvider(nil)
+
+	if n := refreshStaleEvents(context.Background()); n != 1 {
+		t.Fatalf("refreshed %d events, want 1", n)
+	}
+	if n := fake.totalCalls(); n != 1 {
+		t.Errorf("made %d calls, want only the stale event translated", n)
+	}
+	refreshed, _ := events.get(stale.ID)
+	if want := "fr:" + assembleDetails(refreshed); refreshed.Translations["fr"] != want {
+		t.Errorf("stale event translated %q, want %q", refreshed.Translations["fr"], want)
+	}
+	if isStale(refreshed) {
+		t.Error("refreshed event still stale")
+	}
+	if got, _ := events.get(fresh.ID); got.Translations["fr"] != fresh.Translations["fr"] || !got.UpdatedAt.Equal(fresh.UpdatedAt) {
+		t.Errorf("fresh event changed: %+v", got)
+	}
+
+	if n := refreshStaleEvents(context.Background()); n != 0 {
+		t.Errorf("second refresh updated %d events, want 0", n)
+	}
+}
+
+func TestRefreshKeepsEventOnFailure(t *testing.T) {
+	setupTest(t)
+	stale := createEvent(t, newRouter(), eventBody("Concert", "fr"))
+	editStored(t, stale.ID, "A new programme")
+	useFakeProvider(func(text, from, to string) (string, error) {
+		return "", ErrProviderUnreachable
+	})
+
+	if n := refreshStaleEvents(context.Background()); n != 0 {
+		t.Fatalf("refreshed %d events, want 0", n)
+	}
+	if got, _ := events.get(stale.ID); got.Translations["fr"] != stale.Translations["fr"] || !isStale(got) {
+		t.Errorf("event %+v, want it left stale with its old translation", got)
+	}
+}
+
+func TestRefreshOnTick(t *testing.T) {
+	setupTest(t)
+	stale := createEvent(t, newRouter(), eventBody("Concert", "fr"))
+	editStored(t, stale.ID, "A new programme")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ticks := make(chan time.Time)
+	done := make(chan struct{})
+	go func() {
+		refreshStaleTranslations(ctx, ticks)
+		close(done)
+	}()
+
+	if got, _ := events.get(stale.ID); !isStale(got) {
+		t.Fatal("event refreshed before the first tick")
+	}
+	ticks <- time.Now()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if got, _ := events.get(stale.ID); !isStale(got) {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("stale event not refreshed after a tick")
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	cancel()
+	<-done
+}
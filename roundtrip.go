@@ -0,0 +1,61 @@
+package main
+
+import (
+	"context"
+	"strings"
+	"unicode"
+)
+
+// roundTripScore translates the translated segments back into from and
+// measures how much of the original text survived, between 0 and 1. It
+// reports false when there is no known source language to translate back
+// into or the back-translation fails, in which case the result simply has no
+// score.
+//
+// The segments are translated back as they are: no keywords, glossary or
+// transforms apply, and the general model is used since a Custom Translator
+// category only covers the forward direction. The calls are left out of the
+// request's debugging details, which describe the translation into lang.
+func roundTripScore(ctx context.Context, event EventInfo, original, translated []eventSegment, from, lang string) (float64, bool) {
+	if from == "" {
+		return 0, false
+	}
+	texts := make([]string, len(translated))
+	for i, segment := range translated {
+		texts[i] = segment.text
+	}
+	pieces, layout := chunkTexts(texts, maxRequestLength)
+	back, _, err := translateTexts(withoutTranslationDebug(ctx), pieces, lang, from, TranslateOptions{TextType: event.TextType})
+	if err != nil {
+		logger.Warn("round-trip translation failed", "language", lang, "error", err)
+		return 0, false
+	}
+	return tokenOverlap(joinSegments(original), strings.Join(layout.join(back), " ")), true
+}
+
+// tokenOverlap is the Dice coefficient of the sets of lowercase words of a
+// and b: 1 when they use the same words, 0 when they share none.
+func tokenOverlap(a, b string) float64 {
+	wordsA, wordsB := wordSet(a), wordSet(b)
+	if len(wordsA)+len(wordsB) == 0 {
+		return 1
+	}
+	shared := 0
+	for word := range wordsA {
+		if wordsB[word] {
+			shared++
+		}
+	}
+	return 2 * float64(shared) / float64(len(wordsA)+len(wordsB))
+}
+
+func wordSet(text string) map[string]bool {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	set := make(map[string]bool, len(words))
+	for _, word := range words {
+		set[word] = true
+	}
+	return set
+}
@@ -0,0 +1,83 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// roundTripEvent creates an event from English into French with
+// verifyRoundTrip set, translating back into English with back.
+func roundTripEvent(t *testing.T, back func(text string) string) EventInfo {
+	t.Helper()
+	useFakeProvider(func(text, from, to string) (string, error) {
+		if to == "en" {
+			return back(text), nil
+		}
+		return text, nil
+	})
+	event := EventInfo{
+		Name:            "Concert",
+		Location:        "Town Hall",
+		Details:         "An evening of music",
+		SourceLanguage:  "en",
+		Languages:       []string{"fr"},
+		VerifyRoundTrip: true,
+	}
+	return createEvent(t, newRouter(), mustJSON(event))
+}
+
+func TestRoundTripFlagsDegradedTranslation(t *testing.T) {
+	setupTest(t)
+	created := roundTripEvent(t, func(string) string { return "A completely different sentence" })
+
+	result := created.Results["fr"]
+	if result.RoundTripScore == nil || *result.RoundTripScore >= config.RoundTripThreshold {
+		t.Fatalf("round-trip score %v, want one below %v", result.RoundTripScore, config.RoundTripThreshold)
+	}
+	if !result.LowConfidence {
+		t.Fatalf("result %+v, want low confidence", result)
+	}
+}
+
+func TestRoundTripAcceptsFaithfulTranslation(t *testing.T) {
+	setupTest(t)
+	created := roundTripEvent(t, func(text string) string { return text })
+
+	result := created.Results["fr"]
+	if result.RoundTripScore == nil || *result.RoundTripScore != 1 {
+		t.Fatalf("round-trip score %v, want 1", result.RoundTripScore)
+	}
+	if result.LowConfidence {
+		t.Fatalf("result %+v, want no low confidence flag", result)
+	}
+}
+
+func TestRoundTripSkipsTransformsAndDebug(t *testing.T) {
+	setupTest(t)
+	registerTransform("en", func(_, text string) string { return strings.ToUpper(text) })
+	useFakeProvider(func(text, from, to string) (string, error) { return text, nil })
+	event := EventInfo{
+		Name:            "Concert",
+		Location:        "Town Hall",
+		Details:         "An evening of music",
+		SourceLanguage:  "en",
+		Languages:       []string{"fr"},
+		VerifyRoundTrip: true,
+	}
+	w := serveRequest(newRouter(), "POST", "/event?debug=true", mustJSON(event))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	var res eventWithStats
+	decodeJSON(t, w, &res)
+	if _, ok := res.Debug["en"]; ok {
+		t.Errorf("debug %v has the back-translation into en", res.Debug)
+	}
+	if len(res.Debug["fr"].Calls) != 1 {
+		t.Errorf("debug calls for fr %v, want the forward call only", res.Debug["fr"].Calls)
+	}
+	if score := res.Results["fr"].RoundTripScore; score == nil || *score != 1 {
+		t.Errorf("round-trip score %v, want 1 with no transform applied to the back-translation", score)
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"golang.org/x/text/unicode/norm"
+	"strings"
+	"unicode"
+)
+
+// invisibleRunes are zero-width characters that rich text editors leave in
+// pasted text. They are invisible but stop keywords from matching.
+var invisibleRunes = map[rune]bool{
+	'\u200B': true, // zero width space
+	'\u200C': true, // zero width non-joiner
+	'\u200D': true, // zero width joiner
+	'\u2060': true, // word joiner
+	'\uFEFF': true, // byte order mark
+}
+
+// sanitizeText removes control characters other than tab and line breaks and
+// zero-width characters from text when config.SanitizeText is set, and
+// applies Unicode NFC normalization when config.NormalizeNFC is set.
+func sanitizeText(text string) string {
+	if config.SanitizeText {
+		text = strings.Map(func(r rune) rune {
+			if invisibleRunes[r] || unicode.IsControl(r) && r != '\t' && r != '\n' && r != '\r' {
+				return -1
+			}
+			return r
+		}, text)
+	}
+	if config.NormalizeNFC {
+		text = norm.NFC.String(text)
+	}
+	return text
+}
+
+func sanitizeTexts(texts []string) []string {
+	sanitized := make([]string, len(texts))
+	for i, text := range texts {
+		sanitized[i] = sanitizeText(text)
+	}
+	return sanitized
+}
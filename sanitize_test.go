@@ -0,0 +1,63 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestSanitizeTextBeforeTranslation(t *testing.T) {
+	setupTest(t, "SANITIZE_TEXT", "true")
+	var mu sync.Mutex
+	var sent []string
+	useFakeProvider(func(text, from, to string) (string, error) {
+		mu.Lock()
+		sent = append(sent, text)
+		mu.Unlock()
+		return text, nil
+	})
+
+	event := EventInfo{
+		Name:      "Con\u200Dcert",
+		Location:  "Town\u200B Hall\x07",
+		Details:   "Live\uFEFF music\x00\ttonight\u2060",
+		Keywords:  []string{"Concert"},
+		Languages: []string{"fr"},
+	}
+	w := serveRequest(newRouter(), "POST", "/event", mustJSON(event))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /event: status %d, body %s", w.Code, w.Body)
+	}
+
+	if len(sent) != 1 {
+		t.Fatalf("sent %d texts, want 1", len(sent))
+	}
+	for _, r := range "\u200B\u200D\u2060\uFEFF\x00\x07" {
+		if strings.ContainsRune(sent[0], r) {
+			t.Errorf("sent text %q contains %U", sent[0], r)
+		}
+	}
+	if !strings.Contains(sent[0], "Live music\ttonight") {
+		t.Errorf("sent text %q lost its tab", sent[0])
+	}
+	var got EventInfo
+	decodeJSON(t, w, &got)
+	if !strings.HasPrefix(got.Translations["fr"], "Concert ") {
+		t.Errorf("translation %q, want the keyword to match once joiners are stripped", got.Translations["fr"])
+	}
+}
+
+func TestSanitizeTextOffByDefault(t *testing.T) {
+	setupTest(t)
+	if got := sanitizeText("a\u200Db\x07"); got != "a\u200Db\x07" {
+		t.Fatalf("sanitizeText = %q, want the text unchanged", got)
+	}
+}
+
+func TestSanitizeTextNormalizesNFC(t *testing.T) {
+	setupTest(t, "NORMALIZE_NFC", "true")
+	if got := sanitizeText("Cafe\u0301"); got != "Caf\u00e9" {
+		t.Fatalf("sanitizeText = %q, want %q", got, "Caf\u00e9")
+	}
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"github.com/gin-gonic/gin"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
+	"net"
+	"net/http"
+	"time"
+)
+
+func newRouter() *gin.Engine {
+	r := gin.New()
+	r.Use(gin.CustomRecovery(func(c *gin.Context, _ interface{}) {
+		abortWithError(c, http.StatusInternalServerError, codeInternal, "Internal server error")
+	}), requestLogger(logger))
+	if len(config.AllowedOrigins) > 0 {
+		// Global middleware also runs for unrouted OPTIONS preflights.
+		r.Use(cors(config.AllowedOrigins, config.CORSAllowedMethods, config.CORSAllowedHeaders))
+	}
+	if config.GzipMinBytes > 0 {
+		// Registered before asciiJSON so that it compresses the escaped JSON.
+		r.Use(gzipResponses(config.GzipMinBytes))
+	}
+	if config.OutputEscaping == escapeUnicode {
+		r.Use(asciiJSON())
+	}
+	if config.OTLPEndpoint != "" {
+		// The request span is the parent of every translation span.
+		r.Use(otelgin.Middleware(tracingServiceName))
+	}
+
+	pass := gin.HandlerFunc(func(c *gin.Context) { c.Next() })
+
+	// Without configured API keys every request is allowed.
+	write, read := pass, pass
+	if len(config.APIKeys) > 0 {
+		write = requireAPIKey(config.APIKeys)
+		if config.APIKeysForReads {
+			read = write
+		}
+	}
+
+	// Only requests that can trigger translations are rate limited.
+	limit := pass
+	if config.RateLimitRPS > 0 {
+		limit = newRateLimiter(config.RateLimitRPS, config.RateLimitBurst).middleware()
+	}
+
+	idempotent := newIdempotencyStore(config.IdempotencyTTL).middleware()
+
+	// Bodies are limited before the idempotency middleware reads them.
+	body := pass
+	if config.MaxBodyBytes > 0 {
+		body = limitBody(config.MaxBodyBytes)
+	}
+
+	r.POST("/event", write, limit, body, idempotent, postEvent)
+	r.GET("/event", read, getEvent)
+	r.PUT("/event", write, limit, body, updateEvent)
+	r.PATCH("/event", write, limit, body, patchEvent)
+	r.DELETE("/event", write, deleteEvent)
+	r.GET("/events", read, listEvents)
+	r.POST("/events", write, limit, body, postEvents)
+	r.POST("/batch", write, limit, body, postBatch)
+	r.GET("/batch/:id", read, getBatch)
+	r.DELETE("/events", requireAdminToken(config.AdminToken), resetEvents)
+	r.POST("/translate", write, limit, body, postTranslate)
+	r.POST("/translate/stream", write, limit, body, streamTranslate)
+	r.GET("/languages", read, listLanguages)
+	r.GET("/healthz", healthz)
+	r.GET("/openapi.json", serveOpenAPI)
+	if metrics != nil {
+		r.GET("/metrics", metrics.handler())
+	}
+	r.NoRoute(func(c *gin.Context) {
+		respondError(c, http.StatusNotFound, codeRouteNotFound, "No route for "+c.Request.Method+" "+c.Request.URL.Path)
+	})
+	return r
+}
+
+// serve runs srv on ln until ctx is done, then stops accepting connections and
+// waits up to timeout for in-flight requests, background translations and the
+// events batches are storing to finish before flushing the event store.
+// Batches stop before their next event and resume on the next start.
+func serve(ctx context.Context, srv *http.Server, ln net.Listener, timeout time.Duration) error {
+	logger.Info("listening", "address", ln.Addr().String())
+	errc := make(chan error, 1)
+	go func() {
+		errc <- srv.Serve(ln)
+	}()
+
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	logger.Info("shutting down", "timeout", timeout)
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	shutdownErr := srv.Shutdown(shutdownCtx)
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	batchJobs.stop()
+	if err := waitForBackgroundJobs(shutdownCtx); err != nil {
+		logger.Warn("background translations still running", "error", err)
+	}
+
+	if err := events.flush(); err != nil {
+		return err
+	}
+	return shutdownErr
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"errors"
+	"golang.org/x/text/unicode/norm"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// slugify lowercases name, strips accents and joins its runs of ASCII
+// letters and digits with hyphens, e.g. "My Café!" becomes "my-cafe". A name
+// without any gives "event".
+func slugify(name string) string {
+	var b strings.Builder
+	for _, r := range norm.NFKD.String(strings.ToLower(name)) {
+		switch {
+		case unicode.Is(unicode.Mn, r):
+			// Accents decomposed into combining marks are dropped.
+		case 'a' <= r && r <= 'z', '0' <= r && r <= '9':
+			b.WriteRune(r)
+		case b.Len() > 0 && !strings.HasSuffix(b.String(), "-"):
+			b.WriteByte('-')
+		}
+	}
+	slug := strings.TrimSuffix(b.String(), "-")
+	if slug == "" {
+		return "event"
+	}
+	return slug
+}
+
+// uniqueSlug returns the slug of name, followed by -2, -3 and so on when
+// stored events already have it.
+func uniqueSlug(name string) string {
+	base := slugify(name)
+	slug := base
+	for n := 2; ; n++ {
+		if _, taken := events.findBySlug(slug); !taken {
+			return slug
+		}
+		slug = base + "-" + strconv.Itoa(n)
+	}
+}
+
+// addEvent stores a new event, giving it another slug when an event stored
+// since its slug was picked took it first.
+func addEvent(event *EventInfo) error {
+	for {
+		err := events.add(*event)
+		if !errors.Is(err, errSlugExists) {
+			return err
+		}
+		event.Slug = uniqueSlug(event.Name)
+	}
+}
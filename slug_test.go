@@ -0,0 +1,75 @@
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+)
+
+func TestSlugify(t *testing.T) {
+	tests := map[string]string{
+		"My Event!":       "my-event",
+		"  Café  Crème ":  "cafe-creme",
+		"Jazz & Blues 24": "jazz-blues-24",
+		"!!!":             "event",
+		"音楽":              "event",
+	}
+	for name, want := range tests {
+		if got := slugify(name); got != want {
+			t.Errorf("slugify(%q) = %q, want %q", name, got, want)
+		}
+	}
+}
+
+func TestCollidingSlugs(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	names := []string{"My Event!", "my event", "My-Event"}
+	wantSlugs := []string{"my-event", "my-event-2", "my-event-3"}
+	for i, name := range names {
+		created := createEvent(t, r, eventBody(name, "fr"))
+		if created.Slug != wantSlugs[i] {
+			t.Errorf("%q got slug %q, want %q", name, created.Slug, wantSlugs[i])
+		}
+	}
+
+	for i, slug := range wantSlugs {
+		w := serveRequest(r, "GET", "/event?type="+slug, "")
+		if w.Code != http.StatusOK {
+			t.Fatalf("GET by slug %q: status %d, body %s", slug, w.Code, w.Body)
+		}
+		var event EventInfo
+		decodeJSON(t, w, &event)
+		if event.Name != names[i] {
+			t.Errorf("slug %q fetched %q, want %q", slug, event.Name, names[i])
+		}
+	}
+
+	w := serveRequest(r, "GET", "/event?type="+url.QueryEscape("My Event!"), "")
+	var event EventInfo
+	decodeJSON(t, w, &event)
+	if event.Slug != "my-event" {
+		t.Errorf("GET by name fetched %+v, want the event named My Event!", event)
+	}
+}
+
+func TestSlugFreedOnDelete(t *testing.T) {
+	setupTest(t)
+	first := EventInfo{ID: "a", Name: "Concert", Slug: "concert"}
+	if err := events.add(first); err != nil {
+		t.Fatal(err)
+	}
+	if err := events.add(EventInfo{ID: "b", Name: "Concert", Slug: "concert"}); err != errSlugExists {
+		t.Fatalf("add with a taken slug: got %v, want %v", err, errSlugExists)
+	}
+	if err := events.delete("a"); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := events.findBySlug("concert"); ok {
+		t.Fatal("slug of a deleted event still found")
+	}
+	if got := uniqueSlug("Concert"); got != "concert" {
+		t.Errorf("uniqueSlug after delete = %q, want concert", got)
+	}
+}
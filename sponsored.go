@@ -0,0 +1,97 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Placements of the sponsored message in the translations into a language,
+// set per language by config.SponsoredPlacement.
+const (
+	// placementInline joins the message to the rest of the text like any
+	// other segment.
+	placementInline = "inline"
+	// placementPrepend and placementAppend put the message in a paragraph of
+	// its own before or after the rest of the text.
+	placementPrepend = "prepend"
+	placementAppend  = "append"
+	// placementOmit leaves the message out of the translations entirely; it
+	// is not translated either.
+	placementOmit = "omit"
+)
+
+const sponsoredSeparator = "\n\n"
+
+// sponsoredPlacement is where the sponsored message goes in the translation
+// into lang.
+func sponsoredPlacement(lang string) string {
+	if placement, ok := config.SponsoredPlacement[canonicalLanguage(lang)]; ok {
+		return placement
+	}
+	return placementInline
+}
+
+// segmentsFor drops the sponsored message from segments when it is omitted
+// from the translation into lang.
+func segmentsFor(segments []eventSegment, lang string) []eventSegment {
+	if sponsoredPlacement(lang) != placementOmit {
+		return segments
+	}
+	kept := make([]eventSegment, 0, len(segments))
+	for _, segment := range segments {
+		if segment.role != "sponsoredMessage" {
+			kept = append(kept, segment)
+		}
+	}
+	return kept
+}
+
+// joinTranslatedSegments joins the segments translated into lang into the
+// event's translated text, placing the sponsored message as configured.
+func joinTranslatedSegments(segments []eventSegment, lang string) string {
+	placement := sponsoredPlacement(lang)
+	if placement != placementPrepend && placement != placementAppend {
+		return joinSegments(segments)
+	}
+
+	var message string
+	rest := make([]eventSegment, 0, len(segments))
+	for _, segment := range segments {
+		if segment.role == "sponsoredMessage" {
+			message = segment.text
+		} else {
+			rest = append(rest, segment)
+		}
+	}
+	if message == "" {
+		return joinSegments(rest)
+	}
+	if placement == placementPrepend {
+		return message + sponsoredSeparator + joinSegments(rest)
+	}
+	return joinSegments(rest) + sponsoredSeparator + message
+}
+
+// getEnvSponsoredPlacement parses a comma-separated list of language=placement
+// pairs.
+func getEnvSponsoredPlacement(key string) (map[string]string, error) {
+	list := getEnvList(key)
+	if len(list) == 0 {
+		return nil, nil
+	}
+	placements := make(map[string]string, len(list))
+	for _, pair := range list {
+		lang, placement, ok := strings.Cut(pair, "=")
+		lang, placement = strings.TrimSpace(lang), strings.TrimSpace(placement)
+		switch placement {
+		case placementInline, placementPrepend, placementAppend, placementOmit:
+		default:
+			ok = false
+		}
+		if !ok || lang == "" {
+			return nil, fmt.Errorf("%s must be a list of language=placement pairs with placement inline, prepend, append or omit, got %q", key, pair)
+		}
+		placements[canonicalLanguage(lang)] = placement
+	}
+	return placements, nil
+}
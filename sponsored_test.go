@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// sponsoredEvent creates an event with message as its sponsored message and
+// returns it along with the texts sent to the provider.
+func sponsoredEvent(t *testing.T, message string) (EventInfo, []string) {
+	t.Helper()
+	var mu sync.Mutex
+	var sent []string
+	useFakeProvider(func(text, from, to string) (string, error) {
+		mu.Lock()
+		sent = append(sent, text)
+		mu.Unlock()
+		return to + ":" + text, nil
+	})
+
+	event := EventInfo{
+		Name:             "Concert",
+		Location:         "Town Hall",
+		Details:          "An evening of music",
+		SponsoredMessage: message,
+		Languages:        []string{"fr"},
+	}
+	return createEvent(t, newRouter(), mustJSON(event)), sent
+}
+
+func TestSponsoredMessageTranslatedSeparately(t *testing.T) {
+	setupTest(t)
+	event, sent := sponsoredEvent(t, "Brought to you by Acme")
+
+	if got, want := event.TranslatedSponsoredMessage["fr"], "fr:Brought to you by Acme"; got != want {
+		t.Errorf("translated sponsored message %q, want %q", got, want)
+	}
+	found := false
+	for _, text := range sent {
+		found = found || text == "Brought to you by Acme"
+	}
+	if !found {
+		t.Errorf("sent %q, want the sponsored message as a text of its own", sent)
+	}
+}
+
+func TestEmptySponsoredMessageNotTranslated(t *testing.T) {
+	setupTest(t)
+	event, sent := sponsoredEvent(t, "")
+
+	if event.TranslatedSponsoredMessage != nil {
+		t.Errorf("translated sponsored message %v, want none", event.TranslatedSponsoredMessage)
+	}
+	if len(sent) != 1 {
+		t.Errorf("sent %q, want only the details", sent)
+	}
+}
+
+func TestSponsoredMessageStoredWithEvent(t *testing.T) {
+	setupTest(t)
+	event, _ := sponsoredEvent(t, "Brought to you by Acme")
+
+	w := serveRequest(newRouter(), "GET", eventLocation(event.ID), "")
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET: status %d, body %s", w.Code, w.Body)
+	}
+	var got EventInfo
+	decodeJSON(t, w, &got)
+	if got.TranslatedSponsoredMessage["fr"] != event.TranslatedSponsoredMessage["fr"] {
+		t.Errorf("stored sponsored message %v, want %v", got.TranslatedSponsoredMessage, event.TranslatedSponsoredMessage)
+	}
+}
+
+func TestSponsoredMessagePlacement(t *testing.T) {
+	setupTest(t, "SPONSORED_MESSAGE_PLACEMENT", "de=append, es=omit, it=prepend")
+	var mu sync.Mutex
+	var sentToES []string
+	useFakeProvider(func(text, from, to string) (string, error) {
+		if to == "es" {
+			mu.Lock()
+			sentToES = append(sentToES, text)
+			mu.Unlock()
+		}
+		return to + ":" + text, nil
+	})
+	event := EventInfo{
+		Name:             "Concert",
+		Location:         "Town Hall",
+		Details:          "An evening of music",
+		SponsoredMessage: "Brought to you by Acme",
+		Languages:        []string{"fr", "de", "es", "it"},
+	}
+
+	created := createEvent(t, newRouter(), mustJSON(event))
+	details := assembleDetails(created)
+	want := map[string]string{
+		"fr": "fr:" + details + " fr:Brought to you by Acme",
+		"de": "de:" + details + "\n\nde:Brought to you by Acme",
+		"es": "es:" + details,
+		"it": "it:Brought to you by Acme\n\nit:" + details,
+	}
+	for lang, text := range want {
+		if got := created.Translations[lang]; got != text {
+			t.Errorf("%s translation %q, want %q", lang, got, text)
+		}
+	}
+	if _, ok := created.TranslatedSponsoredMessage["es"]; ok || len(created.TranslatedSponsoredMessage) != 3 {
+		t.Errorf("translated sponsored messages %v, want all but es", created.TranslatedSponsoredMessage)
+	}
+	for _, text := range sentToES {
+		if strings.Contains(text, "Acme") {
+			t.Errorf("sent %q to translate into es, want the sponsored message left out", text)
+		}
+	}
+}
+
+func TestInvalidSponsoredPlacementRejected(t *testing.T) {
+	for _, placement := range []string{"de=footer", "de", "=append"} {
+		t.Setenv("SPONSORED_MESSAGE_PLACEMENT", placement)
+		if _, err := loadConfig(); err == nil {
+			t.Errorf("SPONSORED_MESSAGE_PLACEMENT=%q accepted, want an error", placement)
+		}
+	}
+}
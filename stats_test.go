@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func postEventWithStats(t *testing.T, r http.Handler, body string) translationStats {
+	t.Helper()
+	w := serveRequest(r, "POST", "/event?stats=true", body)
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /event?stats=true: status %d, body %s", w.Code, w.Body)
+	}
+	var res eventWithStats
+	decodeJSON(t, w, &res)
+	if res.Stats == nil {
+		t.Fatalf("response %s has no stats", w.Body)
+	}
+	return *res.Stats
+}
+
+func TestEventStats(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	stats := postEventWithStats(t, r, eventBody("Concert", "fr", "de"))
+	if stats.Calls != 2 || stats.CacheHits != 0 {
+		t.Errorf("first event: %d calls, %d cache hits, want 2 and 0", stats.Calls, stats.CacheHits)
+	}
+	if len(stats.LanguageDurations) != 2 {
+		t.Errorf("language durations %v, want fr and de", stats.LanguageDurations)
+	}
+	for _, lang := range []string{"fr", "de"} {
+		if _, ok := stats.LanguageDurations[lang]; !ok {
+			t.Errorf("no duration for %s", lang)
+		}
+	}
+
+	stats = postEventWithStats(t, r, eventBody("Concert", "fr", "de"))
+	if stats.Calls != 0 || stats.CacheHits != 2 {
+		t.Errorf("repeated event: %d calls, %d cache hits, want 0 and 2", stats.Calls, stats.CacheHits)
+	}
+}
+
+func TestEventWithoutStats(t *testing.T) {
+	setupTest(t)
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "fr"))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /event: status %d, body %s", w.Code, w.Body)
+	}
+	if strings.Contains(w.Body.String(), `"stats"`) {
+		t.Fatalf("response %s has stats, want none", w.Body)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"errors"
+	"sort"
+	"sync"
+)
+
+var (
+	errEventExists   = errors.New("event already exists")
+	errEventNotFound = errors.New("event not found")
+	errSlugExists    = errors.New("slug already taken")
+)
+
+// persister saves a snapshot of every stored event so the store survives a
+// restart.
+type persister interface {
+	load() (map[string]EventInfo, error)
+	save(events map[string]EventInfo) error
+}
+
+// eventStore holds the events, keyed by ID. The memory store keeps them in
+// this process while the Redis store shares them between instances; both
+// behave as documented on memoryEventStore.
+type eventStore interface {
+	get(id string) (EventInfo, bool)
+	exists(id string) bool
+	findByName(name string) (EventInfo, bool)
+	findBySlug(slug string) (EventInfo, bool)
+	add(event EventInfo) error
+	update(event EventInfo) error
+	delete(id string) error
+	reset() error
+	list() []EventInfo
+	flush() error
+}
+
+type memoryEventStore struct {
+	sync.RWMutex
+	events map[string]EventInfo
+	// slugs maps each slug to the ID of the event that has it, so slugs are
+	// looked up without scanning every event.
+	slugs     map[string]string
+	persister persister
+}
+
+func newMemoryEventStore() *memoryEventStore {
+	return &memoryEventStore{events: make(map[string]EventInfo), slugs: make(map[string]string)}
+}
+
+// newPersistentEventStore loads any previously saved events and writes every
+// later change back through p.
+func newPersistentEventStore(p persister) (*memoryEventStore, error) {
+	loaded, err := p.load()
+	if err != nil {
+		return nil, err
+	}
+	if loaded == nil {
+		loaded = make(map[string]EventInfo)
+	}
+	// Events saved before they had IDs are keyed by name, which becomes
+	// their ID.
+	for key, event := range loaded {
+		if event.ID == "" {
+			event.ID = key
+			loaded[key] = event
+		}
+	}
+	return &memoryEventStore{events: loaded, slugs: slugIndex(loaded), persister: p}, nil
+}
+
+// slugIndex maps the slug of each event to its ID. Should two saved events
+// share a slug, the first in listing order keeps it.
+func slugIndex(events map[string]EventInfo) map[string]string {
+	list := make([]EventInfo, 0, len(events))
+	for _, event := range events {
+		list = append(list, event)
+	}
+	sortEvents(list)
+	slugs := make(map[string]string, len(list))
+	for _, event := range list {
+		if _, taken := slugs[event.Slug]; !taken && event.Slug != "" {
+			slugs[event.Slug] = event.ID
+		}
+	}
+	return slugs
+}
+
+func (s *memoryEventStore) get(id string) (EventInfo, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	event, ok := s.events[id]
+	return event, ok
+}
+
+func (s *memoryEventStore) exists(id string) bool {
+	_, ok := s.get(id)
+	return ok
+}
+
+// findByName returns the first event in listing order with the given name.
+func (s *memoryEventStore) findByName(name string) (EventInfo, bool) {
+	for _, event := range s.list() {
+		if event.Name == name {
+			return event, true
+		}
+	}
+	return EventInfo{}, false
+}
+
+func (s *memoryEventStore) findBySlug(slug string) (EventInfo, bool) {
+	s.RLock()
+	defer s.RUnlock()
+	return s.eventWithSlug(slug)
+}
+
+func (s *memoryEventStore) eventWithSlug(slug string) (EventInfo, bool) {
+	id, ok := s.slugs[slug]
+	if !ok {
+		return EventInfo{}, false
+	}
+	event, ok := s.events[id]
+	return event, ok
+}
+
+// add stores the event unless one with the same ID is already present, or
+// another event already has its slug.
+func (s *memoryEventStore) add(event EventInfo) error {
+	s.Lock()
+	defer s.Unlock()
+	if _, exists := s.events[event.ID]; exists {
+		return errEventExists
+	}
+	if _, taken := s.eventWithSlug(event.Slug); taken && event.Slug != "" {
+		return errSlugExists
+	}
+	s.events[event.ID] = event
+	if event.Slug != "" {
+		s.slugs[event.Slug] = event.ID
+	}
+	if err := s.persist(); err != nil {
+		delete(s.events, event.ID)
+		delete(s.slugs, event.Slug)
+		return err
+	}
+	return nil
+}
+
+// update replaces an existing event.
+func (s *memoryEventStore) update(event EventInfo) error {
+	s.Lock()
+	defer s.Unlock()
+	previous, exists := s.events[event.ID]
+	if !exists {
+		return errEventNotFound
+	}
+	s.events[event.ID] = event
+	// Events stored before slugs existed get theirs on an update.
+	_, taken := s.slugs[event.Slug]
+	reserved := !taken && event.Slug != ""
+	if reserved {
+		s.slugs[event.Slug] = event.ID
+	}
+	if err := s.persist(); err != nil {
+		s.events[event.ID] = previous
+		if reserved {
+			delete(s.slugs, event.Slug)
+		}
+		return err
+	}
+	return nil
+}
+
+func (s *memoryEventStore) delete(id string) error {
+	s.Lock()
+	defer s.Unlock()
+	previous, exists := s.events[id]
+	if !exists {
+		return errEventNotFound
+	}
+	delete(s.events, id)
+	owned := s.slugs[previous.Slug] == id
+	if owned {
+		delete(s.slugs, previous.Slug)
+	}
+	if err := s.persist(); err != nil {
+		s.events[id] = previous
+		if owned {
+			s.slugs[previous.Slug] = id
+		}
+		return err
+	}
+	return nil
+}
+
+// reset removes every stored event.
+func (s *memoryEventStore) reset() error {
+	s.Lock()
+	defer s.Unlock()
+	previous, previousSlugs := s.events, s.slugs
+	s.events, s.slugs = make(map[string]EventInfo), make(map[string]string)
+	if err := s.persist(); err != nil {
+		s.events, s.slugs = previous, previousSlugs
+		return err
+	}
+	return nil
+}
+
+// list returns every stored event ordered by name, then ID.
+func (s *memoryEventStore) list() []EventInfo {
+	s.RLock()
+	defer s.RUnlock()
+	list := make([]EventInfo, 0, len(s.events))
+	for _, event := range s.events {
+		list = append(list, event)
+	}
+	sortEvents(list)
+	return list
+}
+
+func sortEvents(list []EventInfo) {
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Name != list[j].Name {
+			return list[i].Name < list[j].Name
+		}
+		return list[i].ID < list[j].ID
+	})
+}
+
+// flush writes the current events through the persister, if there is one.
+func (s *memoryEventStore) flush() error {
+	s.Lock()
+	defer s.Unlock()
+	return s.persist()
+}
+
+// persist must be called with the write lock held.
+func (s *memoryEventStore) persist() error {
+	metrics.setEventsStored(len(s.events))
+	if s.persister == nil {
+		return nil
+	}
+	return s.persister.save(s.events)
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+)
+
+func TestMemoryEventStoreConcurrentAccess(t *testing.T) {
+	store := newMemoryEventStore()
+	done := make(chan struct{})
+
+	var reader sync.WaitGroup
+	reader.Add(1)
+	go func() {
+		defer reader.Done()
+		for {
+			select {
+			case <-done:
+				return
+			default:
+			}
+			for _, event := range store.list() {
+				if _, ok := store.get(event.ID); !ok {
+					t.Errorf("listed event %q not found", event.ID)
+				}
+			}
+		}
+	}()
+
+	var writers sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		writers.Add(1)
+		go func(i int) {
+			defer writers.Done()
+			id := fmt.Sprintf("event-%d", i)
+			if err := store.add(EventInfo{ID: id, Name: "Concert " + id}); err != nil {
+				t.Errorf("add %s: %v", id, err)
+			}
+		}(i)
+	}
+	writers.Wait()
+	close(done)
+	reader.Wait()
+
+	if n := len(store.list()); n != 50 {
+		t.Fatalf("stored %d events, want 50", n)
+	}
+}
+
+func TestMemoryEventStoreAddExisting(t *testing.T) {
+	store := newMemoryEventStore()
+	if err := store.add(EventInfo{ID: "a", Name: "Concert"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := store.add(EventInfo{ID: "a", Name: "Other"}); err != errEventExists {
+		t.Fatalf("second add: got %v, want %v", err, errEventExists)
+	}
+}
+
+func TestPersistentEventStoreIndexesSlugs(t *testing.T) {
+	saved := map[string]EventInfo{
+		"a": {ID: "a", Name: "Concert", Slug: "concert"},
+		"b": {ID: "b", Name: "Concert", Slug: "concert"},
+		"c": {ID: "c", Name: "Gala", Slug: "gala"},
+	}
+	store, err := newPersistentEventStore(staticPersister(saved))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if event, ok := store.findBySlug("concert"); !ok || event.ID != "a" {
+		t.Errorf("findBySlug(concert) = %+v, %v, want the first in listing order", event, ok)
+	}
+	if event, ok := store.findBySlug("gala"); !ok || event.ID != "c" {
+		t.Errorf("findBySlug(gala) = %+v, %v, want c", event, ok)
+	}
+	if err := store.reset(); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := store.findBySlug("gala"); ok {
+		t.Error("slug found after reset")
+	}
+}
+
+// staticPersister loads a fixed set of events and discards saves.
+type staticPersister map[string]EventInfo
+
+func (p staticPersister) load() (map[string]EventInfo, error) {
+	loaded := make(map[string]EventInfo, len(p))
+	for id, event := range p {
+		loaded[id] = event
+	}
+	return loaded, nil
+}
+
+func (p staticPersister) save(map[string]EventInfo) error { return nil }
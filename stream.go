@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/gin-gonic/gin"
+)
+
+// languageProgress is the data of a "progress" event of POST
+// /translate/stream, sent as each language finishes.
+type languageProgress struct {
+	Language    string `json:"language"`
+	Fallback    string `json:"fallback,omitempty"`
+	Translation string `json:"translation,omitempty"`
+	Error       string `json:"error,omitempty"`
+}
+
+// streamSummary is the data of the final "done" event.
+type streamSummary struct {
+	Translated int `json:"translated"`
+	Failed     int `json:"failed"`
+}
+
+// streamTranslate translates like POST /translate but answers with a
+// text/event-stream: a "progress" event per language in the order they
+// finish, then a "done" event. Languages still translating when the client
+// goes away are abandoned; those cut off by the translation deadline are
+// reported as failed.
+func streamTranslate(c *gin.Context) {
+	req, options, segments, ok := bindTranslateRequest(c)
+	if !ok {
+		return
+	}
+
+	// Buffered so the translations never block on a client that went away.
+	progress := make(chan languageProgress, len(req.To))
+	ctx, cancel := translationContext(c)
+	defer cancel()
+	go func() {
+		translateLanguages(ctx, req, options, segments, func(lang, fallback, text string, err error) {
+			p := languageProgress{Language: lang, Fallback: fallback, Translation: text}
+			if err != nil {
+				p.Error = err.Error()
+			}
+			progress <- p
+		})
+		close(progress)
+	}()
+
+	c.Header("Cache-Control", "no-cache")
+	// Keeps proxies such as nginx from buffering the stream.
+	c.Header("X-Accel-Buffering", "no")
+
+	var summary streamSummary
+	for {
+		select {
+		case p, ok := <-progress:
+			if !ok {
+				c.SSEvent("done", summary)
+				c.Writer.Flush()
+				return
+			}
+			if p.Error != "" {
+				summary.Failed++
+			} else {
+				summary.Translated++
+			}
+			c.SSEvent("progress", p)
+			c.Writer.Flush()
+		case <-c.Request.Context().Done():
+			return
+		}
+	}
+}
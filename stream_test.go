@@ -0,0 +1,93 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+type streamEvent struct {
+	name string
+	data string
+}
+
+// readStream splits a text/event-stream body into its events.
+func readStream(t *testing.T, body string) []streamEvent {
+	t.Helper()
+	var streamed []streamEvent
+	var current streamEvent
+	scanner := bufio.NewScanner(strings.NewReader(body))
+	for scanner.Scan() {
+		line := scanner.Text()
+		switch {
+		case line == "":
+			if current.name != "" {
+				streamed = append(streamed, current)
+			}
+			current = streamEvent{}
+		case strings.HasPrefix(line, "event:"):
+			current.name = strings.TrimSpace(strings.TrimPrefix(line, "event:"))
+		case strings.HasPrefix(line, "data:"):
+			current.data += strings.TrimSpace(strings.TrimPrefix(line, "data:"))
+		}
+	}
+	if current.name != "" {
+		streamed = append(streamed, current)
+	}
+	return streamed
+}
+
+func TestStreamTranslateProgress(t *testing.T) {
+	setupTest(t)
+	useFakeProvider(func(text, from, to string) (string, error) {
+		if to == "de" {
+			return "", errors.New("unavailable")
+		}
+		return to + ":" + text, nil
+	})
+
+	w := serveRequest(newRouter(), "POST", "/translate/stream", `{"text":"Hello","to":["fr","es","de"]}`)
+	if w.Code != http.StatusOK {
+		t.Fatalf("status %d, body %s", w.Code, w.Body)
+	}
+	if ct := w.Header().Get("Content-Type"); !strings.HasPrefix(ct, "text/event-stream") {
+		t.Fatalf("Content-Type = %q, want text/event-stream", ct)
+	}
+
+	streamed := readStream(t, w.Body.String())
+	if len(streamed) != 4 {
+		t.Fatalf("got %d events, want 3 progress and a done: %v", len(streamed), streamed)
+	}
+	seen := make(map[string]languageProgress)
+	for _, e := range streamed[:3] {
+		if e.name != "progress" {
+			t.Fatalf("event %q before done, want progress", e.name)
+		}
+		var p languageProgress
+		if err := json.Unmarshal([]byte(e.data), &p); err != nil {
+			t.Fatalf("decoding %q: %v", e.data, err)
+		}
+		seen[p.Language] = p
+	}
+	if seen["fr"].Translation != "fr:Hello" || seen["es"].Translation != "es:Hello" {
+		t.Errorf("progress %v, want fr and es translated", seen)
+	}
+	if seen["de"].Error == "" {
+		t.Errorf("progress for de %+v, want an error", seen["de"])
+	}
+
+	done := streamed[3]
+	if done.name != "done" {
+		t.Fatalf("last event %q, want done", done.name)
+	}
+	var summary streamSummary
+	if err := json.Unmarshal([]byte(done.data), &summary); err != nil {
+		t.Fatalf("decoding %q: %v", done.data, err)
+	}
+	if summary.Translated != 2 || summary.Failed != 1 {
+		t.Errorf("summary %+v, want 2 translated and 1 failed", summary)
+	}
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracingServiceName = "custom-translator"
+
+// tracer starts the spans around translation work. Until setupTracing
+// installs a tracer provider it creates no-op spans.
+var tracer = otel.Tracer("CustomTranslator")
+
+// setupTracing exports spans over OTLP/HTTP to cfg.OTLPEndpoint. Without an
+// endpoint tracing stays a no-op. The returned function flushes and stops
+// the exporter.
+func setupTracing(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	if cfg.OTLPEndpoint == "" {
+		return func(context.Context) error { return nil }, nil
+	}
+
+	exporter, err := otlptracehttp.New(ctx, otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint))
+	if err != nil {
+		return nil, err
+	}
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tp.Shutdown, nil
+}
+
+// endSpan records err, including the provider's HTTP status when the upstream
+// API returned one, and ends span.
+func endSpan(span trace.Span, err error) {
+	if err != nil {
+		var upstreamErr providerError
+		if errors.As(err, &upstreamErr) {
+			span.SetAttributes(attribute.Int("http.response.status_code", upstreamErr.HTTPStatus()))
+		}
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"context"
+	"go.opentelemetry.io/otel/attribute"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"testing"
+)
+
+func spanAttribute(span sdktrace.ReadOnlySpan, key attribute.Key) (attribute.Value, bool) {
+	for _, kv := range span.Attributes() {
+		if kv.Key == key {
+			return kv.Value, true
+		}
+	}
+	return attribute.Value{}, false
+}
+
+func TestTranslationSpans(t *testing.T) {
+	setupTest(t)
+	exporter := tracetest.NewInMemoryExporter()
+	tp := sdktrace.NewTracerProvider(sdktrace.WithSyncer(exporter))
+	previous := tracer
+	tracer = tp.Tracer("test")
+	t.Cleanup(func() { tracer = previous })
+
+	ctx, request := tracer.Start(context.Background(), "request")
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr", "de"}}
+	if failures := translateEvent(ctx, &event); len(failures) > 0 {
+		t.Fatal(failures)
+	}
+	request.End()
+
+	spans := exporter.GetSpans().Snapshots()
+	byID := make(map[string]sdktrace.ReadOnlySpan)
+	for _, span := range spans {
+		byID[span.SpanContext().SpanID().String()] = span
+	}
+	languages := make(map[string]bool)
+	calls := 0
+	for _, span := range spans {
+		parent := byID[span.Parent().SpanID().String()]
+		switch span.Name() {
+		case "translate.language":
+			if parent == nil || parent.Name() != "request" {
+				t.Errorf("translate.language span is not a child of the request span")
+			}
+			lang, _ := spanAttribute(span, "translation.target_language")
+			languages[lang.AsString()] = true
+			if hit, ok := spanAttribute(span, "translation.cache_hit"); !ok || hit.AsBool() {
+				t.Errorf("%s: cache_hit %v, %v; want false", lang.AsString(), hit.AsBool(), ok)
+			}
+		case "translator.call":
+			calls++
+			if parent == nil || parent.Name() != "translate.language" {
+				t.Errorf("translator.call span is not a child of a translate.language span")
+			}
+			if p, _ := spanAttribute(span, "translation.provider"); p.AsString() != "mock" {
+				t.Errorf("provider attribute %q, want mock", p.AsString())
+			}
+			if n, _ := spanAttribute(span, "translation.texts"); n.AsInt64() != 1 {
+				t.Errorf("texts attribute %d, want 1", n.AsInt64())
+			}
+		}
+	}
+	if !languages["fr"] || !languages["de"] || len(languages) != 2 {
+		t.Errorf("translate.language spans for %v, want fr and de", languages)
+	}
+	if calls != 2 {
+		t.Errorf("got %d translator.call spans, want 2", calls)
+	}
+}
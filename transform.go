@@ -0,0 +1,79 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"regexp"
+)
+
+// translationTransform post-processes a translation into lang, after its
+// keywords were restored.
+type translationTransform func(lang, text string) string
+
+// transforms holds the registered transforms by canonical language code.
+// Those registered under wildcardLanguage apply to every language, before
+// the language's own. Transforms are registered at startup, before any
+// request is served.
+var transforms = make(map[string][]translationTransform)
+
+// registerTransform adds transform to the ones applied to translations into
+// lang, or into every language when lang is "*".
+func registerTransform(lang string, transform translationTransform) {
+	if lang != wildcardLanguage {
+		lang = canonicalLanguage(lang)
+	}
+	transforms[lang] = append(transforms[lang], transform)
+}
+
+// applyTransforms runs the registered transforms of lang over text in the
+// order they were registered.
+func applyTransforms(lang, text string) string {
+	for _, transform := range transforms[wildcardLanguage] {
+		text = transform(lang, text)
+	}
+	for _, transform := range transforms[canonicalLanguage(lang)] {
+		text = transform(lang, text)
+	}
+	return text
+}
+
+// rewriteRule is one find/replace rule of config.RewritesFile. Find is a
+// regular expression and Replace may refer to its groups as $1 or ${name}.
+// Language is a language code or "*" for every language.
+type rewriteRule struct {
+	Language string `json:"language"`
+	Find     string `json:"find"`
+	Replace  string `json:"replace"`
+}
+
+// loadRewrites registers a transform for every rule of the JSON array in
+// path. An empty path registers none.
+func loadRewrites(path string) error {
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return err
+	}
+	var rules []rewriteRule
+	if err := json.Unmarshal(data, &rules); err != nil {
+		return fmt.Errorf("error decoding rules: %v", err)
+	}
+
+	for i, rule := range rules {
+		if rule.Language == "" {
+			return fmt.Errorf("rule %d has no language", i)
+		}
+		re, err := regexp.Compile(rule.Find)
+		if err != nil {
+			return fmt.Errorf("rule %d: %v", i, err)
+		}
+		replace := rule.Replace
+		registerTransform(rule.Language, func(_, text string) string {
+			return re.ReplaceAllString(text, replace)
+		})
+	}
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestTransformForOneLanguage(t *testing.T) {
+	setupTest(t)
+	registerTransform("de", func(_, text string) string { return strings.ToUpper(text) })
+
+	event := EventInfo{Name: "Jazz", Location: "Hall", Details: "Music", Keywords: []string{"Jazz"}, Languages: []string{"fr", "de"}}
+	created := createEvent(t, newRouter(), mustJSON(event))
+	if want := "[DE] JAZZ LOCATION: HALL DETAILS: MUSIC"; created.Translations["de"] != want {
+		t.Errorf("de translation %q, want %q", created.Translations["de"], want)
+	}
+	if want := "[fr] Jazz Location: Hall Details: Music"; created.Translations["fr"] != want {
+		t.Errorf("fr translation %q, want it untouched: %q", created.Translations["fr"], want)
+	}
+}
+
+func TestTransformsOrder(t *testing.T) {
+	setupTest(t)
+	registerTransform("fr-ca", func(_, text string) string { return text + " (CA)" })
+	registerTransform(wildcardLanguage, func(lang, text string) string { return "<" + text + ">" })
+
+	if got, want := applyTransforms("fr-CA", "Bonjour"), "<Bonjour> (CA)"; got != want {
+		t.Errorf("fr-CA: got %q, want %q", got, want)
+	}
+	if got, want := applyTransforms("fr", "Bonjour"), "<Bonjour>"; got != want {
+		t.Errorf("fr: got %q, want %q", got, want)
+	}
+}
+
+func TestRewritesFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "rewrites.json")
+	rules := `[
+		{"language": "fr", "find": "\\s+([!?])", "replace": " $1"},
+		{"language": "*", "find": "Concert", "replace": "Show"}
+	]`
+	if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	setupTest(t, "TRANSLATION_REWRITES_FILE", path)
+
+	if got, want := applyTransforms("fr", "Concert ce soir !"), "Show ce soir !"; got != want {
+		t.Errorf("fr: got %q, want %q", got, want)
+	}
+	if got, want := applyTransforms("de", "Concert heute !"), "Show heute !"; got != want {
+		t.Errorf("de: got %q, want %q", got, want)
+	}
+}
+
+func TestInvalidRewritesRejected(t *testing.T) {
+	setupTest(t)
+	dir := t.TempDir()
+	for name, rules := range map[string]string{
+		"no-language.json": `[{"find": "a", "replace": "b"}]`,
+		"bad-regexp.json":  `[{"language": "fr", "find": "(", "replace": "b"}]`,
+		"not-json.json":    `{"language": "fr"}`,
+	} {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(rules), 0o600); err != nil {
+			t.Fatal(err)
+		}
+		if err := loadRewrites(path); err == nil {
+			t.Errorf("%s accepted, want an error", name)
+		}
+	}
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"context"
+	"github.com/gin-gonic/gin"
+	"golang.org/x/sync/errgroup"
+	"net/http"
+	"sync"
+)
+
+// translateRequest is the body of POST /translate.
+type translateRequest struct {
+	Text string   `json:"text" validate:"required"`
+	To   []string `json:"to" validate:"required,min=1,dive,required,supported_language"`
+	From string   `json:"from" validate:"omitempty,iso639_1"`
+
+	Keywords                []string                     `json:"keywords" validate:"dive,required"`
+	Glossary                map[string]map[string]string `json:"glossary" validate:"dive,keys,required,endkeys,dive,keys,required,endkeys,required"`
+	CaseInsensitiveKeywords bool                         `json:"caseInsensitiveKeywords"`
+	WholeWordKeywords       bool                         `json:"wholeWordKeywords"`
+	PreserveLiterals        bool                         `json:"preserveLiterals"`
+	TextType                string                       `json:"textType" validate:"omitempty,oneof=plain html"`
+	ProfanityAction         string                       `json:"profanityAction" validate:"omitempty,oneof=NoAction Marked Deleted"`
+	Category                string                       `json:"category" validate:"omitempty,azure_category"`
+}
+
+type translateResponse struct {
+	Translations      map[string]string `json:"translations"`
+	TranslationErrors map[string]string `json:"translationErrors,omitempty"`
+	// Fallbacks maps each language that was translated into a fallback
+	// language instead to that language.
+	Fallbacks map[string]string `json:"fallbacks,omitempty"`
+	// Debug is what was sent to the provider, with ?debug=true.
+	Debug map[string]languageDebug `json:"debug,omitempty"`
+}
+
+// postTranslate translates arbitrary text into every requested language
+// without storing anything. Keywords and glossary terms are handled as they
+// are for events.
+func postTranslate(c *gin.Context) {
+	req, options, segments, ok := bindTranslateRequest(c)
+	if !ok {
+		return
+	}
+
+	var mu sync.Mutex
+	translations := make(map[string]string)
+	failures := make(map[string]error)
+	var fallbacks map[string]string
+	ctx, cancel := translationContext(c)
+	defer cancel()
+	ctx, debug := withTranslationDebug(ctx, c.Query("debug") == "true")
+	translateLanguages(ctx, req, options, segments, func(lang, fallback, text string, err error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if err != nil {
+			failures[lang] = err
+			return
+		}
+		translations[lang] = text
+		if fallback != "" {
+			if fallbacks == nil {
+				fallbacks = make(map[string]string)
+			}
+			fallbacks[lang] = fallback
+		}
+	})
+	if abortIfCanceled(c) {
+		return
+	}
+
+	res := translateResponse{Translations: translations, TranslationErrors: failureMessages(failures), Fallbacks: fallbacks, Debug: debug.snapshot()}
+	if respondIfDeadlineExceeded(c, ctx, failures, res) {
+		return
+	}
+	if len(translations) == 0 && len(failures) > 0 {
+		lang, err := firstFailure(failures)
+		respondTranslationError(c, lang, err)
+		return
+	}
+
+	if len(failures) > 0 {
+		c.JSON(http.StatusMultiStatus, res)
+		return
+	}
+	c.JSON(http.StatusOK, res)
+}
+
+// bindTranslateRequest decodes and validates a translate request, writing an
+// error response and returning false when it is unusable. It also returns the
+// event carrying the request's translation settings and the segment to
+// translate.
+func bindTranslateRequest(c *gin.Context) (translateRequest, EventInfo, []eventSegment, bool) {
+	var req translateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return req, EventInfo{}, nil, false
+	}
+	to, err := expandLanguages(c.Request.Context(), requestedLanguages(req.To), req.From)
+	if err != nil {
+		respondValidationError(c, err)
+		return req, EventInfo{}, nil, false
+	}
+	req.To = to
+	if err := validate.Struct(req); err != nil {
+		respondValidationError(c, err)
+		return req, EventInfo{}, nil, false
+	}
+
+	// The keyword, glossary and translation settings are read from an event so the text
+	// goes through the same path as event translations.
+	options := EventInfo{
+		Keywords:                req.Keywords,
+		Glossary:                req.Glossary,
+		CaseInsensitiveKeywords: req.CaseInsensitiveKeywords,
+		WholeWordKeywords:       req.WholeWordKeywords,
+		PreserveLiterals:        req.PreserveLiterals,
+		TextType:                req.TextType,
+		ProfanityAction:         req.ProfanityAction,
+		Category:                req.Category,
+	}
+	segments := []eventSegment{{role: "text", text: req.Text}}
+	if !checkTextLength(c, segments) {
+		return req, EventInfo{}, nil, false
+	}
+	statsFromContext(c.Request.Context()).setEvent("", len(req.To))
+	return req, options, segments, true
+}
+
+// translateLanguages translates segments into every language of req, running
+// up to config.TranslationConcurrency translations at once, and calls done
+// as each language finishes with the fallback language used, if any. done
+// may be called concurrently.
+func translateLanguages(ctx context.Context, req translateRequest, options EventInfo, segments []eventSegment, done func(lang, fallback, text string, err error)) {
+	var g errgroup.Group
+	if config.TranslationConcurrency > 0 {
+		g.SetLimit(config.TranslationConcurrency)
+	}
+	for _, lang := range req.To {
+		lang := lang
+		g.Go(func() error {
+			translated, fallback, err := translateWithFallback(ctx, options, segments, req.From, lang)
+			if err != nil {
+				done(lang, "", "", err)
+			} else {
+				done(lang, fallback, escapeOutput(translated.segments[0].text), nil)
+			}
+			return nil
+		})
+	}
+	g.Wait()
+}
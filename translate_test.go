@@ -0,0 +1,53 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+)
+
+func postTranslateRequest(t *testing.T, body string) translateResponse {
+	t.Helper()
+	w := serveRequest(newRouter(), "POST", "/translate", body)
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST /translate: status %d, body %s", w.Code, w.Body)
+	}
+	var res translateResponse
+	decodeJSON(t, w, &res)
+	return res
+}
+
+func TestTranslateSingleLanguage(t *testing.T) {
+	setupTest(t)
+	res := postTranslateRequest(t, `{"text":"Hello","to":["fr"]}`)
+	if len(res.Translations) != 1 || res.Translations["fr"] != "[fr] Hello" {
+		t.Fatalf("translations %v, want only fr", res.Translations)
+	}
+	if n := len(events.list()); n != 0 {
+		t.Fatalf("stored %d events, want none", n)
+	}
+}
+
+func TestTranslateMultipleLanguages(t *testing.T) {
+	setupTest(t)
+	res := postTranslateRequest(t, `{"text":"Hello","to":["fr","de","ja"]}`)
+	for _, lang := range []string{"fr", "de", "ja"} {
+		if want := "[" + lang + "] Hello"; res.Translations[lang] != want {
+			t.Errorf("%s: %q, want %q", lang, res.Translations[lang], want)
+		}
+	}
+}
+
+func TestTranslateInvalidLanguage(t *testing.T) {
+	setupTest(t)
+	supportedLanguages = supportedLanguagesFor(Config{Provider: "azure"})
+
+	w := serveRequest(newRouter(), "POST", "/translate", `{"text":"Hello","to":["fr","xx-bogus"]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400: %s", w.Code, w.Body)
+	}
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if _, ok := res.Error.Fields["to[1]"]; !ok {
+		t.Fatalf("fields %v, want to[1] reported", res.Error.Fields)
+	}
+}
@@ -0,0 +1,390 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"math"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+	"unicode/utf8"
+)
+
+// TranslationProvider is implemented by every translation backend.
+type TranslationProvider interface {
+	// Translate translates text into the to language. An empty from lets the
+	// provider detect the source language.
+	Translate(ctx context.Context, text, from, to string, opts TranslateOptions) (string, error)
+}
+
+// TranslateOptions adjusts how a provider translates text.
+type TranslateOptions struct {
+	// TextType is "plain" (the default when empty) or "html". HTML text has
+	// its markup preserved.
+	TextType string
+	// ProfanityAction is Azure's profanityAction: "NoAction" (the default
+	// when empty), "Marked" or "Deleted". Other providers ignore it.
+	ProfanityAction string
+	// Category is Azure's category, the ID of a Custom Translator model.
+	// Empty uses the standard model; other providers ignore it.
+	Category string
+}
+
+func (o TranslateOptions) isHTML() bool {
+	return o.TextType == "html"
+}
+
+// providerError is implemented by the errors providers return when the
+// upstream API answered with a non-OK status.
+type providerError interface {
+	error
+	HTTPStatus() int
+	ErrorCode() int
+	ResponseBody() []byte
+	// RetryDelay is the server's Retry-After hint, or zero.
+	RetryDelay() time.Duration
+	IsAuth() bool
+	IsQuota() bool
+	// IsUnsupportedLanguage reports whether a language of the request is
+	// not supported.
+	IsUnsupportedLanguage() bool
+}
+
+// Errors callers can test for with errors.Is, whichever provider failed.
+// Provider errors match ErrProviderAuth, ErrProviderQuota and
+// ErrUnsupportedLanguage through their Is methods.
+var (
+	ErrProviderAuth        = errors.New("translation provider rejected the credentials")
+	ErrProviderQuota       = errors.New("translation provider quota exceeded")
+	ErrUnsupportedLanguage = errors.New("language not supported by the translation provider")
+	ErrProviderUnreachable = errors.New("error making translation request")
+	ErrTranslationDecode   = errors.New("error decoding response body")
+)
+
+// providerErrorIs implements errors.Is for a providerError.
+func providerErrorIs(e providerError, target error) bool {
+	switch target {
+	case ErrProviderAuth:
+		return e.IsAuth()
+	case ErrProviderQuota:
+		return e.IsQuota()
+	case ErrUnsupportedLanguage:
+		return e.IsUnsupportedLanguage()
+	default:
+		return false
+	}
+}
+
+func newProvider(cfg Config, client *http.Client) (TranslationProvider, error) {
+	switch cfg.Provider {
+	case "azure":
+		return newAzureProvider(cfg, client), nil
+	case "google":
+		return newGoogleProvider(cfg, client), nil
+	case "mock":
+		return mockProvider{}, nil
+	default:
+		return nil, fmt.Errorf("unknown translation provider %q", cfg.Provider)
+	}
+}
+
+var retryableStatuses = map[int]bool{
+	http.StatusTooManyRequests:     true,
+	http.StatusInternalServerError: true,
+	http.StatusBadGateway:          true,
+	http.StatusServiceUnavailable:  true,
+	http.StatusGatewayTimeout:      true,
+}
+
+// translateTexts translates texts into targetLanguage with the configured
+// provider, keeping their order, and reports whether every text was served
+// from the translation cache. Texts already in the cache are served from it
+// and the rest are sent together in as few calls as the request limits allow
+// when the provider supports batching. Transient failures are retried up to
+// config.MaxRetries times with exponential backoff.
+func translateTexts(ctx context.Context, texts []string, sourceLanguage, targetLanguage string, opts TranslateOptions) ([]string, bool, error) {
+	stats := statsFromContext(ctx)
+	results := make([]string, len(texts))
+	var missing []int
+	for i, text := range texts {
+		translated, ok := cache.get(cacheKey{text: text, sourceLanguage: sourceLanguage, targetLanguage: targetLanguage, opts: opts})
+		metrics.observeCache(ok)
+		if ok {
+			stats.recordCacheHit()
+			results[i] = translated
+		} else {
+			missing = append(missing, i)
+		}
+	}
+	if len(missing) == 0 {
+		return results, true, nil
+	}
+
+	pending := make([]string, len(missing))
+	for i, index := range missing {
+		pending[i] = texts[index]
+	}
+
+	var translated []string
+	for _, batch := range batches(pending) {
+		debugFromContext(ctx).recordCall(targetLanguage, batch)
+		var batchTranslated []string
+		err := withRetry(ctx, func() error {
+			ctx, span := tracer.Start(ctx, "translator.call", trace.WithAttributes(
+				attribute.String("translation.provider", config.Provider),
+				attribute.String("translation.target_language", targetLanguage),
+				attribute.Int("translation.texts", len(batch)),
+				attribute.Int("translation.text_length", textLength(batch)),
+			))
+			start := time.Now()
+			var err error
+			batchTranslated, err = translateBatch(ctx, batch, sourceLanguage, targetLanguage, opts)
+			stats.recordCall(time.Since(start))
+			metrics.observeCall(targetLanguage, time.Since(start), err)
+			endSpan(span, err)
+			return err
+		})
+		if err != nil {
+			return nil, false, err
+		}
+		translated = append(translated, batchTranslated...)
+	}
+
+	for i, index := range missing {
+		results[index] = translated[i]
+		cache.add(cacheKey{text: texts[index], sourceLanguage: sourceLanguage, targetLanguage: targetLanguage, opts: opts}, translated[i])
+	}
+	return results, false, nil
+}
+
+func textLength(texts []string) int {
+	length := 0
+	for _, text := range texts {
+		length += utf8.RuneCountInString(text)
+	}
+	return length
+}
+
+// batchTranslationProvider is implemented by providers that can translate
+// several texts in one API call.
+type batchTranslationProvider interface {
+	TranslateBatch(ctx context.Context, texts []string, from, to string, opts TranslateOptions) ([]string, error)
+}
+
+func translateBatch(ctx context.Context, texts []string, from, to string, opts TranslateOptions) ([]string, error) {
+	if batcher, ok := provider.(batchTranslationProvider); ok {
+		translated, err := batcher.TranslateBatch(ctx, texts, from, to, opts)
+		if err != nil {
+			return nil, err
+		}
+		if len(translated) != len(texts) {
+			return nil, fmt.Errorf("%w: expected %d translations, got %d", ErrTranslationDecode, len(texts), len(translated))
+		}
+		return translated, nil
+	}
+
+	translated := make([]string, len(texts))
+	for i, text := range texts {
+		var err error
+		if translated[i], err = provider.Translate(ctx, text, from, to, opts); err != nil {
+			return nil, err
+		}
+	}
+	return translated, nil
+}
+
+// alternativesProvider is implemented by providers that can offer several
+// candidate translations of a text. Each text's candidates start with the
+// primary translation.
+type alternativesProvider interface {
+	TranslateAlternatives(ctx context.Context, texts []string, from, to string, opts TranslateOptions) ([][]string, error)
+}
+
+// translateAlternatives returns candidate translations of texts, by candidate
+// and then by text: the first candidate holds the primary translation of
+// every text. A text with fewer candidates than others repeats its primary
+// translation. Alternatives bypass the translation cache. Providers without
+// alternatives yield a single candidate.
+func translateAlternatives(ctx context.Context, texts []string, sourceLanguage, targetLanguage string, opts TranslateOptions) ([][]string, error) {
+	alternator, ok := provider.(alternativesProvider)
+	if !ok {
+		translated, _, err := translateTexts(ctx, texts, sourceLanguage, targetLanguage, opts)
+		if err != nil {
+			return nil, err
+		}
+		return [][]string{translated}, nil
+	}
+
+	stats := statsFromContext(ctx)
+	var byText [][]string
+	for _, batch := range batches(texts) {
+		debugFromContext(ctx).recordCall(targetLanguage, batch)
+		var batchCandidates [][]string
+		err := withRetry(ctx, func() error {
+			start := time.Now()
+			var err error
+			batchCandidates, err = alternator.TranslateAlternatives(ctx, batch, sourceLanguage, targetLanguage, opts)
+			if err == nil && len(batchCandidates) != len(batch) {
+				err = fmt.Errorf("%w: expected %d translations, got %d", ErrTranslationDecode, len(batch), len(batchCandidates))
+			}
+			stats.recordCall(time.Since(start))
+			metrics.observeCall(targetLanguage, time.Since(start), err)
+			return err
+		})
+		if err != nil {
+			return nil, err
+		}
+		byText = append(byText, batchCandidates...)
+	}
+
+	count := 1
+	for _, candidates := range byText {
+		if len(candidates) > count {
+			count = len(candidates)
+		}
+	}
+	result := make([][]string, count)
+	for k := range result {
+		result[k] = make([]string, len(texts))
+		for i, candidates := range byText {
+			if k < len(candidates) {
+				result[k][i] = candidates[k]
+			} else {
+				result[k][i] = candidates[0]
+			}
+		}
+	}
+	return result, nil
+}
+
+// withRetry calls fn until it succeeds, fails with a non-transient error, the
+// context is done, or config.MaxRetries retries have been made. fn is not
+// called at all once the context is done, or while the circuit breaker is
+// open. Each attempt first waits for its turn from the pacer, then holds one
+// of callSlots while it runs, but not while it backs off.
+func withRetry(ctx context.Context, fn func() error) error {
+	for attempt := 0; ; attempt++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if err := pacer.wait(ctx); err != nil {
+			return err
+		}
+		if err := callSlots.acquire(ctx); err != nil {
+			return err
+		}
+		if !breaker.allow(time.Now()) {
+			callSlots.release()
+			return errCircuitOpen
+		}
+		err := fn()
+		callSlots.release()
+		if ctx.Err() == nil {
+			breaker.record(err, time.Now())
+		}
+		if err == nil {
+			return nil
+		}
+
+		if attempt >= config.MaxRetries || !isRetryable(err) || ctx.Err() != nil {
+			return err
+		}
+
+		delay, ok := retryDelay(err, attempt)
+		if !ok {
+			return err
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// newHTTPClient builds the client shared by every translation call so that
+// connections to the translation API are kept alive and reused. Calls go
+// through cfg.ProxyURL when set and otherwise through the proxy the
+// environment names, if any.
+func newHTTPClient(cfg Config) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if cfg.ProxyURL != nil {
+		transport.Proxy = http.ProxyURL(cfg.ProxyURL)
+	}
+	transport.MaxIdleConns = 100
+	transport.MaxIdleConnsPerHost = cfg.TranslationConcurrency
+	if transport.MaxIdleConnsPerHost < http.DefaultMaxIdleConnsPerHost {
+		transport.MaxIdleConnsPerHost = http.DefaultMaxIdleConnsPerHost
+	}
+	transport.IdleConnTimeout = 90 * time.Second
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.RequestTimeout,
+	}
+}
+
+func isRetryable(err error) bool {
+	var upstreamErr providerError
+	if errors.As(err, &upstreamErr) {
+		return retryableStatuses[upstreamErr.HTTPStatus()]
+	}
+
+	var netErr net.Error
+	return errors.As(err, &netErr)
+}
+
+// retryDelay honors a Retry-After hint when the server sent one and otherwise
+// doubles config.RetryBaseDelay per attempt, adding up to 50% jitter. A hint
+// longer than maxRetryDelay is not worth waiting for, and retryDelay reports
+// false so the error is returned instead.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	var upstreamErr providerError
+	if errors.As(err, &upstreamErr) && upstreamErr.RetryDelay() > 0 {
+		hint := upstreamErr.RetryDelay()
+		return hint, hint <= maxRetryDelay()
+	}
+
+	delay := config.RetryBaseDelay << uint(attempt)
+	if delay <= 0 {
+		return 0, true
+	}
+	return delay + time.Duration(rand.Int63n(int64(delay)/2+1)), true
+}
+
+// maxRetryDelay is the longest wait before a retry: config.RetryBaseDelay
+// doubled config.MaxRetries times, twice the backoff before the last retry,
+// so it covers that backoff with its jitter.
+func maxRetryDelay() time.Duration {
+	limit := config.RetryBaseDelay << uint(config.MaxRetries)
+	if limit>>uint(config.MaxRetries) != config.RetryBaseDelay {
+		// The shift overflowed.
+		return time.Duration(math.MaxInt64)
+	}
+	return limit
+}
+
+// parseRetryAfter accepts both forms of the Retry-After header: a number of
+// seconds or an HTTP date.
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil {
+		if delay := time.Until(date); delay > 0 {
+			return delay
+		}
+	}
+	return 0
+}
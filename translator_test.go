@@ -0,0 +1,222 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// useAzure points the Azure provider at a test server running handler.
+func useAzure(t *testing.T, handler http.HandlerFunc) *httptest.Server {
+	t.Helper()
+	srv := httptest.NewServer(handler)
+	t.Cleanup(srv.Close)
+	config.Provider = "azure"
+	config.Endpoint = srv.URL
+	config.SubscriptionKey = "test-key"
+	provider = newAzureProvider(config, httpClient)
+	return srv
+}
+
+// fakeProvider translates with fn, or by prefixing the text with the target
+// language and a colon when fn is nil, and counts the calls made per target
+// language.
+type fakeProvider struct {
+	sync.Mutex
+	fn    func(text, from, to string) (string, error)
+	calls map[string]int
+}
+
+func useFakeProvider(fn func(text, from, to string) (string, error)) *fakeProvider {
+	p := &fakeProvider{fn: fn, calls: make(map[string]int)}
+	provider = p
+	return p
+}
+
+func (p *fakeProvider) Translate(ctx context.Context, text, from, to string, opts TranslateOptions) (string, error) {
+	p.Lock()
+	p.calls[to]++
+	p.Unlock()
+	if err := ctx.Err(); err != nil {
+		return "", err
+	}
+	if p.fn == nil {
+		return to + ":" + text, nil
+	}
+	return p.fn(text, from, to)
+}
+
+func (p *fakeProvider) callCount(lang string) int {
+	p.Lock()
+	defer p.Unlock()
+	return p.calls[lang]
+}
+
+func (p *fakeProvider) totalCalls() int {
+	p.Lock()
+	defer p.Unlock()
+	total := 0
+	for _, n := range p.calls {
+		total += n
+	}
+	return total
+}
+
+const azureTranslation = `[{"translations":[{"text":"Bonjour","to":"fr"}]}]`
+
+func TestWithRetryRetriesTooManyRequests(t *testing.T) {
+	setupTest(t, "TRANSLATOR_RETRY_BASE_DELAY", "1ms")
+	var hits int32
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&hits, 1) <= 2 {
+			http.Error(w, `{"error":{"code":429001,"message":"Too many requests"}}`, http.StatusTooManyRequests)
+			return
+		}
+		w.Write([]byte(azureTranslation))
+	})
+
+	translated, _, err := translateTexts(context.Background(), []string{"Hello"}, "", "fr", TranslateOptions{})
+	if err != nil {
+		t.Fatalf("translateTexts: %v", err)
+	}
+	if translated[0] != "Bonjour" {
+		t.Fatalf("translated %q, want Bonjour", translated[0])
+	}
+	if hits != 3 {
+		t.Fatalf("server hit %d times, want 3", hits)
+	}
+}
+
+func TestWithRetryGivesUpOnLongRetryAfter(t *testing.T) {
+	setupTest(t, "TRANSLATOR_RETRY_BASE_DELAY", "1ms")
+	var hits int32
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		w.Header().Set("Retry-After", "3600")
+		http.Error(w, `{"error":{"code":429001,"message":"Too many requests"}}`, http.StatusTooManyRequests)
+	})
+
+	start := time.Now()
+	_, _, err := translateTexts(context.Background(), []string{"Hello"}, "", "fr", TranslateOptions{})
+	if !errors.Is(err, ErrProviderQuota) {
+		t.Fatalf("got %v, want the quota error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("took %v, want the hint to be refused without waiting", elapsed)
+	}
+	if hits != 1 {
+		t.Fatalf("server hit %d times, want 1", hits)
+	}
+}
+
+func TestRetryDelayCapsHint(t *testing.T) {
+	setupTest(t, "TRANSLATOR_RETRY_BASE_DELAY", "100ms", "TRANSLATOR_MAX_RETRIES", "2")
+	within := &AzureError{StatusCode: http.StatusTooManyRequests, RetryAfter: 400 * time.Millisecond}
+	if delay, ok := retryDelay(within, 0); !ok || delay != within.RetryAfter {
+		t.Fatalf("retryDelay(%v) = %v, %v; want the hint", within.RetryAfter, delay, ok)
+	}
+	beyond := &AzureError{StatusCode: http.StatusTooManyRequests, RetryAfter: time.Second}
+	if _, ok := retryDelay(beyond, 0); ok {
+		t.Fatalf("retryDelay(%v) accepted a hint beyond the cap", beyond.RetryAfter)
+	}
+}
+
+func TestTranslateTimesOut(t *testing.T) {
+	setupTest(t, "TRANSLATOR_TIMEOUT", "50ms", "TRANSLATOR_MAX_RETRIES", "0")
+	release := make(chan struct{})
+	defer close(release)
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-release:
+		case <-time.After(5 * time.Second):
+		}
+	})
+
+	start := time.Now()
+	_, _, err := translateTexts(context.Background(), []string{"Hello"}, "", "fr", TranslateOptions{})
+	if !isTimeout(err) {
+		t.Fatalf("got %v, want a timeout error", err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("took %v, want the call cut off after the 50ms timeout", elapsed)
+	}
+}
+
+// BenchmarkHTTPClientConnectionReuse reports how many connections each call
+// opens; a shared client keeps it near zero.
+func BenchmarkHTTPClientConnectionReuse(b *testing.B) {
+	var conns int64
+	srv := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(azureTranslation))
+	}))
+	srv.Config.ConnState = func(_ net.Conn, state http.ConnState) {
+		if state == http.StateNew {
+			atomic.AddInt64(&conns, 1)
+		}
+	}
+	srv.Start()
+	defer srv.Close()
+	client := newHTTPClient(Config{RequestTimeout: 5 * time.Second, TranslationConcurrency: 4})
+
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		resp, err := client.Get(srv.URL)
+		if err != nil {
+			b.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&conns))/float64(b.N), "conns/op")
+}
+
+func TestTypedProviderErrors(t *testing.T) {
+	tests := []struct {
+		name       string
+		status     int
+		body       string
+		closed     bool
+		want       error
+		wantStatus int
+	}{
+		{"auth", http.StatusUnauthorized, `{"error":{"code":401000,"message":"The request is not authorized"}}`, false, ErrProviderAuth, http.StatusBadGateway},
+		{"quota", http.StatusForbidden, `{"error":{"code":403001,"message":"Free tier quota exceeded"}}`, false, ErrProviderQuota, http.StatusTooManyRequests},
+		{"unsupported language", http.StatusBadRequest, `{"error":{"code":400036,"message":"The target language is not valid"}}`, false, ErrUnsupportedLanguage, http.StatusBadRequest},
+		{"decode", http.StatusOK, `not json`, false, ErrTranslationDecode, http.StatusBadGateway},
+		{"empty translations", http.StatusOK, `[]`, false, ErrTranslationDecode, http.StatusBadGateway},
+		{"unreachable", 0, "", true, ErrProviderUnreachable, http.StatusBadGateway},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			setupTest(t, "TRANSLATOR_MAX_RETRIES", "0")
+			srv := useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				w.Write([]byte(tt.body))
+			})
+			if tt.closed {
+				srv.Close()
+			}
+
+			_, _, err := translateTexts(context.Background(), []string{"Hello"}, "", "fr", TranslateOptions{})
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("translateTexts: got %v, want %v", err, tt.want)
+			}
+			for _, other := range []error{ErrProviderAuth, ErrProviderQuota, ErrUnsupportedLanguage, ErrTranslationDecode, ErrProviderUnreachable} {
+				if other != tt.want && errors.Is(err, other) {
+					t.Errorf("error %v also matches %v", err, other)
+				}
+			}
+
+			if w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "fr")); w.Code != tt.wantStatus {
+				t.Fatalf("POST /event: status %d, want %d", w.Code, tt.wantStatus)
+			}
+		})
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"strings"
+)
+
+// latinScript is the script transliterations are produced in.
+const latinScript = "Latn"
+
+// transliterationScripts maps target languages written in a non-Latin script
+// to that script's ISO 15924 code. Languages missing here are not
+// transliterated.
+var transliterationScripts = map[string]string{
+	"am":      "Ethi",
+	"ar":      "Arab",
+	"be":      "Cyrl",
+	"bg":      "Cyrl",
+	"bn":      "Beng",
+	"el":      "Grek",
+	"fa":      "Arab",
+	"gu":      "Gujr",
+	"he":      "Hebr",
+	"hi":      "Deva",
+	"ja":      "Jpan",
+	"ka":      "Geor",
+	"kk":      "Cyrl",
+	"kn":      "Knda",
+	"ko":      "Kore",
+	"mk":      "Cyrl",
+	"ml":      "Mlym",
+	"mr":      "Deva",
+	"ne":      "Deva",
+	"or":      "Orya",
+	"pa":      "Guru",
+	"ru":      "Cyrl",
+	"si":      "Sinh",
+	"sr-cyrl": "Cyrl",
+	"ta":      "Taml",
+	"te":      "Telu",
+	"th":      "Thai",
+	"uk":      "Cyrl",
+	"ur":      "Arab",
+	"zh-hans": "Hans",
+	"zh-hant": "Hant",
+}
+
+// transliterationProvider is implemented by providers that can convert text
+// from one script to another.
+type transliterationProvider interface {
+	Transliterate(ctx context.Context, text, language, fromScript, toScript string) (string, error)
+}
+
+// transliterateText renders text, already translated into lang, in Latin
+// script. It reports false when the language or provider does not support
+// transliteration or the call fails, in which case the event simply has no
+// transliteration for lang.
+func transliterateText(ctx context.Context, text, lang string) (string, bool) {
+	fromScript, ok := transliterationScripts[strings.ToLower(lang)]
+	if !ok {
+		return "", false
+	}
+	transliterator, ok := provider.(transliterationProvider)
+	if !ok {
+		return "", false
+	}
+
+	var romanized string
+	err := withRetry(ctx, func() error {
+		var err error
+		romanized, err = transliterator.Transliterate(ctx, text, lang, fromScript, latinScript)
+		return err
+	})
+	if err != nil {
+		logger.Warn("transliteration failed", "language", lang, "error", err)
+		return "", false
+	}
+	return romanized, true
+}
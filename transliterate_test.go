@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+)
+
+func TestTransliterationOnlyWhenRequested(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"ja", "fr"}}
+	plain := createEvent(t, r, mustJSON(event))
+	if plain.Transliterations != nil {
+		t.Fatalf("transliterations %v without transliterate", plain.Transliterations)
+	}
+
+	event.Name, event.Transliterate = "Recital", true
+	romanized := createEvent(t, r, mustJSON(event))
+	if len(romanized.Transliterations) != 1 {
+		t.Fatalf("transliterations %v, want only ja", romanized.Transliterations)
+	}
+	if got, want := romanized.Transliterations["ja"], "[Latn] "+romanized.Translations["ja"]; got != want {
+		t.Fatalf("ja transliteration %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,111 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// putEvent replaces the event event.ID names and returns the stored copy.
+func putEvent(t *testing.T, r http.Handler, event EventInfo) EventInfo {
+	t.Helper()
+	w := serveRequest(r, "PUT", eventLocation(event.ID), mustJSON(event))
+	if w.Code != http.StatusOK {
+		t.Fatalf("PUT: status %d, body %s", w.Code, w.Body)
+	}
+	var updated EventInfo
+	decodeJSON(t, w, &updated)
+	return updated
+}
+
+func TestUpdateTranslatesOnlyNewLanguages(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "fr", "de"))
+	fake := useFakeProvider(nil)
+
+	created.Languages = []string{"fr", "de", "es"}
+	updated := putEvent(t, r, created)
+
+	if fake.callCount("es") != 1 || fake.totalCalls() != 1 {
+		t.Fatalf("calls %v, want es only", fake.calls)
+	}
+	if updated.Translations["fr"] != created.Translations["fr"] || updated.Translations["de"] != created.Translations["de"] {
+		t.Errorf("translations %v, want fr and de kept from %v", updated.Translations, created.Translations)
+	}
+	if updated.Translations["es"] == "" {
+		t.Errorf("translations %v, want es added", updated.Translations)
+	}
+}
+
+func TestUpdateRemovesDroppedLanguages(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "fr", "de"))
+	fake := useFakeProvider(nil)
+
+	created.Languages = []string{"fr"}
+	updated := putEvent(t, r, created)
+
+	if fake.totalCalls() != 0 {
+		t.Errorf("calls %v, want none", fake.calls)
+	}
+	if _, ok := updated.Translations["de"]; ok || len(updated.Translations) != 1 {
+		t.Errorf("translations %v, want only fr", updated.Translations)
+	}
+}
+
+func TestUpdateWithChangedTextRetranslatesAll(t *testing.T) {
+	setupTest(t)
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "fr", "de"))
+	fake := useFakeProvider(nil)
+
+	created.Details = "An afternoon of music"
+	created.Languages = []string{"fr", "de", "es"}
+	putEvent(t, r, created)
+
+	for _, lang := range created.Languages {
+		if fake.callCount(lang) != 1 {
+			t.Errorf("%s translated %d times, want once", lang, fake.callCount(lang))
+		}
+	}
+}
+
+func TestUpdateUnknownEvent(t *testing.T) {
+	setupTest(t)
+	event := EventInfo{ID: "missing", Name: "Concert", Location: "Hall", Details: "Music", Languages: []string{"fr"}}
+
+	w := serveRequest(newRouter(), "PUT", eventLocation(event.ID), mustJSON(event))
+	var body errorResponse
+	decodeJSON(t, w, &body)
+	if w.Code != http.StatusNotFound || body.Error.Code != codeEventNotFound {
+		t.Errorf("PUT unknown event: status %d, body %s, want 404 %s", w.Code, w.Body, codeEventNotFound)
+	}
+	if n := len(events.list()); n != 0 {
+		t.Errorf("stored %d events, want none", n)
+	}
+}
+
+func TestUpdateKeepsEventWhenTranslationFails(t *testing.T) {
+	setupTest(t, "TRANSLATOR_MAX_RETRIES", "0")
+	r := newRouter()
+	created := createEvent(t, r, eventBody("Concert", "fr"))
+	useFakeProvider(func(text, from, to string) (string, error) { return "", errors.New("boom") })
+
+	changed := created
+	changed.Details = "An afternoon of music"
+	changed.Languages = []string{"fr", "de"}
+	w := serveRequest(r, "PUT", eventLocation(created.ID), mustJSON(changed))
+	if w.Code != http.StatusInternalServerError {
+		t.Fatalf("PUT: status %d, body %s, want 500", w.Code, w.Body)
+	}
+
+	stored, ok := events.get(created.ID)
+	if !ok {
+		t.Fatal("event deleted after a failed update")
+	}
+	if stored.Details != created.Details || len(stored.Languages) != 1 || stored.Translations["fr"] != created.Translations["fr"] {
+		t.Errorf("stored %+v, want the event as created: %+v", stored, created)
+	}
+}
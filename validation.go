@@ -0,0 +1,159 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+	"net/http"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+)
+
+// bindEvent decodes and validates the request body into event, writing a 400
+// response and returning false when either step fails.
+func bindEvent(c *gin.Context, event *EventInfo) bool {
+	if err := c.ShouldBindJSON(event); err != nil {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return false
+	}
+	if err := validateEvent(c.Request.Context(), event); err != nil {
+		respondValidationError(c, err)
+		return false
+	}
+	return true
+}
+
+// validateEvent normalizes the event's languages, defaulting them when
+// omitted and expanding a wildcard, and its link URLs, and checks its
+// `validate` tags. Gin's binding only honors `binding` tags, so they are
+// checked here explicitly.
+func validateEvent(ctx context.Context, event *EventInfo) error {
+	languages, err := expandLanguages(ctx, requestedLanguages(event.Languages), event.SourceLanguage)
+	if err != nil {
+		return err
+	}
+	event.Languages = languages
+	if event.LinkNames, err = normalizeLinkNames(event.LinkNames); err != nil {
+		return err
+	}
+	return validate.Struct(event)
+}
+
+// checkTextLength writes a 413 response and returns false when the segments
+// are too long to translate.
+func checkTextLength(c *gin.Context, segments []eventSegment) bool {
+	if err := textLengthError(segments); err != nil {
+		message := err.Error()
+		respondError(c, http.StatusRequestEntityTooLarge, codeTextTooLong, strings.ToUpper(message[:1])+message[1:])
+		return false
+	}
+	return true
+}
+
+// textLengthError reports segments that add up to more than
+// config.MaxTextLength characters. Characters are counted as runes, the way
+// the translation APIs count them.
+func textLengthError(segments []eventSegment) error {
+	if config.MaxTextLength == 0 {
+		return nil
+	}
+	length := 0
+	for _, segment := range segments {
+		length += utf8.RuneCountInString(segment.text)
+	}
+	if length > config.MaxTextLength {
+		return fmt.Errorf("text to translate is %d characters long, the limit is %d", length, config.MaxTextLength)
+	}
+	return nil
+}
+
+// respondValidationError reports each failing field by its JSON name, e.g.
+// {"error":{"code":"validation_failed","message":"The request has invalid fields","fields":{"location":"is required"}}}.
+// The provider failing to list the languages a wildcard expands to is
+// reported as 502.
+func respondValidationError(c *gin.Context, err error) {
+	if errors.Is(err, errLanguagesUnavailable) {
+		respondError(c, http.StatusBadGateway, codeLanguagesUnavailable, err.Error())
+		return
+	}
+	fields, ok := validationErrors(err)
+	if !ok {
+		respondError(c, http.StatusBadRequest, codeInvalidRequest, err.Error())
+		return
+	}
+	c.JSON(http.StatusBadRequest, errorResponse{apiError{
+		Code:    codeValidationFailed,
+		Message: "The request has invalid fields",
+		Fields:  fields,
+	}})
+}
+
+// validationErrors maps each failing field of a validator error to its
+// message, or reports false for any other error.
+func validationErrors(err error) (map[string]string, bool) {
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		return nil, false
+	}
+
+	fields := make(map[string]string, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields[fieldPath(fieldErr)] = validationMessage(fieldErr)
+	}
+	return fields, true
+}
+
+// fieldPath drops the leading struct name from the error's namespace, which
+// is already made of JSON field names.
+func fieldPath(fieldErr validator.FieldError) string {
+	namespace := fieldErr.Namespace()
+	if i := strings.Index(namespace, "."); i >= 0 {
+		return namespace[i+1:]
+	}
+	return namespace
+}
+
+func validationMessage(fieldErr validator.FieldError) string {
+	switch fieldErr.Tag() {
+	case "required":
+		return "is required"
+	case "required_without":
+		return fmt.Sprintf("is required without %s", strings.ToLower(fieldErr.Param()))
+	case "min":
+		return fmt.Sprintf("must have at least %s entries", fieldErr.Param())
+	case "oneof":
+		return fmt.Sprintf("must be one of: %s", strings.Join(strings.Fields(fieldErr.Param()), ", "))
+	case "url", "startswith":
+		return "must be an http or https URL"
+	case "iso639_1":
+		return fmt.Sprintf("%q is not an ISO 639-1 language code", fieldErr.Value())
+	case "supported_language":
+		return fmt.Sprintf("%q is not a supported language", fieldErr.Value())
+	case "azure_category":
+		return fmt.Sprintf("%q is not a Custom Translator category ID", fieldErr.Value())
+	case "link_url":
+		return fmt.Sprintf("%q is not an http or https URL", fieldErr.Value())
+	default:
+		return fmt.Sprintf("failed %s validation", fieldErr.Tag())
+	}
+}
+
+// isAzureCategory reports whether a field holds an Azure category ID.
+func isAzureCategory(fl validator.FieldLevel) bool {
+	return categoryPattern.MatchString(fl.Field().String())
+}
+
+// jsonFieldName makes validation errors refer to fields by their JSON names.
+func jsonFieldName(field reflect.StructField) string {
+	name := strings.SplitN(field.Tag.Get("json"), ",", 2)[0]
+	if name == "-" {
+		return ""
+	}
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"testing"
+)
+
+func TestValidationReportsEveryField(t *testing.T) {
+	setupTest(t)
+	body := `{"name":"Concert","languages":["fr"],"textType":"markdown","sourceLanguage":"xyz"}`
+
+	w := serveRequest(newRouter(), "POST", "/event", body)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400: %s", w.Code, w.Body)
+	}
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if res.Error.Code != codeValidationFailed {
+		t.Errorf("code %q, want %q", res.Error.Code, codeValidationFailed)
+	}
+	want := map[string]string{
+		"location":       "is required",
+		"details":        "is required",
+		"textType":       "must be one of: plain, html",
+		"sourceLanguage": `"xyz" is not an ISO 639-1 language code`,
+	}
+	if len(res.Error.Fields) != len(want) {
+		t.Errorf("fields %v, want %v", res.Error.Fields, want)
+	}
+	for field, message := range want {
+		if res.Error.Fields[field] != message {
+			t.Errorf("%s: %q, want %q", field, res.Error.Fields[field], message)
+		}
+	}
+}
+
+func TestPostEventMissingLocation(t *testing.T) {
+	setupTest(t)
+	fake := useFakeProvider(nil)
+
+	w := serveRequest(newRouter(), "POST", "/event", `{"name":"Concert","details":"Music","languages":["fr"]}`)
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, want 400: %s", w.Code, w.Body)
+	}
+	if n := fake.totalCalls(); n != 0 {
+		t.Fatalf("made %d translation calls for an invalid event, want none", n)
+	}
+	if n := len(events.list()); n != 0 {
+		t.Fatalf("stored %d events, want none", n)
+	}
+}
+
+func TestPostEventTextTooLong(t *testing.T) {
+	setupTest(t, "MAX_TEXT_LENGTH", "100")
+	fake := useFakeProvider(nil)
+	event := EventInfo{Name: "Concert", Location: "Hall", Details: strings.Repeat("music ", 20), Languages: []string{"fr"}}
+
+	w := serveRequest(newRouter(), "POST", "/event", mustJSON(event))
+	if w.Code != http.StatusRequestEntityTooLarge {
+		t.Fatalf("status %d, want 413: %s", w.Code, w.Body)
+	}
+	var res errorResponse
+	decodeJSON(t, w, &res)
+	if res.Error.Code != codeTextTooLong {
+		t.Errorf("code %q, want %q", res.Error.Code, codeTextTooLong)
+	}
+	if !strings.HasPrefix(res.Error.Message, "Text to translate is") {
+		t.Errorf("message %q, want it capitalized", res.Error.Message)
+	}
+	if err := textLengthError(eventSegments(event)); err == nil || !strings.HasPrefix(err.Error(), "text to translate is") {
+		t.Errorf("textLengthError = %v, want a lowercase error", err)
+	}
+	if n := fake.totalCalls(); n != 0 {
+		t.Errorf("made %d translation calls, want none", n)
+	}
+
+	event.Details = "music"
+	if w := serveRequest(newRouter(), "POST", "/event", mustJSON(event)); w.Code != http.StatusCreated {
+		t.Fatalf("short event: status %d, want 201", w.Code)
+	}
+}
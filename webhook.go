@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// backgroundJobs tracks events being translated for a callback and running
+// batches, so shutdown can wait for them.
+var backgroundJobs sync.WaitGroup
+
+// translateInBackground translates and stores event without a client waiting
+// for it, then posts the finished event to its CallbackURL. The event is only
+// stored when at least one language was translated; the callback is sent
+// either way, with TranslationErrors saying what failed.
+func translateInBackground(event EventInfo) {
+	backgroundJobs.Add(1)
+	go func() {
+		defer backgroundJobs.Done()
+		ctx := context.Background()
+
+		failures := translateEvent(ctx, &event)
+		event.TranslationErrors = failureMessages(failures)
+		if len(event.Translations) > 0 {
+			if err := addEvent(&event); err != nil {
+				logger.Error("error storing event", "id", event.ID, "error", err)
+			}
+		}
+
+		if err := sendCallback(ctx, event); err != nil {
+			logger.Warn("callback failed", "id", event.ID, "url", event.CallbackURL, "error", err)
+		}
+	}()
+}
+
+// waitForBackgroundJobs waits until every background translation has
+// finished or ctx is done.
+func waitForBackgroundJobs(ctx context.Context) error {
+	done := make(chan struct{})
+	go func() {
+		backgroundJobs.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// signatureHeader carries the hex HMAC-SHA256 of the callback body, keyed
+// with config.WebhookSecret, as "sha256=<hex>".
+const signatureHeader = "X-Signature-256"
+
+func signBody(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// sendCallback posts event as JSON to its CallbackURL, retrying network
+// errors, 429 and 5xx responses up to config.MaxRetries times with
+// exponential backoff.
+func sendCallback(ctx context.Context, event EventInfo) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("error marshaling json: %v", err)
+	}
+
+	for attempt := 0; ; attempt++ {
+		retry, err := postCallback(ctx, event.CallbackURL, body)
+		if err == nil {
+			return nil
+		}
+		if !retry || attempt >= config.MaxRetries {
+			return err
+		}
+
+		timer := time.NewTimer(config.RetryBaseDelay << uint(attempt))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return err
+		case <-timer.C:
+		}
+	}
+}
+
+// postCallback makes one callback attempt and reports whether a failure is
+// worth retrying.
+func postCallback(ctx context.Context, uri string, body []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, "POST", uri, bytes.NewReader(body))
+	if err != nil {
+		return false, fmt.Errorf("error creating request: %v", err)
+	}
+	req.Header.Add("Content-Type", "application/json")
+	if config.WebhookSecret != "" {
+		req.Header.Add(signatureHeader, signBody(config.WebhookSecret, body))
+	}
+
+	resp, err := callbackClient.Do(req)
+	if err != nil {
+		return true, fmt.Errorf("error making callback request: %w", err)
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return retryableStatuses[resp.StatusCode],
+			fmt.Errorf("non-2xx HTTP status from callback: %d", resp.StatusCode)
+	}
+	return false, nil
+}
+
+// errCallbackAddress is returned for callbacks to an address that is not
+// publicly routable, so a client cannot make the server call its own
+// internal network.
+var errCallbackAddress = errors.New("callback address is not allowed")
+
+// newCallbackClient builds the client callbacks are posted with. Unlike the
+// translation client it dials directly, never through a proxy, so that
+// checkCallbackAddress sees the address each connection really goes to,
+// after DNS resolution and on every redirect.
+func newCallbackClient(cfg Config) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control:   checkCallbackAddress,
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = nil
+	transport.DialContext = dialer.DialContext
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.RequestTimeout,
+	}
+}
+
+// checkCallbackAddress refuses connections to loopback, private, link-local
+// and unspecified addresses.
+func checkCallbackAddress(network, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return err
+	}
+	ip := net.ParseIP(host)
+	if ip == nil || ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() || ip.IsInterfaceLocalMulticast() {
+		return fmt.Errorf("%w: %s", errCallbackAddress, host)
+	}
+	return nil
+}
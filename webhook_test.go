@@ -0,0 +1,106 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// callbackReceiver starts a server that passes each callback it receives to
+// the returned channel. The server listens on loopback, which the callback
+// client refuses, so callbacks are sent with the server's own client.
+func callbackReceiver(t *testing.T) (*httptest.Server, <-chan receivedCallback) {
+	t.Helper()
+	received := make(chan receivedCallback, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		received <- receivedCallback{signature: r.Header.Get(signatureHeader), body: body}
+	}))
+	t.Cleanup(srv.Close)
+	callbackClient = srv.Client()
+	return srv, received
+}
+
+type receivedCallback struct {
+	signature string
+	body      []byte
+}
+
+func TestPostEventWithCallback(t *testing.T) {
+	setupTest(t, "WEBHOOK_SECRET", "s3cret")
+	srv, received := callbackReceiver(t)
+	r := newRouter()
+
+	event := EventInfo{Name: "Concert", Location: "Town Hall", Details: "Music", Languages: []string{"fr"}, CallbackURL: srv.URL}
+	w := serveRequest(r, "POST", "/event", mustJSON(event))
+	if w.Code != http.StatusAccepted {
+		t.Fatalf("POST /event: status %d, body %s", w.Code, w.Body)
+	}
+
+	var callback receivedCallback
+	select {
+	case callback = <-received:
+	case <-time.After(5 * time.Second):
+		t.Fatal("no callback received")
+	}
+	if want := signBody("s3cret", callback.body); callback.signature != want {
+		t.Errorf("%s = %q, want %q", signatureHeader, callback.signature, want)
+	}
+	var finished EventInfo
+	if err := json.Unmarshal(callback.body, &finished); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := finished.Translations["fr"]; len(finished.Translations) != 1 || !ok {
+		t.Errorf("callback translations = %+v, want one in fr", finished.Translations)
+	}
+
+	if err := waitForBackgroundJobs(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := events.get(finished.ID); !ok {
+		t.Errorf("event %q not stored", finished.ID)
+	}
+}
+
+func TestCallbackToLoopbackRefused(t *testing.T) {
+	setupTest(t)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("callback reached a loopback address")
+	}))
+	defer srv.Close()
+
+	_, err := postCallback(context.Background(), srv.URL, []byte("{}"))
+	if !errors.Is(err, errCallbackAddress) {
+		t.Fatalf("postCallback: got %v, want %v", err, errCallbackAddress)
+	}
+}
+
+func TestCheckCallbackAddress(t *testing.T) {
+	tests := []struct {
+		address string
+		allowed bool
+	}{
+		{"93.184.216.34:443", true},
+		{"[2606:2800:220:1:248:1893:25c8:1946]:443", true},
+		{"127.0.0.1:80", false},
+		{"[::1]:80", false},
+		{"10.1.2.3:80", false},
+		{"172.16.0.1:80", false},
+		{"192.168.1.1:80", false},
+		{"169.254.169.254:80", false},
+		{"[fe80::1]:80", false},
+		{"[fd00::1]:80", false},
+		{"0.0.0.0:80", false},
+	}
+	for _, tt := range tests {
+		err := checkCallbackAddress("tcp", tt.address, nil)
+		if allowed := err == nil; allowed != tt.allowed {
+			t.Errorf("checkCallbackAddress(%q) = %v, want allowed %v", tt.address, err, tt.allowed)
+		}
+	}
+}
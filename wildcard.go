@@ -0,0 +1,56 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// wildcardLanguage requests every language the provider translates into.
+const wildcardLanguage = "*"
+
+// errLanguagesUnavailable wraps the provider failing to list its languages
+// while a wildcard was being expanded.
+var errLanguagesUnavailable = errors.New("error listing languages")
+
+// expandLanguages replaces a lone "*" in normalized languages with every
+// language of the catalog except from, the source language. Catalog codes
+// the supported_language validator would reject are left out, so a catalog
+// newer than the built-in tables cannot fail the request. Mixing "*" with
+// explicit codes is rejected, as is a wildcard expanding to more than
+// config.WildcardLanguageLimit languages; a zero limit disables wildcards.
+func expandLanguages(ctx context.Context, languages []string, from string) ([]string, error) {
+	wildcard := false
+	for _, lang := range languages {
+		if lang == wildcardLanguage {
+			wildcard = true
+		}
+	}
+	if !wildcard {
+		return languages, nil
+	}
+	if len(languages) > 1 {
+		return nil, fmt.Errorf("%q cannot be combined with other languages", wildcardLanguage)
+	}
+	if config.WildcardLanguageLimit == 0 {
+		return nil, fmt.Errorf("%q is not enabled", wildcardLanguage)
+	}
+
+	available, err := catalog.list(ctx, time.Now())
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", errLanguagesUnavailable, err)
+	}
+	codes := make([]string, 0, len(available))
+	for _, language := range available {
+		if !strings.EqualFold(language.Code, from) && languageSupported(language.Code) {
+			codes = append(codes, language.Code)
+		}
+	}
+	expanded := normalizeLanguages(codes)
+	if len(expanded) > config.WildcardLanguageLimit {
+		return nil, fmt.Errorf("%q expands to %d languages, the limit is %d", wildcardLanguage, len(expanded), config.WildcardLanguageLimit)
+	}
+	return expanded, nil
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"net/http"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+const wildcardLanguagesBody = `{
+	"translation": {
+		"en": {"name": "English", "nativeName": "English", "dir": "ltr"},
+		"fr": {"name": "French", "nativeName": "Français", "dir": "ltr"},
+		"de": {"name": "German", "nativeName": "Deutsch", "dir": "ltr"},
+		"xx": {"name": "Newer", "nativeName": "Newer", "dir": "ltr"}
+	}
+}`
+
+// useWildcardAzure serves wildcardLanguagesBody from GET /languages and
+// echoes translations, accepting the catalog's languages except xx, which
+// stands for a language the provider added after the built-in tables.
+func useWildcardAzure(t *testing.T) {
+	t.Helper()
+	useAzure(t, func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/languages" {
+			w.Write([]byte(wildcardLanguagesBody))
+			return
+		}
+		echoAzure(nil)(w, r)
+	})
+	supportedLanguages = languageSet("en", "fr", "de")
+}
+
+func TestWildcardExpandsToCatalog(t *testing.T) {
+	setupTest(t, "WILDCARD_LANGUAGE_LIMIT", "10")
+	useWildcardAzure(t)
+
+	event := EventInfo{Name: "Concert", Location: "Town Hall", Details: "Music", SourceLanguage: "en", Languages: []string{"*"}}
+	w := serveRequest(newRouter(), "POST", "/event", mustJSON(event))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("POST /event: status %d, body %s", w.Code, w.Body)
+	}
+	var res EventInfo
+	decodeJSON(t, w, &res)
+	languages := append([]string(nil), res.Languages...)
+	sort.Strings(languages)
+	if want := []string{"de", "fr"}; !reflect.DeepEqual(languages, want) {
+		t.Errorf("languages %v, want %v without the source and unsupported languages", res.Languages, want)
+	}
+	if len(res.Translations) != 2 {
+		t.Errorf("translations %v, want de and fr", res.Translations)
+	}
+}
+
+func TestWildcardOverLimitRejected(t *testing.T) {
+	setupTest(t, "WILDCARD_LANGUAGE_LIMIT", "1")
+	useWildcardAzure(t)
+
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "*"))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, body %s, want 400 for a wildcard over the limit", w.Code, w.Body)
+	}
+}
+
+func TestWildcardRejectedWithOtherLanguages(t *testing.T) {
+	setupTest(t, "WILDCARD_LANGUAGE_LIMIT", "10")
+	useWildcardAzure(t)
+
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "*", "fr"))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, body %s, want 400 for a wildcard mixed with codes", w.Code, w.Body)
+	}
+}
+
+func TestWildcardDisabledByDefault(t *testing.T) {
+	setupTest(t)
+
+	w := serveRequest(newRouter(), "POST", "/event", eventBody("Concert", "*"))
+	if w.Code != http.StatusBadRequest {
+		t.Fatalf("status %d, body %s, want 400 with wildcards disabled", w.Code, w.Body)
+	}
+}